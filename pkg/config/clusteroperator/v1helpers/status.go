@@ -141,3 +141,21 @@ func IsStatusConditionNotIn(conditions []configv1.ClusterOperatorStatusCondition
 	}
 	return true
 }
+
+// SetOperandVersionWhenStable sets operandVersion in status.Versions, replacing the version of the operand with
+// the same name, only when the conditions of status report the operand Available and not Progressing. This avoids
+// reporting a version in the middle of a rollout, before the operand runs it. It returns true when operandVersion
+// is reported in status.Versions.
+func SetOperandVersionWhenStable(status *configv1.ClusterOperatorStatus, operandVersion configv1.OperandVersion) bool {
+	if !IsStatusConditionTrue(status.Conditions, configv1.OperatorAvailable) || !IsStatusConditionFalse(status.Conditions, configv1.OperatorProgressing) {
+		return false
+	}
+	for i := range status.Versions {
+		if status.Versions[i].Name == operandVersion.Name {
+			status.Versions[i].Version = operandVersion.Version
+			return true
+		}
+	}
+	status.Versions = append(status.Versions, operandVersion)
+	return true
+}
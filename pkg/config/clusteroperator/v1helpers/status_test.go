@@ -83,3 +83,63 @@ func TestGetStatusConditionDiff(t *testing.T) {
 		})
 	}
 }
+
+func TestSetOperandVersionWhenStable(t *testing.T) {
+	available := configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue}
+	unavailable := configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorAvailable, Status: configv1.ConditionFalse}
+	progressing := configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorProgressing, Status: configv1.ConditionTrue}
+	notProgressing := configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorProgressing, Status: configv1.ConditionFalse}
+	previous := []configv1.OperandVersion{{Name: "operator", Version: "4.15.0"}, {Name: "kube-apiserver", Version: "1.28.0"}}
+
+	tests := []struct {
+		name             string
+		conditions       []configv1.ClusterOperatorStatusCondition
+		versions         []configv1.OperandVersion
+		expectedSet      bool
+		expectedVersions []configv1.OperandVersion
+	}{
+		{
+			name:             "withheld while progressing",
+			conditions:       []configv1.ClusterOperatorStatusCondition{available, progressing},
+			versions:         previous,
+			expectedVersions: previous,
+		},
+		{
+			name:             "withheld while unavailable",
+			conditions:       []configv1.ClusterOperatorStatusCondition{unavailable, notProgressing},
+			versions:         previous,
+			expectedVersions: previous,
+		},
+		{
+			name:       "withheld without conditions",
+			conditions: nil,
+		},
+		{
+			name:             "set when available and not progressing",
+			conditions:       []configv1.ClusterOperatorStatusCondition{available, notProgressing},
+			versions:         previous,
+			expectedSet:      true,
+			expectedVersions: []configv1.OperandVersion{{Name: "operator", Version: "4.16.0"}, {Name: "kube-apiserver", Version: "1.28.0"}},
+		},
+		{
+			name:             "added when available and not progressing",
+			conditions:       []configv1.ClusterOperatorStatusCondition{notProgressing, available},
+			expectedSet:      true,
+			expectedVersions: []configv1.OperandVersion{{Name: "operator", Version: "4.16.0"}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			status := &configv1.ClusterOperatorStatus{
+				Conditions: tc.conditions,
+				Versions:   append([]configv1.OperandVersion(nil), tc.versions...),
+			}
+			if set := SetOperandVersionWhenStable(status, configv1.OperandVersion{Name: "operator", Version: "4.16.0"}); set != tc.expectedSet {
+				t.Errorf("expected set to be %v, got %v", tc.expectedSet, set)
+			}
+			if !reflect.DeepEqual(status.Versions, tc.expectedVersions) {
+				t.Errorf("expected versions %v, got %v", tc.expectedVersions, status.Versions)
+			}
+		})
+	}
+}
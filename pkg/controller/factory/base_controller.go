@@ -4,18 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
 	applyoperatorv1 "github.com/openshift/client-go/operator/applyconfigurations/operator/v1"
 
 	"github.com/robfig/cron"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclientv1 "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/library-go/pkg/operator/management"
@@ -28,6 +33,15 @@ var SyntheticRequeueError = errors.New("synthetic requeue request")
 
 var defaultCacheSyncTimeout = 10 * time.Minute
 
+// maxResyncJitter is the largest fraction of the resync interval the resync may be moved by.
+const maxResyncJitter = 0.5
+
+// crdEstablishedPollInterval is how often the controller checks whether the CRDs it waits for are established.
+var crdEstablishedPollInterval = time.Second
+
+// resyncJitterRand returns a random number in [0.0,1.0), it is replaced in unit tests.
+var resyncJitterRand = rand.Float64
+
 // baseController represents generic Kubernetes controller boiler-plate
 type baseController struct {
 	name                   string
@@ -36,14 +50,32 @@ type baseController struct {
 	sync                   func(ctx context.Context, controllerContext SyncContext) error
 	syncContext            SyncContext
 	syncDegradedClient     operatorv1helpers.OperatorClient
+	syncStatusClient       operatorv1helpers.OperatorClient
 	resyncEvery            time.Duration
+	resyncJitter           float64
 	resyncSchedules        []cron.Schedule
 	postStartHooks         []PostStartHook
 	cacheSyncTimeout       time.Duration
+	heartbeatInterval      time.Duration
+	heartbeatReason        string
+	changesOnlyEvents      bool
+	syncDebounce           time.Duration
+	contextValues          []contextValue
+	crdClient              apiextensionsclientv1.CustomResourceDefinitionsGetter
+	crdsToWait             []string
+	crdWaitTimeout         time.Duration
+	metricsRecorder        MetricsRecorderFunc
+	clock                  clock.WithTicker
+	syncPreconditions      []SyncPreconditionFunc
 }
 
 var _ Controller = &baseController{}
 
+// contextValue is a value attached to the context of every sync.
+type contextValue struct {
+	key, value interface{}
+}
+
 // Name returns a controller name.
 func (c baseController) Name() string {
 	return c.name
@@ -84,10 +116,82 @@ func waitForNamedCacheSync(controllerName string, stopCh <-chan struct{}, cacheS
 	return nil
 }
 
+// waitForCRDsEstablished polls the given CRDs until all of them have the Established condition set to True.
+func waitForCRDsEstablished(ctx context.Context, clock clock.WithTicker, controllerName string, client apiextensionsclientv1.CustomResourceDefinitionsGetter, timeout time.Duration, crdNames ...string) error {
+	klog.Infof("Waiting for CRDs %v to be established for %s", crdNames, controllerName)
+
+	deadline := clock.Now().Add(timeout)
+	for {
+		established, err := crdsEstablished(ctx, controllerName, client, crdNames...)
+		if err != nil {
+			return fmt.Errorf("CRDs %v are not established for %s: %w", crdNames, controllerName, err)
+		}
+		if established {
+			break
+		}
+		if !clock.Now().Before(deadline) {
+			return fmt.Errorf("CRDs %v are not established for %s: %w", crdNames, controllerName, context.DeadlineExceeded)
+		}
+		timer := clock.NewTimer(crdEstablishedPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("CRDs %v are not established for %s: %w", crdNames, controllerName, ctx.Err())
+		case <-timer.C():
+		}
+	}
+
+	klog.Infof("CRDs %v are established for %s", crdNames, controllerName)
+	return nil
+}
+
+// crdsEstablished returns true if all the given CRDs exist and are established.
+func crdsEstablished(ctx context.Context, controllerName string, client apiextensionsclientv1.CustomResourceDefinitionsGetter, crdNames ...string) (bool, error) {
+	for _, name := range crdNames {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		crd, err := client.CustomResourceDefinitions().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				klog.V(2).Infof("Unable to get CRD %s for %s: %v", name, controllerName, err)
+			}
+			return false, nil
+		}
+		if !crdEstablished(crd) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func crdEstablished(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	for _, condition := range crd.Status.Conditions {
+		if condition.Type == apiextensionsv1.Established {
+			return condition.Status == apiextensionsv1.ConditionTrue
+		}
+	}
+	return false
+}
+
 func (c *baseController) Run(ctx context.Context, workers int) {
 	// HandleCrash recovers panics
 	defer utilruntime.HandleCrash(c.degradedPanicHandler)
 
+	// the informers of custom resources cannot sync until their CRDs are established
+	if len(c.crdsToWait) > 0 {
+		if err := waitForCRDsEstablished(ctx, c.clock, c.name, c.crdClient, c.crdWaitTimeout, c.crdsToWait...); err != nil {
+			select {
+			case <-ctx.Done():
+				// Exit gracefully because the controller was requested to stop.
+				return
+			default:
+				// The control loops would never start, exit with a good message like when the caches do not sync.
+				klog.Exit(err)
+			}
+		}
+	}
+
 	// give caches 10 minutes to sync
 	cacheSyncCtx, cacheSyncCancel := context.WithTimeout(ctx, c.cacheSyncTimeout)
 	defer cacheSyncCancel()
@@ -147,7 +251,16 @@ func (c *baseController) Run(ctx context.Context, workers int) {
 		}
 		go func() {
 			defer workerWg.Done()
-			wait.UntilWithContext(ctx, func(ctx context.Context) { c.syncContext.Queue().Add(DefaultQueueKey) }, c.resyncEvery)
+			c.runPeriodicalResync(ctx)
+		}()
+	}
+
+	// heartbeats are independent from queue
+	if c.heartbeatInterval > 0 {
+		workerWg.Add(1)
+		go func() {
+			defer workerWg.Done()
+			c.runHeartbeat(ctx)
 		}()
 	}
 
@@ -181,6 +294,42 @@ func (c *baseController) Run(ctx context.Context, workers int) {
 	klog.Infof("Shutting down %s ...", c.name)
 }
 
+// runPeriodicalResync queues a sync every jittered resync interval until the context is cancelled.
+func (c *baseController) runPeriodicalResync(ctx context.Context) {
+	for {
+		c.syncContext.Queue().Add(DefaultQueueKey)
+		timer := c.clock.NewTimer(c.jitteredResyncInterval())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+	}
+}
+
+// jitteredResyncInterval returns the resync interval moved by a random fraction within +/- resyncJitter.
+func (c *baseController) jitteredResyncInterval() time.Duration {
+	if c.resyncJitter <= 0 {
+		return c.resyncEvery
+	}
+	return time.Duration(float64(c.resyncEvery) * (1 + c.resyncJitter*(2*resyncJitterRand()-1)))
+}
+
+// runHeartbeat records a Normal event every heartbeatInterval until the context is cancelled.
+func (c *baseController) runHeartbeat(ctx context.Context) {
+	ticker := c.clock.NewTicker(c.heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			c.syncContext.Recorder().Eventf(c.heartbeatReason, "Controller %q is alive", c.name)
+		}
+	}
+}
+
 func (c *baseController) Sync(ctx context.Context, syncCtx SyncContext) error {
 	return c.sync(ctx, syncCtx)
 }
@@ -199,15 +348,40 @@ func (c *baseController) runWorker(queueCtx context.Context) {
 					return
 				default:
 					c.processNextWorkItem(queueCtx)
+					c.waitSyncDebounce(queueCtx)
 				}
 			}
 		},
 		1*time.Second)
 }
 
+// waitSyncDebounce waits for the sync debounce interval, keys queued in the meantime are coalesced by the queue.
+func (c *baseController) waitSyncDebounce(ctx context.Context) {
+	if c.syncDebounce <= 0 {
+		return
+	}
+	timer := c.clock.NewTimer(c.syncDebounce)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C():
+	}
+}
+
 // reconcile wraps the sync() call and if operator client is set, it handle the degraded condition if sync() returns an error.
 func (c *baseController) reconcile(ctx context.Context, syncCtx SyncContext) error {
-	err := c.sync(ctx, syncCtx)
+	for _, v := range c.contextValues {
+		ctx = context.WithValue(ctx, v.key, v.value)
+	}
+	met, err := c.syncPreconditionsMet(ctx)
+	if err == nil && !met {
+		// the prerequisites are not a failure of this controller, retry later without reporting it
+		return SyntheticRequeueError
+	}
+	if err == nil {
+		err = c.syncWithMetrics(ctx, syncCtx)
+	}
+	c.reportSyncStatus(ctx, err)
 	degradedErr := c.reportDegraded(ctx, err)
 	if apierrors.IsNotFound(degradedErr) && management.IsOperatorRemovable() {
 		// The operator tolerates missing CR, therefore don't report it up.
@@ -216,6 +390,38 @@ func (c *baseController) reconcile(ctx context.Context, syncCtx SyncContext) err
 	return degradedErr
 }
 
+// syncPreconditionsMet returns true if all sync preconditions are met.
+func (c *baseController) syncPreconditionsMet(ctx context.Context) (bool, error) {
+	for _, precondition := range c.syncPreconditions {
+		met, err := precondition(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to evaluate sync precondition: %w", err)
+		}
+		if !met {
+			klog.V(4).Infof("%q controller sync precondition is not met, requeueing", c.name)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// syncWithMetrics runs sync and reports its duration and error to the metrics recorder. A panic of sync is reported
+// as an error before it is propagated to the panic handlers.
+func (c *baseController) syncWithMetrics(ctx context.Context, syncCtx SyncContext) (err error) {
+	if c.metricsRecorder == nil {
+		return c.sync(ctx, syncCtx)
+	}
+	start := c.clock.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			c.metricsRecorder(c.name, c.clock.Since(start), fmt.Errorf("panic caught:\n%v", r))
+			panic(r)
+		}
+		c.metricsRecorder(c.name, c.clock.Since(start), err)
+	}()
+	return c.sync(ctx, syncCtx)
+}
+
 // degradedPanicHandler will go degraded on failures, then we should catch potential panics and covert them into bad status.
 func (c *baseController) degradedPanicHandler(panicVal interface{}) {
 	if c.syncDegradedClient == nil {
@@ -253,6 +459,26 @@ func (c *baseController) reportDegraded(ctx context.Context, reportedError error
 	return updateErr
 }
 
+// reportSyncStatus updates status with the outcome of the last sync
+func (c *baseController) reportSyncStatus(ctx context.Context, syncErr error) {
+	if c.syncStatusClient == nil || syncErr == SyntheticRequeueError {
+		return
+	}
+	condition := applyoperatorv1.OperatorCondition().
+		WithType(c.name + "Synced").
+		WithStatus(operatorv1.ConditionTrue).
+		WithReason("AsExpected")
+	if syncErr != nil {
+		condition = condition.
+			WithStatus(operatorv1.ConditionFalse).
+			WithReason("SyncError").
+			WithMessage(syncErr.Error())
+	}
+	if err := c.syncStatusClient.ApplyOperatorStatus(ctx, ControllerFieldManager(c.name, "reportSyncStatus"), applyoperatorv1.OperatorStatus().WithConditions(condition)); err != nil {
+		klog.Warningf("Updating sync status of %q failed: %v", c.Name(), err)
+	}
+}
+
 func (c *baseController) processNextWorkItem(queueCtx context.Context) {
 	key, quit := c.syncContext.Queue().Get()
 	if quit {
@@ -268,6 +494,18 @@ func (c *baseController) processNextWorkItem(queueCtx context.Context) {
 		return
 	}
 
+	if queue, ok := syncCtx.queue.(*sharedKeysQueue); ok && !queue.keys.startSync(queue, syncCtx.queueKey) {
+		klog.V(5).Infof("%q controller skipped key %q already synced by another controller", c.name, key)
+		c.syncContext.Queue().Forget(key)
+		return
+	}
+
+	if c.changesOnlyEvents {
+		recorder := newChangesOnlyRecorder(syncCtx.eventRecorder)
+		syncCtx.eventRecorder = recorder
+		defer recorder.flush()
+	}
+
 	if err := c.reconcile(queueCtx, syncCtx); err != nil {
 		if err == SyntheticRequeueError {
 			// logging this helps detecting wedged controllers with missing pre-requirements
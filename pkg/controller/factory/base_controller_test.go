@@ -5,13 +5,22 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclientv1 "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 )
@@ -132,6 +141,132 @@ func TestBaseController_Reconcile(t *testing.T) {
 	}
 }
 
+func TestBaseController_ReconcileSyncStatus(t *testing.T) {
+	operatorClient := v1helpers.NewFakeOperatorClient(
+		&operatorv1.OperatorSpec{},
+		&operatorv1.OperatorStatus{},
+		nil,
+	)
+	c := New().WithSync(func(ctx context.Context, controllerContext SyncContext) error {
+		return nil
+	}).WithSyncStatus(operatorClient).ToController("TestController", eventstesting.NewTestingEventRecorder(t)).(*baseController)
+
+	if err := c.reconcile(context.TODO(), NewSyncContext("TestController", eventstesting.NewTestingEventRecorder(t))); err != nil {
+		t.Fatal(err)
+	}
+	_, status, _, err := operatorClient.GetOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v1helpers.IsOperatorConditionPresentAndEqual(status.Conditions, "TestControllerSynced", "True") {
+		t.Fatalf("expected TestControllerSynced to be True, got %#v", status.Conditions)
+	}
+	if v1helpers.FindOperatorCondition(status.Conditions, "TestControllerDegraded") != nil {
+		t.Errorf("expected no TestControllerDegraded condition, got %#v", status.Conditions)
+	}
+
+	c.sync = func(ctx context.Context, controllerContext SyncContext) error {
+		return fmt.Errorf("error")
+	}
+	if err := c.reconcile(context.TODO(), NewSyncContext("TestController", eventstesting.NewTestingEventRecorder(t))); err == nil {
+		t.Fatal("expected error, got none")
+	}
+	_, status, _, err = operatorClient.GetOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	condition := v1helpers.FindOperatorCondition(status.Conditions, "TestControllerSynced")
+	if condition == nil || condition.Status != operatorv1.ConditionFalse {
+		t.Fatalf("expected TestControllerSynced to be False, got %#v", status.Conditions)
+	}
+	if condition.Reason != "SyncError" {
+		t.Errorf("expected condition reason 'SyncError', got %q", condition.Reason)
+	}
+	if condition.Message != "error" {
+		t.Errorf("expected condition message 'error', got %q", condition.Message)
+	}
+
+	// a synthetic requeue does not change the published outcome
+	c.sync = func(ctx context.Context, controllerContext SyncContext) error {
+		return SyntheticRequeueError
+	}
+	_ = c.reconcile(context.TODO(), NewSyncContext("TestController", eventstesting.NewTestingEventRecorder(t)))
+	_, status, _, err = operatorClient.GetOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v1helpers.IsOperatorConditionPresentAndEqual(status.Conditions, "TestControllerSynced", "False") {
+		t.Fatalf("expected TestControllerSynced to stay False, got %#v", status.Conditions)
+	}
+}
+
+func TestBaseController_SyncPrecondition(t *testing.T) {
+	operatorClient := v1helpers.NewFakeOperatorClient(
+		&operatorv1.OperatorSpec{},
+		&operatorv1.OperatorStatus{},
+		nil,
+	)
+	syncs := 0
+	ready := false
+	var preconditionErr error
+	syncCtx := NewSyncContext("TestController", eventstesting.NewTestingEventRecorder(t))
+	c := New().WithSync(func(ctx context.Context, controllerContext SyncContext) error {
+		syncs++
+		return nil
+	}).WithSyncPrecondition(func(ctx context.Context) (bool, error) {
+		return ready, preconditionErr
+	}).WithSyncContext(syncCtx).WithSyncDegradedOnError(operatorClient).ToController("TestController", eventstesting.NewTestingEventRecorder(t)).(*baseController)
+
+	// an unmet precondition skips the sync and requeues the key with backoff, without going degraded
+	syncCtx.Queue().Add(DefaultQueueKey)
+	c.processNextWorkItem(context.TODO())
+	if syncs != 0 {
+		t.Errorf("expected the sync to be skipped, got %d syncs", syncs)
+	}
+	if requeues := syncCtx.Queue().NumRequeues(DefaultQueueKey); requeues != 1 {
+		t.Errorf("expected the key to be requeued once, got %d", requeues)
+	}
+	_, status, _, err := operatorClient.GetOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if condition := v1helpers.FindOperatorCondition(status.Conditions, "TestControllerDegraded"); condition != nil {
+		t.Errorf("expected no TestControllerDegraded condition, got %#v", condition)
+	}
+
+	// an error evaluating the precondition is reported like a sync error
+	preconditionErr = fmt.Errorf("unable to get configmap")
+	if err := c.reconcile(context.TODO(), syncCtx); err == nil {
+		t.Fatal("expected error, got none")
+	}
+	_, status, _, err = operatorClient.GetOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v1helpers.IsOperatorConditionPresentAndEqual(status.Conditions, "TestControllerDegraded", "True") {
+		t.Fatalf("expected TestControllerDegraded to be True, got %#v", status.Conditions)
+	}
+	if syncs != 0 {
+		t.Errorf("expected the sync to be skipped, got %d syncs", syncs)
+	}
+
+	// once the precondition is met, the controller syncs
+	ready, preconditionErr = true, nil
+	if err := c.reconcile(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+	if syncs != 1 {
+		t.Errorf("expected a single sync, got %d", syncs)
+	}
+	_, status, _, err = operatorClient.GetOperatorState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v1helpers.IsOperatorConditionPresentAndEqual(status.Conditions, "TestControllerDegraded", "False") {
+		t.Fatalf("expected TestControllerDegraded to be False, got %#v", status.Conditions)
+	}
+}
+
 func TestBaseController_Run(t *testing.T) {
 	informer := &fakeInformer{hasSyncedDelay: 200 * time.Millisecond}
 	controllerCtx, cancel := context.WithCancel(context.Background())
@@ -153,6 +288,7 @@ func TestBaseController_Run(t *testing.T) {
 		},
 		syncContext: NewSyncContext("test", eventstesting.NewTestingEventRecorder(t)),
 		resyncEvery: 200 * time.Millisecond,
+		clock:       clock.RealClock{},
 		postStartHooks: []PostStartHook{func(ctx context.Context, syncContext SyncContext) error {
 			defer func() {
 				postStartHookDone = true
@@ -184,3 +320,366 @@ func TestBaseController_Run(t *testing.T) {
 		t.Errorf("expected the post start hook to be terminated when context is cancelled")
 	}
 }
+
+type heartbeatRecorder struct {
+	events.Recorder
+	reasons chan string
+}
+
+func (r *heartbeatRecorder) Eventf(reason, messageFmt string, args ...interface{}) {
+	r.reasons <- reason
+}
+
+func TestBaseController_Heartbeat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	recorder := &heartbeatRecorder{Recorder: eventstesting.NewTestingEventRecorder(t), reasons: make(chan string)}
+	c := &baseController{
+		name:              "test",
+		syncContext:       syncContext{eventRecorder: recorder},
+		heartbeatInterval: time.Minute,
+		heartbeatReason:   "ControllerHeartbeat",
+		clock:             fakeClock,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.runHeartbeat(ctx)
+	}()
+
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return fakeClock.HasWaiters(), nil
+	}); err != nil {
+		t.Fatalf("heartbeat ticker was not started: %v", err)
+	}
+
+	// no heartbeat until the interval elapses
+	fakeClock.Step(30 * time.Second)
+	select {
+	case reason := <-recorder.reasons:
+		t.Fatalf("unexpected heartbeat %q before the interval elapsed", reason)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	for i := 0; i < 3; i++ {
+		fakeClock.Step(time.Minute)
+		select {
+		case reason := <-recorder.reasons:
+			if reason != "ControllerHeartbeat" {
+				t.Errorf("expected ControllerHeartbeat event, got %q", reason)
+			}
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatalf("expected heartbeat #%d", i+1)
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("expected heartbeat to stop when context is cancelled")
+	}
+}
+
+func TestBaseController_ResyncJitter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	randValues := []float64{0, 1}
+	defer func(f func() float64) { resyncJitterRand = f }(resyncJitterRand)
+	resyncJitterRand = func() float64 {
+		v := randValues[0]
+		randValues = append(randValues[1:], v)
+		return v
+	}
+
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	syncCtx := NewSyncContext("test", eventstesting.NewTestingEventRecorder(t))
+	c := &baseController{
+		name:         "test",
+		syncContext:  syncCtx,
+		resyncEvery:  time.Minute,
+		resyncJitter: 0.5,
+		clock:        fakeClock,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.runPeriodicalResync(ctx)
+	}()
+
+	expectResync := func(expected bool) {
+		t.Helper()
+		err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 200*time.Millisecond, true, func(context.Context) (bool, error) {
+			return syncCtx.Queue().Len() > 0, nil
+		})
+		if resynced := err == nil; resynced != expected {
+			t.Fatalf("expected resync %v, got %v", expected, resynced)
+		}
+		if expected {
+			key, _ := syncCtx.Queue().Get()
+			syncCtx.Queue().Done(key)
+		}
+	}
+	waitForTimer := func() {
+		t.Helper()
+		if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+			return fakeClock.HasWaiters(), nil
+		}); err != nil {
+			t.Fatalf("resync timer was not started: %v", err)
+		}
+	}
+
+	// the first resync happens right away
+	expectResync(true)
+
+	// the interval is shortened by the jitter
+	waitForTimer()
+	fakeClock.Step(29 * time.Second)
+	expectResync(false)
+	fakeClock.Step(time.Second)
+	expectResync(true)
+
+	// the interval is recomputed and extended by the jitter
+	waitForTimer()
+	fakeClock.Step(89 * time.Second)
+	expectResync(false)
+	fakeClock.Step(time.Second)
+	expectResync(true)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("expected resync to stop when context is cancelled")
+	}
+}
+
+func TestBaseController_SyncDebounce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	syncCtx := NewSyncContext("test", eventstesting.NewTestingEventRecorder(t))
+	syncs := make(chan struct{}, 100)
+	c := &baseController{
+		name:        "test",
+		syncContext: syncCtx,
+		sync: func(ctx context.Context, controllerContext SyncContext) error {
+			syncs <- struct{}{}
+			return nil
+		},
+		syncDebounce: time.Minute,
+		clock:        fakeClock,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.runWorker(ctx)
+	}()
+
+	expectSyncs := func(expected int) {
+		t.Helper()
+		count := 0
+		timeout := time.After(200 * time.Millisecond)
+		for waiting := true; waiting; {
+			select {
+			case <-syncs:
+				count++
+			case <-timeout:
+				waiting = false
+			}
+		}
+		if count != expected {
+			t.Fatalf("expected %d syncs, got %d", expected, count)
+		}
+	}
+	waitForDebounce := func() {
+		t.Helper()
+		if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+			return fakeClock.HasWaiters(), nil
+		}); err != nil {
+			t.Fatalf("worker is not waiting for the debounce interval: %v", err)
+		}
+	}
+
+	syncCtx.Queue().Add(DefaultQueueKey)
+	expectSyncs(1)
+	waitForDebounce()
+
+	// a burst of events during the debounce interval is coalesced into a single sync
+	for i := 0; i < 20; i++ {
+		syncCtx.Queue().Add(DefaultQueueKey)
+	}
+	fakeClock.Step(59 * time.Second)
+	expectSyncs(0)
+	fakeClock.Step(time.Second)
+	expectSyncs(1)
+
+	waitForDebounce()
+	fakeClock.Step(time.Minute)
+	expectSyncs(0)
+
+	cancel()
+	syncCtx.Queue().ShutDown()
+	select {
+	case <-done:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("expected the worker to stop when context is cancelled")
+	}
+}
+
+type fakeCRDClient struct {
+	apiextensionsclientv1.CustomResourceDefinitionInterface
+
+	lock        sync.Mutex
+	crd         *apiextensionsv1.CustomResourceDefinition
+	getRequests int
+}
+
+func (f *fakeCRDClient) CustomResourceDefinitions() apiextensionsclientv1.CustomResourceDefinitionInterface {
+	return f
+}
+
+func (f *fakeCRDClient) Get(_ context.Context, name string, _ metav1.GetOptions) (*apiextensionsv1.CustomResourceDefinition, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.getRequests++
+	if f.crd == nil || f.crd.Name != name {
+		return nil, apierrors.NewNotFound(apiextensionsv1.Resource("customresourcedefinitions"), name)
+	}
+	return f.crd.DeepCopy(), nil
+}
+
+func (f *fakeCRDClient) setCRD(crd *apiextensionsv1.CustomResourceDefinition) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.crd = crd
+}
+
+func (f *fakeCRDClient) requests() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.getRequests
+}
+
+func TestBaseController_WaitForCRDEstablished(t *testing.T) {
+	crdEstablishedPollInterval = 10 * time.Millisecond
+	defer func() { crdEstablishedPollInterval = time.Second }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	crdClient := &fakeCRDClient{}
+	synced := make(chan struct{}, 1)
+	controller := New().WithWaitForCRDEstablished(crdClient, wait.ForeverTestTimeout, "widgets.example.com").WithSync(func(ctx context.Context, syncContext SyncContext) error {
+		select {
+		case synced <- struct{}{}:
+		default:
+		}
+		return nil
+	}).ToController("test", eventstesting.NewTestingEventRecorder(t))
+	controller.(*baseController).syncContext.Queue().Add(DefaultQueueKey)
+
+	go controller.Run(ctx, 1)
+
+	// the controller does not start while the CRD is missing
+	select {
+	case <-synced:
+		t.Fatal("expected the controller to wait for the CRD to be created")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the controller does not start while the CRD is not established
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets.example.com"},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{
+			Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+				{Type: apiextensionsv1.NamesAccepted, Status: apiextensionsv1.ConditionTrue},
+				{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionFalse},
+			},
+		},
+	}
+	crdClient.setCRD(crd)
+	select {
+	case <-synced:
+		t.Fatal("expected the controller to wait for the CRD to be established")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	crd = crd.DeepCopy()
+	crd.Status.Conditions[1].Status = apiextensionsv1.ConditionTrue
+	crdClient.setCRD(crd)
+	select {
+	case <-synced:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("expected the controller to start once the CRD is established")
+	}
+	if crdClient.requests() < 2 {
+		t.Errorf("expected the CRD to be polled, got %d requests", crdClient.requests())
+	}
+}
+
+func TestBaseController_MetricsRecorder(t *testing.T) {
+	type syncMetric struct {
+		controllerName string
+		duration       time.Duration
+		err            error
+	}
+	var metrics []syncMetric
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	c := &baseController{
+		name:  "TestController",
+		clock: fakeClock,
+		metricsRecorder: func(controllerName string, duration time.Duration, err error) {
+			metrics = append(metrics, syncMetric{controllerName: controllerName, duration: duration, err: err})
+		},
+	}
+	syncCtx := NewSyncContext("TestController", eventstesting.NewTestingEventRecorder(t))
+
+	c.sync = func(ctx context.Context, controllerContext SyncContext) error {
+		fakeClock.Step(2 * time.Second)
+		return nil
+	}
+	if err := c.reconcile(context.TODO(), syncCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	c.sync = func(ctx context.Context, controllerContext SyncContext) error {
+		fakeClock.Step(time.Second)
+		return fmt.Errorf("sync error")
+	}
+	if err := c.reconcile(context.TODO(), syncCtx); err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	c.sync = func(ctx context.Context, controllerContext SyncContext) error {
+		panic("sync panic")
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != "sync panic" {
+				t.Errorf("expected the panic to be propagated, got %v", r)
+			}
+		}()
+		_ = c.reconcile(context.TODO(), syncCtx)
+	}()
+
+	if len(metrics) != 3 {
+		t.Fatalf("expected 3 recorded syncs, got %#v", metrics)
+	}
+	if m := metrics[0]; m.controllerName != "TestController" || m.duration != 2*time.Second || m.err != nil {
+		t.Errorf("unexpected metric of the successful sync: %#v", m)
+	}
+	if m := metrics[1]; m.duration != time.Second || m.err == nil || m.err.Error() != "sync error" {
+		t.Errorf("unexpected metric of the failed sync: %#v", m)
+	}
+	if m := metrics[2]; m.err == nil || !strings.Contains(m.err.Error(), "sync panic") {
+		t.Errorf("unexpected metric of the panicking sync: %#v", m)
+	}
+}
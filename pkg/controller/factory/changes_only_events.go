@@ -0,0 +1,107 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// MarkModified records that the current sync modified something, usually based on the boolean
+// returned by the resourceapply functions:
+//
+//	_, modified, err := resourceapply.ApplyConfigMap(ctx, client, syncCtx.Recorder(), required)
+//	factory.MarkModified(syncCtx, modified)
+//
+// It only has an effect for controllers built WithChangesOnlyEvents, where Normal events recorded
+// during a sync are dropped unless the sync was marked as modified.
+func MarkModified(syncCtx SyncContext, modified bool) {
+	if r, ok := syncCtx.Recorder().(*changesOnlyRecorder); ok && modified {
+		r.markModified()
+	}
+}
+
+// changesOnlyRecorder holds back Normal events recorded during a single sync until it is known whether
+// the sync modified anything. Warning events are recorded immediately. Recorders derived with ForComponent,
+// WithComponentSuffix or WithContext share the pending events of the sync.
+type changesOnlyRecorder struct {
+	events.Recorder
+
+	state *changesOnlyState
+}
+
+// changesOnlyState is the state of a sync shared by a changesOnlyRecorder and the recorders derived from it.
+type changesOnlyState struct {
+	lock     sync.Mutex
+	modified bool
+	pending  []pendingEvent
+}
+
+type pendingEvent struct {
+	recorder events.Recorder
+	reason   string
+	message  string
+	fields   map[string]string
+}
+
+var _ events.Recorder = &changesOnlyRecorder{}
+var _ events.FieldsRecorder = &changesOnlyRecorder{}
+
+func newChangesOnlyRecorder(delegate events.Recorder) *changesOnlyRecorder {
+	return &changesOnlyRecorder{Recorder: delegate, state: &changesOnlyState{}}
+}
+
+func (r *changesOnlyRecorder) Event(reason, message string) {
+	r.hold(pendingEvent{recorder: r.Recorder, reason: reason, message: message})
+}
+
+func (r *changesOnlyRecorder) Eventf(reason, messageFmt string, args ...interface{}) {
+	r.Event(reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// EventfWithFields holds back the normal type event with the given fields like Eventf.
+func (r *changesOnlyRecorder) EventfWithFields(reason string, fields map[string]string, messageFmt string, args ...interface{}) {
+	r.hold(pendingEvent{recorder: r.Recorder, reason: reason, message: fmt.Sprintf(messageFmt, args...), fields: fields})
+}
+
+func (r *changesOnlyRecorder) ForComponent(componentName string) events.Recorder {
+	return &changesOnlyRecorder{Recorder: r.Recorder.ForComponent(componentName), state: r.state}
+}
+
+func (r *changesOnlyRecorder) WithComponentSuffix(componentNameSuffix string) events.Recorder {
+	return &changesOnlyRecorder{Recorder: r.Recorder.WithComponentSuffix(componentNameSuffix), state: r.state}
+}
+
+func (r *changesOnlyRecorder) WithContext(ctx context.Context) events.Recorder {
+	return &changesOnlyRecorder{Recorder: r.Recorder.WithContext(ctx), state: r.state}
+}
+
+func (r *changesOnlyRecorder) hold(e pendingEvent) {
+	r.state.lock.Lock()
+	defer r.state.lock.Unlock()
+	r.state.pending = append(r.state.pending, e)
+}
+
+func (r *changesOnlyRecorder) markModified() {
+	r.state.lock.Lock()
+	defer r.state.lock.Unlock()
+	r.state.modified = true
+}
+
+// flush records the pending Normal events if the sync was marked as modified and drops them otherwise.
+func (r *changesOnlyRecorder) flush() {
+	r.state.lock.Lock()
+	defer r.state.lock.Unlock()
+	if r.state.modified {
+		for _, e := range r.state.pending {
+			if e.fields != nil {
+				events.EventfWithFields(e.recorder, e.reason, e.fields, "%s", e.message)
+				continue
+			}
+			e.recorder.Event(e.reason, e.message)
+		}
+	}
+	r.state.pending = nil
+	r.state.modified = false
+}
@@ -0,0 +1,89 @@
+package factory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestChangesOnlyEvents(t *testing.T) {
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	modified := false
+	c := New().WithSync(func(ctx context.Context, syncCtx SyncContext) error {
+		syncCtx.Recorder().Event("Synced", "configuration synced")
+		syncCtx.Recorder().Warning("Slow", "sync took long")
+		MarkModified(syncCtx, modified)
+		return nil
+	}).WithChangesOnlyEvents().ToController("test", recorder).(*baseController)
+
+	countEvents := func(eventType string) int {
+		count := 0
+		for _, e := range recorder.Events() {
+			if e.Type == eventType {
+				count++
+			}
+		}
+		return count
+	}
+
+	// a no-op sync only records the warning
+	c.syncContext.Queue().Add(DefaultQueueKey)
+	c.processNextWorkItem(context.TODO())
+	if normal := countEvents(corev1.EventTypeNormal); normal != 0 {
+		t.Errorf("expected no Normal event for a no-op sync, got %d", normal)
+	}
+	if warnings := countEvents(corev1.EventTypeWarning); warnings != 1 {
+		t.Errorf("expected 1 Warning event, got %d", warnings)
+	}
+
+	// a changing sync records the Normal event
+	modified = true
+	c.syncContext.Queue().Add(DefaultQueueKey)
+	c.processNextWorkItem(context.TODO())
+	if normal := countEvents(corev1.EventTypeNormal); normal != 1 {
+		t.Errorf("expected 1 Normal event for a changing sync, got %d", normal)
+	}
+	if warnings := countEvents(corev1.EventTypeWarning); warnings != 2 {
+		t.Errorf("expected 2 Warning events, got %d", warnings)
+	}
+}
+
+func TestChangesOnlyEventsDerivedRecorders(t *testing.T) {
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	modified := false
+	c := New().WithSync(func(ctx context.Context, syncCtx SyncContext) error {
+		events.EventfWithFields(syncCtx.Recorder(), "Synced", map[string]string{"example.com/key": "value"}, "configuration synced")
+		syncCtx.Recorder().WithComponentSuffix("sub").Event("SubSynced", "sub-component synced")
+		syncCtx.Recorder().WithContext(ctx).Eventf("ContextSynced", "synced %d items", 2)
+		MarkModified(syncCtx, modified)
+		return nil
+	}).WithChangesOnlyEvents().ToController("test", recorder).(*baseController)
+
+	// a no-op sync records nothing, whatever recorder the events went through
+	c.syncContext.Queue().Add(DefaultQueueKey)
+	c.processNextWorkItem(context.TODO())
+	if len(recorder.Events()) != 0 {
+		t.Fatalf("expected no events for a no-op sync, got %d", len(recorder.Events()))
+	}
+
+	// a changing sync records all events, with their fields
+	modified = true
+	c.syncContext.Queue().Add(DefaultQueueKey)
+	c.processNextWorkItem(context.TODO())
+	var reasons []string
+	for _, e := range recorder.Events() {
+		reasons = append(reasons, e.Reason)
+		if e.Reason == "Synced" && e.Annotations["example.com/key"] != "value" {
+			t.Errorf("expected the fields to be recorded, got %v", e.Annotations)
+		}
+	}
+	if expected := []string{"Synced", "SubSynced", "ContextSynced"}; !reflect.DeepEqual(reasons, expected) {
+		t.Errorf("expected events %v, got %v", expected, reasons)
+	}
+}
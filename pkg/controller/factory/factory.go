@@ -3,12 +3,15 @@ package factory
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/robfig/cron"
+	apiextensionsclientv1 "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	errorutil "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
 
 	"github.com/openshift/library-go/pkg/operator/events"
 	operatorv1helpers "github.com/openshift/library-go/pkg/operator/v1helpers"
@@ -28,7 +31,9 @@ type Factory struct {
 	sync                   SyncFunc
 	syncContext            SyncContext
 	syncDegradedClient     operatorv1helpers.OperatorClient
+	syncStatusClient       operatorv1helpers.OperatorClient
 	resyncInterval         time.Duration
+	resyncJitter           float64
 	resyncSchedules        []string
 	informers              []filteredInformers
 	informerQueueKeys      []informersWithQueueKey
@@ -37,6 +42,18 @@ type Factory struct {
 	namespaceInformers     []*namespaceInformer
 	cachesToSync           []cache.InformerSynced
 	controllerInstanceName string
+	heartbeatInterval      time.Duration
+	heartbeatReason        string
+	sharedQueueKeys        *SharedQueueKeys
+	changesOnlyEvents      bool
+	syncDebounce           time.Duration
+	contextValues          []contextValue
+	crdClient              apiextensionsclientv1.CustomResourceDefinitionsGetter
+	crdsToWait             []string
+	crdWaitTimeout         time.Duration
+	metricsRecorder        MetricsRecorderFunc
+	clock                  clock.WithTicker
+	syncPreconditions      []SyncPreconditionFunc
 }
 
 // Informer represents any structure that allow to register event handlers and informs if caches are synced.
@@ -67,6 +84,10 @@ type filteredInformers struct {
 // The syncContext allow access to controller queue and event recorder.
 type PostStartHook func(ctx context.Context, syncContext SyncContext) error
 
+// SyncPreconditionFunc reports whether the controller is ready to sync, for instance because another controller
+// created a resource the sync depends on. An error is handled the same way as an error of the sync.
+type SyncPreconditionFunc func(ctx context.Context) (bool, error)
+
 // ObjectQueueKeyFunc is used to make a string work queue key out of the runtime object that is passed to it.
 // This can extract the "namespace/name" if you need to or just return "key" if you building controller that only use string
 // triggers.
@@ -207,6 +228,15 @@ func (f *Factory) ResyncEvery(interval time.Duration) *Factory {
 	return f
 }
 
+// WithResyncJitter randomizes the interval set by ResyncEvery within +/- maxFraction of it, so that controllers
+// sharing the same interval do not resync at the same time. A new interval is picked after every resync, the first
+// resync still happens right after the controller starts. maxFraction is capped at 0.5.
+// If this is not called, the controller resyncs exactly every interval.
+func (f *Factory) WithResyncJitter(maxFraction float64) *Factory {
+	f.resyncJitter = maxFraction
+	return f
+}
+
 // ResyncSchedule allows to supply a Cron syntax schedule that will be used to schedule the sync() call runs.
 // This allows more fine-tuned controller scheduling than ResyncEvery.
 // Examples:
@@ -245,6 +275,102 @@ func (f *Factory) WithControllerInstanceName(controllerInstanceName string) *Fac
 	return f
 }
 
+// WithHeartbeat causes the controller to record a Normal event with the given reason every interval while it runs.
+// This gives visibility into the controller liveness even when nothing changes.
+// If this is not called, no heartbeat events are recorded.
+func (f *Factory) WithHeartbeat(interval time.Duration, reason string) *Factory {
+	f.heartbeatInterval = interval
+	f.heartbeatReason = reason
+	return f
+}
+
+// WithSharedQueueKeys makes the controller skip queue keys that another controller using the same keys already
+// started to sync after they were queued. Use it for controllers doing equivalent work for the same resource.
+// If this is not called, every queued key is synced.
+func (f *Factory) WithSharedQueueKeys(keys *SharedQueueKeys) *Factory {
+	f.sharedQueueKeys = keys
+	return f
+}
+
+// WithChangesOnlyEvents drops Normal events recorded during a sync unless the sync reported a modification
+// using MarkModified. This avoids recording the same events on every sync when nothing changed.
+// Warning events are always recorded.
+// If this is not called, all events are recorded.
+func (f *Factory) WithChangesOnlyEvents() *Factory {
+	f.changesOnlyEvents = true
+	return f
+}
+
+// WithContextValue attaches the value for key to the context passed to every Sync() call, for instance to identify the
+// shard or tenant handled by this controller instance. Keys follow the rules of context.WithValue and should be of an
+// unexported type. The values are fixed when the controller is built.
+func (f *Factory) WithContextValue(key, value interface{}) *Factory {
+	f.contextValues = append(f.contextValues, contextValue{key: key, value: value})
+	return f
+}
+
+// WithSyncDebounce makes every worker of the controller wait at least minInterval after processing a queue key before
+// it takes the next one. Keys queued in the meantime are coalesced by the queue, so a burst of events for the same key
+// results in a single later sync instead of many consecutive ones.
+// If this is not called, the next key is processed right away.
+func (f *Factory) WithSyncDebounce(minInterval time.Duration) *Factory {
+	f.syncDebounce = minInterval
+	return f
+}
+
+// WithSyncStatus publishes the outcome of the last sync in the operator status using the given operator client.
+// After every sync, the "<name>Synced" condition is set to True when the sync succeeded, or to False with the
+// sync error as message when it failed. The last transition time of the condition tells when the outcome changed.
+// Unlike WithSyncDegradedOnError, the condition does not mark the operator as degraded.
+// If this is not called, the sync outcome is not published.
+func (f *Factory) WithSyncStatus(operatorClient operatorv1helpers.OperatorClient) *Factory {
+	f.syncStatusClient = operatorClient
+	return f
+}
+
+// WithWaitForCRDEstablished defers the start of the controller until the given CustomResourceDefinitions are
+// Established, polling them using the given apiextensions client. This is useful for controllers watching custom
+// resources whose CRD might not be installed yet when the controller starts. The caches are synced only after
+// the CRDs are established. If they are not established within timeout, the controller fails to start the same
+// way it does when the caches do not sync.
+// If this is not called, the controller does not wait for any CRD.
+func (f *Factory) WithWaitForCRDEstablished(client apiextensionsclientv1.CustomResourceDefinitionsGetter, timeout time.Duration, crdNames ...string) *Factory {
+	f.crdClient = client
+	f.crdWaitTimeout = timeout
+	f.crdsToWait = append(f.crdsToWait, crdNames...)
+	return f
+}
+
+// WithMetricsRecorder calls the given recorder after every sync of the controller queue with the controller name,
+// the duration of the sync and its error, nil on success. A panic of the sync is reported as an error before it is
+// propagated. This allows to collect timing and error rate metrics, for instance with Prometheus collectors.
+// If this is not called, no metrics are recorded.
+func (f *Factory) WithMetricsRecorder(recorder MetricsRecorderFunc) *Factory {
+	f.metricsRecorder = recorder
+	return f
+}
+
+// WithClock sets the clock used for all timing of the controller: periodical resyncs, heartbeats, sync debounce,
+// the wait for CRDs and the sync durations reported to the metrics recorder. This is meant for unit tests, which can
+// pass a fake clock from k8s.io/utils/clock/testing to step through the timing deterministically. Cron schedules set
+// by ResyncSchedule always follow the wall clock.
+// If this is not called, the real clock is used.
+func (f *Factory) WithClock(clock clock.WithTicker) *Factory {
+	f.clock = clock
+	return f
+}
+
+// WithSyncPrecondition makes the controller evaluate the given preconditions before every sync. When one of them
+// is not met, the sync is skipped and the queue key is requeued with the rate limited backoff of the queue, as if
+// the sync returned SyntheticRequeueError. An unmet precondition is not reported by WithSyncDegradedOnError or
+// WithSyncStatus. This allows to order controllers that depend on each other without reporting the missing
+// prerequisite as a failure.
+// If this is not called, the controller syncs unconditionally.
+func (f *Factory) WithSyncPrecondition(preconditions ...SyncPreconditionFunc) *Factory {
+	f.syncPreconditions = append(f.syncPreconditions, preconditions...)
+	return f
+}
+
 // Controller produce a runnable controller.
 func (f *Factory) ToController(name string, eventRecorder events.Recorder) Controller {
 	if f.sync == nil {
@@ -257,6 +383,11 @@ func (f *Factory) ToController(name string, eventRecorder events.Recorder) Contr
 	} else {
 		ctx = NewSyncContext(name, eventRecorder)
 	}
+	if f.sharedQueueKeys != nil {
+		sharedCtx := ctx.(syncContext)
+		sharedCtx.queue = newSharedKeysQueue(sharedCtx.queue, f.sharedQueueKeys)
+		ctx = sharedCtx
+	}
 
 	var cronSchedules []cron.Schedule
 	if len(f.resyncSchedules) > 0 {
@@ -277,13 +408,29 @@ func (f *Factory) ToController(name string, eventRecorder events.Recorder) Contr
 		name:                   name,
 		controllerInstanceName: f.controllerInstanceName,
 		syncDegradedClient:     f.syncDegradedClient,
+		syncStatusClient:       f.syncStatusClient,
 		sync:                   f.sync,
 		resyncEvery:            f.resyncInterval,
+		resyncJitter:           math.Min(math.Max(f.resyncJitter, 0), maxResyncJitter),
 		resyncSchedules:        cronSchedules,
 		cachesToSync:           append([]cache.InformerSynced{}, f.cachesToSync...),
 		syncContext:            ctx,
 		postStartHooks:         f.postStartHooks,
 		cacheSyncTimeout:       defaultCacheSyncTimeout,
+		heartbeatInterval:      f.heartbeatInterval,
+		heartbeatReason:        f.heartbeatReason,
+		changesOnlyEvents:      f.changesOnlyEvents,
+		syncDebounce:           f.syncDebounce,
+		contextValues:          append([]contextValue{}, f.contextValues...),
+		crdClient:              f.crdClient,
+		crdsToWait:             append([]string{}, f.crdsToWait...),
+		crdWaitTimeout:         f.crdWaitTimeout,
+		metricsRecorder:        f.metricsRecorder,
+		clock:                  f.clock,
+		syncPreconditions:      append([]SyncPreconditionFunc{}, f.syncPreconditions...),
+	}
+	if c.clock == nil {
+		c.clock = clock.RealClock{}
 	}
 
 	for i := range f.informerQueueKeys {
@@ -122,6 +122,60 @@ func TestResyncController(t *testing.T) {
 	}
 }
 
+func TestResyncControllerWithFakeClock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	syncs := make(chan string, 10)
+	controller := New().ResyncEvery(time.Hour).WithClock(fakeClock).WithSync(func(ctx context.Context, controllerContext SyncContext) error {
+		syncs <- controllerContext.QueueKey()
+		return nil
+	}).ToController("PeriodicController", events.NewInMemoryRecorder("periodic-controller", clocktesting.NewFakePassiveClock(time.Now())))
+
+	go controller.Run(ctx, 1)
+
+	expectSync := func() {
+		t.Helper()
+		select {
+		case key := <-syncs:
+			if key != DefaultQueueKey {
+				t.Errorf("expected resync with %q key, got %q", DefaultQueueKey, key)
+			}
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatal("expected a resync")
+		}
+	}
+	expectNoSync := func() {
+		t.Helper()
+		select {
+		case key := <-syncs:
+			t.Fatalf("unexpected sync of %q before the resync interval elapsed", key)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	waitForTimer := func() {
+		t.Helper()
+		if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+			return fakeClock.HasWaiters(), nil
+		}); err != nil {
+			t.Fatalf("resync timer was not started: %v", err)
+		}
+	}
+
+	// the first resync happens right after the start
+	expectSync()
+	waitForTimer()
+
+	for i := 0; i < 3; i++ {
+		fakeClock.Step(59 * time.Minute)
+		expectNoSync()
+		fakeClock.Step(time.Minute)
+		expectSync()
+		waitForTimer()
+	}
+}
+
 func TestMultiWorkerControllerShutdown(t *testing.T) {
 	controllerCtx, shutdown := context.WithCancel(context.TODO())
 	factory := New().ResyncEvery(10 * time.Minute) // make sure we only call 1 sync manually
@@ -268,6 +322,32 @@ func TestControllerSyncAfterStart(t *testing.T) {
 	}
 }
 
+type shardKey struct{}
+
+func TestControllerWithContextValue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	shards := make(chan interface{}, 1)
+	controller := New().ResyncEvery(10*time.Second).WithContextValue(shardKey{}, "shard-3").WithSync(func(ctx context.Context, controllerContext SyncContext) error {
+		select {
+		case shards <- ctx.Value(shardKey{}):
+		default:
+		}
+		return nil
+	}).ToController("test", events.NewInMemoryRecorder("fake-controller", clocktesting.NewFakePassiveClock(time.Now())))
+
+	go controller.Run(ctx, 1)
+	select {
+	case shard := <-shards:
+		if shard != "shard-3" {
+			t.Errorf("expected shard-3 in the sync context, got %v", shard)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("expected sync to be called right after controller is started")
+	}
+}
+
 func TestControllerWithQueueFunction(t *testing.T) {
 	kubeClient := fake.NewSimpleClientset()
 
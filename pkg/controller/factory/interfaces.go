@@ -3,6 +3,7 @@ package factory
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"k8s.io/client-go/util/workqueue"
 
@@ -47,6 +48,10 @@ type SyncContext interface {
 // The syncContext provides access to controller name, queue and event recorder.
 type SyncFunc func(ctx context.Context, controllerContext SyncContext) error
 
+// MetricsRecorderFunc is called after every Sync() of a controller with the controller name, the duration of the sync
+// and the error it returned, nil on success. It allows callers to collect per-controller metrics.
+type MetricsRecorderFunc func(controllerName string, duration time.Duration, err error)
+
 func ControllerFieldManager(controllerName, usageName string) string {
 	return fmt.Sprintf("%s-%s", controllerName, usageName)
 }
@@ -0,0 +1,85 @@
+package factory
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// SharedQueueKeys coordinates controllers that reconcile the same resource so that a queue key observed by
+// several of them is synced only once. Each controller keeps its own queue, but a key is skipped when another
+// controller sharing the keys started syncing it after the key was queued, as that sync already observed the
+// change that caused the key to be queued.
+// This is only useful when the sync functions of the controllers do equivalent work for a given key.
+type SharedQueueKeys struct {
+	lock     sync.Mutex
+	sequence uint64
+	// started tracks the sequence number at which a sync of the key last started in any controller
+	started map[string]uint64
+}
+
+// NewSharedQueueKeys returns keys to be shared by controllers with WithSharedQueueKeys.
+func NewSharedQueueKeys() *SharedQueueKeys {
+	return &SharedQueueKeys{
+		started: map[string]uint64{},
+	}
+}
+
+// queued records that key was queued in queue.
+func (s *SharedQueueKeys) queued(queue *sharedKeysQueue, key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sequence++
+	queue.queued[key] = s.sequence
+}
+
+// startSync returns true if the key taken from queue must be synced and records that the sync started.
+// It returns false if a sync of the key started in any controller after the key was queued.
+func (s *SharedQueueKeys) startSync(queue *sharedKeysQueue, key string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.started[key] > queue.queued[key] {
+		return false
+	}
+	s.sequence++
+	s.started[key] = s.sequence
+	return true
+}
+
+// sharedKeysQueue records every key added to the controller queue in the shared keys.
+type sharedKeysQueue struct {
+	workqueue.RateLimitingInterface
+	keys *SharedQueueKeys
+	// queued tracks the sequence number at which the key was last queued, guarded by keys.lock
+	queued map[string]uint64
+}
+
+func newSharedKeysQueue(queue workqueue.RateLimitingInterface, keys *SharedQueueKeys) *sharedKeysQueue {
+	return &sharedKeysQueue{
+		RateLimitingInterface: queue,
+		keys:                  keys,
+		queued:                map[string]uint64{},
+	}
+}
+
+func (q *sharedKeysQueue) record(item interface{}) {
+	if key, ok := item.(string); ok {
+		q.keys.queued(q, key)
+	}
+}
+
+func (q *sharedKeysQueue) Add(item interface{}) {
+	q.record(item)
+	q.RateLimitingInterface.Add(item)
+}
+
+func (q *sharedKeysQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.record(item)
+	q.RateLimitingInterface.AddAfter(item, duration)
+}
+
+func (q *sharedKeysQueue) AddRateLimited(item interface{}) {
+	q.record(item)
+	q.RateLimitingInterface.AddRateLimited(item)
+}
@@ -0,0 +1,77 @@
+package factory
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+)
+
+func TestSharedQueueKeys(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	keys := NewSharedQueueKeys()
+	var syncs atomic.Int32
+	var syncedKeys sync.Map
+	newController := func(name string) *baseController {
+		return New().WithSync(func(ctx context.Context, syncCtx SyncContext) error {
+			syncs.Add(1)
+			syncedKeys.Store(syncCtx.QueueKey(), name)
+			return nil
+		}).WithSharedQueueKeys(keys).ToController(name, eventstesting.NewTestingEventRecorder(t)).(*baseController)
+	}
+	first, second := newController("first"), newController("second")
+
+	// both controllers observe the same change before they run
+	first.syncContext.Queue().Add("shared")
+	second.syncContext.Queue().Add("shared")
+
+	var wg sync.WaitGroup
+	for _, c := range []*baseController{first, second} {
+		wg.Add(1)
+		go func(c *baseController) {
+			defer wg.Done()
+			c.Run(ctx, 2)
+		}(c)
+	}
+	defer wg.Wait()
+
+	waitForSyncs := func(expected int32) {
+		t.Helper()
+		if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+			return syncs.Load() >= expected && first.syncContext.Queue().Len() == 0 && second.syncContext.Queue().Len() == 0, nil
+		}); err != nil {
+			t.Fatalf("expected %d syncs, got %d", expected, syncs.Load())
+		}
+		// give a chance to a duplicate sync to happen
+		time.Sleep(200 * time.Millisecond)
+		if actual := syncs.Load(); actual != expected {
+			t.Fatalf("expected %d syncs, got %d", expected, actual)
+		}
+	}
+	waitForSyncs(1)
+
+	// a new change observed by both controllers is synced once more
+	first.syncContext.Queue().Add("shared")
+	second.syncContext.Queue().Add("shared")
+	waitForSyncs(2)
+
+	// keys are only deduplicated with each other
+	first.syncContext.Queue().Add("first-only")
+	second.syncContext.Queue().Add("second-only")
+	waitForSyncs(4)
+	if _, ok := syncedKeys.Load("first-only"); !ok {
+		t.Errorf("expected first-only key to be synced")
+	}
+	if _, ok := syncedKeys.Load("second-only"); !ok {
+		t.Errorf("expected second-only key to be synced")
+	}
+
+	cancel()
+}
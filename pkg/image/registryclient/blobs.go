@@ -0,0 +1,61 @@
+package registryclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/opencontainers/go-digest"
+)
+
+// blobStatBatchWorkers bounds how many blobs BlobStatBatch looks up concurrently. The requests are
+// throttled by the rate limiter of the repository as well.
+const blobStatBatchWorkers = 5
+
+// BlobStatResult is the result of looking up a single blob with BlobStatBatch.
+type BlobStatResult struct {
+	Descriptor distribution.Descriptor
+	Err        error
+}
+
+// BlobStatBatch looks up the descriptors of dgsts in repo concurrently and returns the result for each
+// digest. All lookups share the authentication, retries and rate limiter of repo, and for repositories
+// created by a Context each digest falls back to the alternate locations independently of the others.
+// Duplicate digests are looked up once.
+func BlobStatBatch(ctx context.Context, repo distribution.Repository, dgsts []digest.Digest) map[digest.Digest]BlobStatResult {
+	results := make(map[digest.Digest]BlobStatResult, len(dgsts))
+	work := make(chan digest.Digest)
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+
+	workers := blobStatBatchWorkers
+	if len(dgsts) < workers {
+		workers = len(dgsts)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// blob stores are not safe for concurrent use
+			blobs := repo.Blobs(ctx)
+			for dgst := range work {
+				desc, err := blobs.Stat(ctx, dgst)
+				lock.Lock()
+				results[dgst] = BlobStatResult{Descriptor: desc, Err: err}
+				lock.Unlock()
+			}
+		}()
+	}
+
+	seen := make(map[digest.Digest]struct{}, len(dgsts))
+	for _, dgst := range dgsts {
+		if _, ok := seen[dgst]; ok {
+			continue
+		}
+		seen[dgst] = struct{}{}
+		work <- dgst
+	}
+	close(work)
+	wg.Wait()
+	return results
+}
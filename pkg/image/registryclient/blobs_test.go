@@ -0,0 +1,87 @@
+package registryclient
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/reference"
+	"github.com/opencontainers/go-digest"
+
+	imagereference "github.com/openshift/library-go/pkg/image/reference"
+)
+
+type digestBlobStore struct {
+	distribution.BlobStore
+	blobs map[digest.Digest]distribution.Descriptor
+}
+
+func (s *digestBlobStore) Stat(ctx context.Context, dgst digest.Digest) (distribution.Descriptor, error) {
+	desc, ok := s.blobs[dgst]
+	if !ok {
+		return distribution.Descriptor{}, distribution.ErrBlobUnknown
+	}
+	return desc, nil
+}
+
+type digestRepository struct {
+	mockRepository
+	blobs *digestBlobStore
+}
+
+func (r *digestRepository) Blobs(ctx context.Context) distribution.BlobStore { return r.blobs }
+
+// lockedMirrorRetriever connects to the given repositories and is safe for concurrent use.
+type lockedMirrorRetriever struct {
+	lock  sync.Mutex
+	repos map[string]distribution.Repository
+}
+
+func (r *lockedMirrorRetriever) connectToRegistry(ctx context.Context, locator repositoryLocator, insecure bool) (RepositoryWithLocation, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return NewLimitedRetryRepository(locator.ref, r.repos[locator.ref.Exact()], 0, unlimited), nil
+}
+
+func TestBlobStatBatch(t *testing.T) {
+	source := imagereference.DockerImageReference{Registry: "source.test", Namespace: "ns", Name: "image"}
+	first := imagereference.DockerImageReference{Registry: "first.test", Namespace: "ns", Name: "image"}
+	second := imagereference.DockerImageReference{Registry: "second.test", Namespace: "ns", Name: "image"}
+
+	layer1 := distribution.Descriptor{Digest: digest.SHA256.FromString("layer1"), Size: 1}
+	layer2 := distribution.Descriptor{Digest: digest.SHA256.FromString("layer2"), Size: 2}
+	missing := digest.SHA256.FromString("missing")
+
+	named, err := reference.WithName(source.RepositoryName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &blobMirroredRepository{
+		locator:  repositoryLocator{ref: source, named: named},
+		strategy: &fakeAlternateBlobStrategy{FirstAlternates: []imagereference.DockerImageReference{first, second}},
+		retriever: &lockedMirrorRetriever{repos: map[string]distribution.Repository{
+			first.Exact():  &digestRepository{blobs: &digestBlobStore{blobs: map[digest.Digest]distribution.Descriptor{layer1.Digest: layer1}}},
+			second.Exact(): &digestRepository{blobs: &digestBlobStore{blobs: map[digest.Digest]distribution.Descriptor{layer1.Digest: layer1, layer2.Digest: layer2}}},
+		}},
+	}
+
+	results := BlobStatBatch(context.Background(), repo, []digest.Digest{layer1.Digest, layer2.Digest, missing, layer1.Digest})
+	if len(results) != 3 {
+		t.Fatalf("expected a result for each distinct digest, got %v", results)
+	}
+	// each digest falls back to the alternates independently
+	if result := results[layer1.Digest]; result.Err != nil || result.Descriptor.Size != 1 {
+		t.Errorf("unexpected result for %s: %#v", layer1.Digest, result)
+	}
+	if result := results[layer2.Digest]; result.Err != nil || result.Descriptor.Size != 2 {
+		t.Errorf("unexpected result for %s: %#v", layer2.Digest, result)
+	}
+	if result := results[missing]; result.Err == nil {
+		t.Errorf("expected an error for %s, got %#v", missing, result)
+	}
+
+	if results := BlobStatBatch(context.Background(), repo, nil); len(results) != 0 {
+		t.Errorf("expected no results, got %v", results)
+	}
+}
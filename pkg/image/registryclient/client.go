@@ -2,6 +2,7 @@ package registryclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
@@ -10,6 +11,8 @@ import (
 	"net/url"
 	"path"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -53,6 +56,139 @@ func (e *ErrNotV2Registry) Error() string {
 	return fmt.Sprintf("endpoint %q does not support v2 API (got %s)", e.Registry, e.Status)
 }
 
+// ErrInsufficientScope is returned when the registry rejects a request because the credentials do not grant
+// the scope the request requires. Scope is the scope requested by the registry in the WWW-Authenticate
+// challenge, for example "repository:library/busybox:pull".
+type ErrInsufficientScope struct {
+	Scope       string
+	Description string
+}
+
+func (e *ErrInsufficientScope) Error() string {
+	msg := "the registry credentials do not grant the required scope"
+	if len(e.Scope) > 0 {
+		msg = fmt.Sprintf("%s %q", msg, e.Scope)
+	}
+	if len(e.Description) > 0 {
+		msg = fmt.Sprintf("%s: %s", msg, e.Description)
+	}
+	return msg
+}
+
+// ErrTooManyRequests is returned when the registry throttles a request and indicates with the Retry-After
+// header how long the client should wait before retrying it.
+type ErrTooManyRequests struct {
+	Status     string
+	RetryAfter time.Duration
+}
+
+func (e *ErrTooManyRequests) Error() string {
+	return fmt.Sprintf("the registry is throttling requests (%s), retry after %s", e.Status, e.RetryAfter)
+}
+
+// parseRetryAfter returns the duration described by a Retry-After header value, which may either be
+// a number of seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if d := date.Sub(nowFn()); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
+// responseErrorTransport returns typed errors for responses carrying information that would otherwise
+// be lost when the registry client converts the response to an error:
+//
+// - an ErrInsufficientScope for responses with an insufficient_scope challenge, that otherwise surface as
+// a generic denied error.
+// - an ErrTooManyRequests for throttled responses with a Retry-After header.
+//
+// Unauthorized responses are passed to unauthorized, if set, before they are inspected.
+type responseErrorTransport struct {
+	rt           http.RoundTripper
+	unauthorized func(resp *http.Response)
+}
+
+func (t *responseErrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && t.unauthorized != nil {
+		t.unauthorized(resp)
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		// challenges are only parsed from unauthorized responses, but registries may report an
+		// insufficient scope with a forbidden status as well
+		challenges := challenge.ResponseChallenges(&http.Response{StatusCode: http.StatusUnauthorized, Header: resp.Header})
+		for _, c := range challenges {
+			if c.Scheme == "bearer" && c.Parameters["error"] == "insufficient_scope" {
+				resp.Body.Close()
+				return nil, &ErrInsufficientScope{
+					Scope:       c.Parameters["scope"],
+					Description: c.Parameters["error_description"],
+				}
+			}
+		}
+	case http.StatusTooManyRequests:
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			resp.Body.Close()
+			return nil, &ErrTooManyRequests{Status: resp.Status, RetryAfter: retryAfter}
+		}
+	}
+	return resp, nil
+}
+
+// headFallbackTransport repeats HEAD requests rejected with 405 Method Not Allowed as GET requests,
+// so existence checks like manifest Exists and blob Stat also work against registries that do not
+// support HEAD. The body of the GET response is discarded, only the status and headers are returned.
+type headFallbackTransport struct {
+	rt http.RoundTripper
+}
+
+func (t *headFallbackTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil || req.Method != http.MethodHead || resp.StatusCode != http.StatusMethodNotAllowed {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	getReq := req.Clone(req.Context())
+	getReq.Method = http.MethodGet
+	resp, err = t.rt.RoundTrip(getReq)
+	if err != nil {
+		return resp, err
+	}
+	resp.Body.Close()
+	resp.Body = http.NoBody
+	resp.Request = req
+	return resp, nil
+}
+
+// ErrOperationTimeout is returned when a single registry operation does not complete within the
+// timeout configured with WithOperationTimeout. It is never retried.
+type ErrOperationTimeout struct {
+	Operation string
+	Timeout   time.Duration
+}
+
+func (e *ErrOperationTimeout) Error() string {
+	return fmt.Sprintf("%s did not complete within %s", e.Operation, e.Timeout)
+}
+
 type AuthHandlersFunc func(transport http.RoundTripper, registry *url.URL, repoName string) []auth.AuthenticationHandler
 
 // NewContext is capable of creating RepositoryRetrievers.
@@ -66,7 +202,7 @@ func NewContext(transp, insecureTransport http.RoundTripper) *Context {
 		Credentials:       NoCredentials,
 		RequestModifiers:  make([]transport.RequestModifier, 0),
 
-		pings:    make(map[url.URL]error),
+		pings:    make(map[url.URL]pingResult),
 		redirect: make(map[url.URL]*url.URL),
 	}
 }
@@ -90,13 +226,29 @@ type Context struct {
 	RequestModifiers   []transport.RequestModifier
 	Limiter            *rate.Limiter
 	Alternates         AlternateBlobSourceStrategy
+	Selector           MirrorSelector
+	MaxMirrorAttempts  int
+	OperationTimeout   time.Duration
+	MaxRetryAfter      time.Duration
+	TokenCache         *TokenCache
+	PingCacheTTL       time.Duration
 
 	DisableDigestVerification bool
+	// AllowedDigestAlgorithms restricts the digest algorithms content may be retrieved by. Content requested by a
+	// digest of another algorithm is rejected before it is retrieved. All available algorithms are allowed if empty.
+	// The algorithms are not checked when digest verification is disabled.
+	AllowedDigestAlgorithms []digest.Algorithm
+	// RequireAPIVersionHeader only considers a registry v2 capable if it returns the
+	// Docker-Distribution-API-Version header when pinged, regardless of the status code.
+	RequireAPIVersionHeader bool
+	// CollectStats makes the repositories of the context collect the statistics returned by Stats.
+	CollectStats bool
 
 	lock             sync.Mutex
-	pings            map[url.URL]error
+	pings            map[url.URL]pingResult
 	redirect         map[url.URL]*url.URL
 	cachedTransports []transportCache
+	stats            *pullStats
 }
 
 func (c *Context) Copy() *Context {
@@ -112,10 +264,19 @@ func (c *Context) Copy() *Context {
 		Credentials:        c.Credentials,
 		CredentialsFactory: c.CredentialsFactory,
 		Limiter:            c.Limiter,
+		Selector:           c.Selector,
+		MaxMirrorAttempts:  c.MaxMirrorAttempts,
+		OperationTimeout:   c.OperationTimeout,
+		MaxRetryAfter:      c.MaxRetryAfter,
+		TokenCache:         c.TokenCache,
+		PingCacheTTL:       c.PingCacheTTL,
 
 		DisableDigestVerification: c.DisableDigestVerification,
+		AllowedDigestAlgorithms:   c.AllowedDigestAlgorithms,
+		RequireAPIVersionHeader:   c.RequireAPIVersionHeader,
+		CollectStats:              c.CollectStats,
 
-		pings:    make(map[url.URL]error),
+		pings:    make(map[url.URL]pingResult),
 		redirect: make(map[url.URL]*url.URL),
 	}
 	for k, v := range c.redirect {
@@ -144,6 +305,23 @@ func (c *Context) WithActions(actions ...string) *Context {
 	return c
 }
 
+// WithOperationTimeout bounds each attempt of a manifest Get, blob Stat and blob Open to timeout,
+// independently of the transport timeouts. A blob Open is bounded until the response is obtained by the
+// first read, reading the content is not bounded. The deadline is reset on every retry, and the backoff
+// between retries only uses the time left by attempts that completed early, so an operation never takes
+// longer than its attempts are allowed to. A zero timeout disables the bound.
+func (c *Context) WithOperationTimeout(timeout time.Duration) *Context {
+	c.OperationTimeout = timeout
+	return c
+}
+
+// WithMaxRetryAfter caps how long a request throttled by the registry waits before it is retried when
+// the registry asks for a longer delay with the Retry-After header. Defaults to one minute.
+func (c *Context) WithMaxRetryAfter(maxRetryAfter time.Duration) *Context {
+	c.MaxRetryAfter = maxRetryAfter
+	return c
+}
+
 func (c *Context) WithCredentials(credentials auth.CredentialStore) *Context {
 	c.Credentials = credentials
 	return c
@@ -154,6 +332,61 @@ func (c *Context) WithCredentialsFactory(factory CredentialStoreFactory) *Contex
 	return c
 }
 
+// WithMirrorSelector sets the selector that orders the alternate locations returned by the
+// AlternateBlobSourceStrategy before they are searched. Without a selector, they are searched
+// in the order returned by the strategy.
+func (c *Context) WithMirrorSelector(selector MirrorSelector) *Context {
+	c.Selector = selector
+	return c
+}
+
+// WithMaxMirrorAttempts bounds how many of the alternate locations returned by the AlternateBlobSourceStrategy
+// are attempted for a single request. Once n locations failed, the request gives up with an error combining the
+// errors of all attempts. A value of zero attempts all locations.
+func (c *Context) WithMaxMirrorAttempts(n int) *Context {
+	c.MaxMirrorAttempts = n
+	return c
+}
+
+// WithPingCacheTTL bounds how long the result of pinging a registry is reused by the repositories created
+// from this context. Registries are pinged again once the result expired. A zero ttl reuses the result for
+// the lifetime of the context.
+func (c *Context) WithPingCacheTTL(ttl time.Duration) *Context {
+	c.PingCacheTTL = ttl
+	return c
+}
+
+// WithDialContext makes the connections to registries use dial, for instance to resolve registry hosts with a
+// resolver other than the system resolver. The transports of the context are cloned with dial as their DialContext,
+// the transports passed to NewContext are not modified. Transports that are not an *http.Transport cannot be
+// changed and keep their dialer.
+func (c *Context) WithDialContext(dial func(ctx context.Context, network, address string) (net.Conn, error)) *Context {
+	c.Transport = transportWithDialContext(c.Transport, dial)
+	c.InsecureTransport = transportWithDialContext(c.InsecureTransport, dial)
+	return c
+}
+
+func transportWithDialContext(rt http.RoundTripper, dial func(ctx context.Context, network, address string) (net.Conn, error)) http.RoundTripper {
+	if rt == nil {
+		return nil
+	}
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		klog.Warningf("Unable to set the dialer of registry transport %T, only *http.Transport is supported", rt)
+		return rt
+	}
+	t = t.Clone()
+	t.DialContext = dial
+	return t
+}
+
+// WithAllowedDigestAlgorithms only allows content to be retrieved by digests of the given algorithms, for instance
+// to reject experimental algorithms a registry supports. Other digests are rejected before the content is retrieved.
+func (c *Context) WithAllowedDigestAlgorithms(algorithms ...digest.Algorithm) *Context {
+	c.AllowedDigestAlgorithms = algorithms
+	return c
+}
+
 func (c *Context) WithAlternateBlobSourceStrategy(alternateStrategy AlternateBlobSourceStrategy) *Context {
 	c.Alternates = alternateStrategy
 	return c
@@ -164,20 +397,32 @@ func (c *Context) Reset() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	c.pings = nil
-	c.redirect = nil
+	c.pings = make(map[url.URL]pingResult)
+	c.redirect = make(map[url.URL]*url.URL)
+}
+
+// pingResult is the cached result of pinging a registry.
+type pingResult struct {
+	err error
+	// expiration is when the result must not be used anymore, zero if it never expires.
+	expiration time.Time
 }
 
 func (c *Context) cachedPing(src url.URL) (*url.URL, error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	err, ok := c.pings[src]
+	result, ok := c.pings[src]
 	if !ok {
 		return nil, nil
 	}
-	if err != nil {
-		return nil, err
+	if !result.expiration.IsZero() && !nowFn().Before(result.expiration) {
+		delete(c.pings, src)
+		delete(c.redirect, src)
+		return nil, nil
+	}
+	if result.err != nil {
+		return nil, result.err
 	}
 	if redirect, ok := c.redirect[src]; ok {
 		src = *redirect
@@ -210,7 +455,11 @@ func (c *Context) Ping(ctx context.Context, registry *url.URL, insecure bool) (h
 
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	c.pings[src] = err
+	result := pingResult{err: err}
+	if c.PingCacheTTL > 0 {
+		result.expiration = nowFn().Add(c.PingCacheTTL)
+	}
+	c.pings[src] = result
 	if err != nil {
 		return nil, nil, err
 	}
@@ -255,10 +504,12 @@ func (c *Context) Repository(ctx context.Context, registry *url.URL, repoName st
 		url:   registry,
 	}
 	return &blobMirroredRepository{
-		locator:   locator,
-		insecure:  insecure,
-		strategy:  c.Alternates,
-		retriever: c,
+		locator:     locator,
+		insecure:    insecure,
+		strategy:    c.Alternates,
+		selector:    c.Selector,
+		maxAttempts: c.MaxMirrorAttempts,
+		retriever:   c,
 	}, nil
 }
 
@@ -296,13 +547,18 @@ func (c *Context) connectToRegistry(ctx context.Context, locator repositoryLocat
 		return nil, err
 	}
 	if !c.DisableDigestVerification {
-		repo = repositoryVerifier{Repository: repo}
+		repo = repositoryVerifier{Repository: repo, allowedAlgorithms: c.AllowedDigestAlgorithms}
 	}
 	limiter := c.Limiter
 	if limiter == nil {
 		limiter = rate.NewLimiter(rate.Limit(5), 5)
 	}
-	return NewLimitedRetryRepository(locator.ref, repo, c.Retries, limiter), nil
+	retryRepo := NewLimitedRetryRepositoryWithTimeout(locator.ref, repo, c.Retries, limiter, c.OperationTimeout).(*retryRepository)
+	if c.MaxRetryAfter > 0 {
+		retryRepo.maxRetryAfter = c.MaxRetryAfter
+	}
+	retryRepo.stats = c.pullStats()
+	return retryRepo, nil
 }
 
 func (c *Context) ping(registry url.URL, insecure bool, transport http.RoundTripper) (*url.URL, error) {
@@ -335,6 +591,11 @@ func (c *Context) ping(registry url.URL, insecure bool, transport http.RoundTrip
 	if len(versions) == 0 {
 		klog.V(5).Infof("Registry responded to v2 Docker endpoint, but has no header for Docker Distribution %s: %d, %#v", req.URL, resp.StatusCode, resp.Header)
 		switch {
+		case c.RequireAPIVersionHeader:
+			return nil, &ErrNotV2Registry{
+				Registry: registry.String(),
+				Status:   resp.Status,
+			}
 		case resp.StatusCode >= 200 && resp.StatusCode < 300:
 			// v2
 		case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
@@ -398,21 +659,32 @@ func (c *Context) cachedTransport(rt http.RoundTripper, host string, scopes []au
 		creds = c.CredentialsFactory.CredentialStoreFor(ref.AsRepository().String())
 	}
 
+	tokenOptions := auth.TokenHandlerOptions{
+		Transport:   rt,
+		Credentials: creds,
+		Scopes:      scopes,
+	}
+	var tokenHandler auth.AuthenticationHandler
+	if c.TokenCache != nil {
+		tokenHandler = newCachingTokenHandler(c.TokenCache, host, tokenOptions)
+	} else {
+		tokenHandler = auth.NewTokenHandlerWithOptions(tokenOptions)
+	}
+
 	modifiers := []transport.RequestModifier{
 		// TODO: slightly smarter authorizer that retries unauthenticated requests
 		// TODO: make multiple attempts if the first credential fails
 		auth.NewAuthorizer(
 			c.Challenges,
-			auth.NewTokenHandlerWithOptions(auth.TokenHandlerOptions{
-				Transport:   rt,
-				Credentials: creds,
-				Scopes:      scopes,
-			}),
+			tokenHandler,
 			auth.NewBasicHandler(creds),
 		),
 	}
 	modifiers = append(modifiers, c.RequestModifiers...)
-	t := transport.NewTransport(rt, modifiers...)
+	t := &responseErrorTransport{
+		rt:           &headFallbackTransport{rt: transport.NewTransport(rt, modifiers...)},
+		unauthorized: c.unauthorized,
+	}
 	c.cachedTransports = append(c.cachedTransports, transportCache{
 		rt:        rt,
 		host:      host,
@@ -422,6 +694,74 @@ func (c *Context) cachedTransport(rt http.RoundTripper, host string, scopes []au
 	return t
 }
 
+// unauthorized drops the token rejected by an unauthorized response from the token cache and refreshes
+// the challenges of the registry if they changed.
+func (c *Context) unauthorized(resp *http.Response) {
+	if c.TokenCache != nil {
+		c.TokenCache.forgetRejectedToken(resp)
+	}
+	c.refreshChangedChallenges(resp)
+}
+
+// refreshChangedChallenges replaces the challenges of a registry and forgets its cached pings when an
+// unauthorized response challenges the client with a realm different from the one the registry returned
+// when it was pinged, for example after the registry moved its token server.
+func (c *Context) refreshChangedChallenges(resp *http.Response) {
+	if resp.Request == nil || resp.Request.URL == nil {
+		return
+	}
+	reqURL := resp.Request.URL
+	pingURL := url.URL{Scheme: reqURL.Scheme, Host: reqURL.Host, Path: reqURL.Path}
+	if i := strings.Index(pingURL.Path, "/v2/"); i != -1 {
+		pingURL.Path = pingURL.Path[:i+4]
+	}
+	cached, err := c.Challenges.GetChallenges(pingURL)
+	if err != nil || !realmChanged(cached, challenge.ResponseChallenges(resp)) {
+		return
+	}
+
+	klog.V(4).Infof("Registry %s changed its authentication realm, refreshing the cached ping", reqURL.Host)
+	if err := c.Challenges.AddResponse(&http.Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Request:    &http.Request{URL: &pingURL},
+	}); err != nil {
+		klog.V(4).Infof("Unable to update the challenges of registry %s: %v", reqURL.Host, err)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for src, redirect := range c.redirect {
+		if redirect.Scheme == reqURL.Scheme && redirect.Host == reqURL.Host {
+			delete(c.pings, src)
+			delete(c.redirect, src)
+		}
+	}
+	for src := range c.pings {
+		if src.Scheme == reqURL.Scheme && src.Host == reqURL.Host {
+			delete(c.pings, src)
+			delete(c.redirect, src)
+		}
+	}
+}
+
+// realmChanged returns true if a challenge in current has a realm different from the challenge of the
+// same scheme in cached.
+func realmChanged(cached, current []challenge.Challenge) bool {
+	for _, cur := range current {
+		realm, ok := cur.Parameters["realm"]
+		if !ok {
+			continue
+		}
+		for _, prev := range cached {
+			if strings.EqualFold(prev.Scheme, cur.Scheme) && prev.Parameters["realm"] != realm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (c *Context) scopes(repoName string) []auth.Scope {
 	scopes := make([]auth.Scope, 0, 1+len(c.Scopes))
 	scopes = append(scopes, c.Scopes...)
@@ -439,25 +779,44 @@ func (c *Context) repositoryTransport(t http.RoundTripper, registry *url.URL, re
 
 var nowFn = time.Now
 
+// defaultMaxRetryAfter is the longest a throttled request waits before it is retried, unless the
+// context sets a different maximum.
+const defaultMaxRetryAfter = time.Minute
+
 type retryRepository struct {
 	distribution.Repository
 
 	ref     imagereference.DockerImageReference
 	limiter *rate.Limiter
 	retries int
-	sleepFn func(time.Duration)
+	timeout time.Duration
+	// maxRetryAfter caps the delay requested by the registry before retrying a throttled request
+	maxRetryAfter time.Duration
+	sleepFn       func(time.Duration)
+	// stats collects the requests of the repository, nil if stats are not collected
+	stats *pullStats
 }
 
 // NewLimitedRetryRepository wraps a distribution.Repository with helpers that will retry temporary failures
 // over a limited time window and duration, and also obeys a rate limit.
 func NewLimitedRetryRepository(ref imagereference.DockerImageReference, repo distribution.Repository, retries int, limiter *rate.Limiter) RepositoryWithLocation {
+	return NewLimitedRetryRepositoryWithTimeout(ref, repo, retries, limiter, 0)
+}
+
+// NewLimitedRetryRepositoryWithTimeout is like NewLimitedRetryRepository, but also bounds each attempt of a
+// manifest Get, blob Stat and blob Open to timeout, as described by Context.WithOperationTimeout. A zero
+// timeout disables the bound.
+func NewLimitedRetryRepositoryWithTimeout(ref imagereference.DockerImageReference, repo distribution.Repository, retries int, limiter *rate.Limiter, timeout time.Duration) RepositoryWithLocation {
 	return &retryRepository{
 		Repository: repo,
 
 		ref:     ref,
 		limiter: limiter,
 		retries: retries,
-		sleepFn: time.Sleep,
+		timeout: timeout,
+
+		maxRetryAfter: defaultMaxRetryAfter,
+		sleepFn:       time.Sleep,
 	}
 }
 
@@ -470,6 +829,11 @@ func isTemporaryHTTPError(err error) (time.Duration, bool) {
 	if err == nil {
 		return 0, false
 	}
+	// the error is wrapped by the http client
+	var tooManyRequests *ErrTooManyRequests
+	if errors.As(err, &tooManyRequests) {
+		return tooManyRequests.RetryAfter, true
+	}
 	switch t := err.(type) {
 	case net.Error:
 		return time.Second, t.Temporary() || t.Timeout()
@@ -495,9 +859,18 @@ func isTemporaryHTTPError(err error) (time.Duration, bool) {
 
 // shouldRetry returns true if the error was temporary and count is less than retries.
 func (c *retryRepository) shouldRetry(count int, err error) bool {
+	return c.shouldRetryUntil(count, err, time.Time{})
+}
+
+// shouldRetryUntil is like shouldRetry, but does not retry once deadline has passed and does not back off
+// past deadline. A zero deadline is ignored.
+func (c *retryRepository) shouldRetryUntil(count int, err error, deadline time.Time) bool {
 	if err == nil {
 		return false
 	}
+	if _, ok := err.(*ErrOperationTimeout); ok {
+		return false
+	}
 	retryAfter, ok := isTemporaryHTTPError(err)
 	if !ok {
 		return false
@@ -505,16 +878,79 @@ func (c *retryRepository) shouldRetry(count int, err error) bool {
 	if count >= c.retries {
 		return false
 	}
+	if c.maxRetryAfter > 0 && retryAfter > c.maxRetryAfter {
+		retryAfter = c.maxRetryAfter
+	}
+	// the backoff must not take longer than the attempt it precedes is allowed to
+	if c.timeout > 0 && retryAfter > c.timeout {
+		retryAfter = c.timeout
+	}
+	if !deadline.IsZero() {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			klog.V(4).Infof("Not retrying request to Docker registry, the retry deadline has passed: %v", err)
+			return false
+		}
+		if retryAfter > remaining {
+			retryAfter = remaining
+		}
+	}
 	c.sleepFn(retryAfter)
+	if c.stats != nil {
+		c.stats.retries.Add(1)
+	}
 	klog.V(4).Infof("Retrying request to Docker registry after encountering error (%d attempts remaining): %v", count, err)
 	return true
 }
 
+// retryDeadline returns the deadline of all attempts of an operation, including the backoff between them,
+// or a zero time if there is no operation timeout. Every attempt may take the full operation timeout, the
+// backoff only uses the time left by attempts that completed early.
+func (c *retryRepository) retryDeadline() time.Time {
+	if c.timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(c.retries+1) * c.timeout)
+}
+
+// attemptTimeout returns how long the next attempt of an operation ending at deadline may take.
+func (c *retryRepository) attemptTimeout(deadline time.Time) time.Duration {
+	if deadline.IsZero() {
+		return c.timeout
+	}
+	if remaining := time.Until(deadline); remaining < c.timeout {
+		return remaining
+	}
+	return c.timeout
+}
+
+// withTimeout returns a context bounded by the operation timeout and deadline, if any.
+func (c *retryRepository) withTimeout(ctx context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.attemptTimeout(deadline))
+}
+
+// timeoutError returns an ErrOperationTimeout if the attempt failed because its own deadline
+// was exceeded, and err otherwise.
+func (c *retryRepository) timeoutError(ctx, attemptCtx context.Context, operation string, err error) error {
+	if err == nil || c.timeout <= 0 || ctx.Err() != nil || attemptCtx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	return &ErrOperationTimeout{Operation: operation, Timeout: c.timeout}
+}
+
+// deniedError returns err as an ErrRepositoryDenied if the registry denied the request by policy.
+func (c *retryRepository) deniedError(err error) error {
+	return RepositoryDeniedError(c.ref.RepositoryName(), err)
+}
+
 // Manifests wraps the manifest service in a retryManifest for shared retries.
 func (c *retryRepository) Manifests(ctx context.Context, options ...distribution.ManifestServiceOption) (distribution.ManifestService, error) {
 	s, err := c.Repository.Manifests(ctx, options...)
 	if err != nil {
-		return nil, err
+		return nil, c.deniedError(err)
 	}
 	return retryManifest{ManifestService: s, repo: c}, nil
 }
@@ -545,21 +981,32 @@ func (c retryManifest) Exists(ctx context.Context, dgst digest.Digest) (bool, er
 		if c.repo.shouldRetry(i, err) {
 			continue
 		}
-		return exists, err
+		return exists, c.repo.deniedError(err)
 	}
 }
 
-// Get retrieves the manifest identified by the digest, if it exists.
+// Get retrieves the manifest identified by the digest, if it exists. The DefaultManifestMediaTypes
+// are accepted unless options select the accepted media types.
 func (c retryManifest) Get(ctx context.Context, dgst digest.Digest, options ...distribution.ManifestServiceOption) (distribution.Manifest, error) {
+	if c.repo.stats != nil {
+		c.repo.stats.manifestGets.Add(1)
+	}
+	if !hasManifestMediaTypes(options) {
+		options = append(options[:len(options):len(options)], distribution.WithManifestMediaTypes(DefaultManifestMediaTypes))
+	}
+	deadline := c.repo.retryDeadline()
 	for i := 0; ; i++ {
 		if err := c.repo.limiter.Wait(ctx); err != nil {
 			return nil, err
 		}
-		m, err := c.ManifestService.Get(ctx, dgst, options...)
-		if c.repo.shouldRetry(i, err) {
+		attemptCtx, cancel := c.repo.withTimeout(ctx, deadline)
+		m, err := c.ManifestService.Get(attemptCtx, dgst, options...)
+		err = c.repo.timeoutError(ctx, attemptCtx, fmt.Sprintf("get manifest %s", dgst), err)
+		cancel()
+		if c.repo.shouldRetryUntil(i, err, deadline) {
 			continue
 		}
-		return m, err
+		return m, c.repo.deniedError(err)
 	}
 }
 
@@ -570,15 +1017,19 @@ type retryBlobStore struct {
 }
 
 func (c retryBlobStore) Stat(ctx context.Context, dgst digest.Digest) (distribution.Descriptor, error) {
+	deadline := c.repo.retryDeadline()
 	for i := 0; ; i++ {
 		if err := c.repo.limiter.Wait(ctx); err != nil {
 			return distribution.Descriptor{}, err
 		}
-		d, err := c.BlobStore.Stat(ctx, dgst)
-		if c.repo.shouldRetry(i, err) {
+		attemptCtx, cancel := c.repo.withTimeout(ctx, deadline)
+		d, err := c.BlobStore.Stat(attemptCtx, dgst)
+		err = c.repo.timeoutError(ctx, attemptCtx, fmt.Sprintf("stat blob %s", dgst), err)
+		cancel()
+		if c.repo.shouldRetryUntil(i, err, deadline) {
 			continue
 		}
-		return d, err
+		return d, c.repo.deniedError(err)
 	}
 }
 
@@ -591,21 +1042,129 @@ func (c retryBlobStore) ServeBlob(ctx context.Context, w http.ResponseWriter, re
 		if c.repo.shouldRetry(i, err) {
 			continue
 		}
-		return err
+		return c.repo.deniedError(err)
 	}
 }
 
 func (c retryBlobStore) Open(ctx context.Context, dgst digest.Digest) (io.ReadSeekCloser, error) {
+	if c.repo.stats != nil {
+		c.repo.stats.blobOpens.Add(1)
+	}
+	operation := fmt.Sprintf("open blob %s", dgst)
+	deadline := c.repo.retryDeadline()
 	for i := 0; ; i++ {
 		if err := c.repo.limiter.Wait(ctx); err != nil {
 			return nil, err
 		}
-		rsc, err := c.BlobStore.Open(ctx, dgst)
-		if c.repo.shouldRetry(i, err) {
+		if c.repo.timeout <= 0 {
+			rsc, err := c.BlobStore.Open(ctx, dgst)
+			if c.repo.shouldRetry(i, err) {
+				continue
+			}
+			if err != nil {
+				return rsc, c.repo.deniedError(err)
+			}
+			if c.repo.stats != nil {
+				rsc = &countingReadSeekCloser{ReadSeekCloser: rsc, stats: c.repo.stats}
+			}
+			return rsc, nil
+		}
+
+		// blobs are read lazily, so the deadline only bounds opening the blob and the first read that
+		// obtains the response, the content itself may take longer to read
+		attemptCtx, cancel := context.WithCancel(ctx)
+		rd := &responseDeadline{timeout: c.repo.attemptTimeout(deadline), cancel: cancel}
+		rd.start()
+		rsc, err := c.BlobStore.Open(attemptCtx, dgst)
+		rd.stop()
+		if err != nil {
+			err = rd.timeoutError(ctx, operation, err)
+			cancel()
+		}
+		if c.repo.shouldRetryUntil(i, err, deadline) {
 			continue
 		}
-		return rsc, err
+		if err != nil {
+			return rsc, c.repo.deniedError(err)
+		}
+		rsc = &responseDeadlineReadSeekCloser{ReadSeekCloser: rsc, ctx: ctx, deadline: rd, operation: operation}
+		if c.repo.stats != nil {
+			rsc = &countingReadSeekCloser{ReadSeekCloser: rsc, stats: c.repo.stats}
+		}
+		return rsc, nil
+	}
+}
+
+// responseDeadline cancels a request that does not obtain a response within timeout. The timer only runs
+// between start and stop, so that reading the content of the response is not bounded.
+type responseDeadline struct {
+	timeout time.Duration
+	cancel  context.CancelFunc
+
+	lock     sync.Mutex
+	timer    *time.Timer
+	timedOut bool
+}
+
+func (d *responseDeadline) start() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.timer = time.AfterFunc(d.timeout, func() {
+		d.lock.Lock()
+		d.timedOut = true
+		d.lock.Unlock()
+		d.cancel()
+	})
+}
+
+func (d *responseDeadline) stop() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+}
+
+// timeoutError returns an ErrOperationTimeout if the request was cancelled by the deadline and not by ctx,
+// and err otherwise.
+func (d *responseDeadline) timeoutError(ctx context.Context, operation string, err error) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if err == nil || !d.timedOut || ctx.Err() != nil {
+		return err
+	}
+	return &ErrOperationTimeout{Operation: operation, Timeout: d.timeout}
+}
+
+// responseDeadlineReadSeekCloser bounds the first read of a lazily opened blob, which obtains the response,
+// by the response deadline. Closing the reader releases the context of the request.
+type responseDeadlineReadSeekCloser struct {
+	io.ReadSeekCloser
+	ctx       context.Context
+	deadline  *responseDeadline
+	operation string
+	read      bool
+}
+
+func (r *responseDeadlineReadSeekCloser) Read(p []byte) (int, error) {
+	if r.read {
+		return r.ReadSeekCloser.Read(p)
+	}
+	r.read = true
+	r.deadline.start()
+	n, err := r.ReadSeekCloser.Read(p)
+	r.deadline.stop()
+	if err != nil && err != io.EOF {
+		err = r.deadline.timeoutError(r.ctx, r.operation, err)
 	}
+	return n, err
+}
+
+func (r *responseDeadlineReadSeekCloser) Close() error {
+	r.deadline.stop()
+	defer r.deadline.cancel()
+	return r.ReadSeekCloser.Close()
 }
 
 type retryTags struct {
@@ -622,7 +1181,7 @@ func (c *retryTags) Get(ctx context.Context, tag string) (distribution.Descripto
 		if c.repo.shouldRetry(i, err) {
 			continue
 		}
-		return t, err
+		return t, c.repo.deniedError(err)
 	}
 }
 
@@ -635,7 +1194,7 @@ func (c *retryTags) All(ctx context.Context) ([]string, error) {
 		if c.repo.shouldRetry(i, err) {
 			continue
 		}
-		return t, err
+		return t, c.repo.deniedError(err)
 	}
 }
 
@@ -648,13 +1207,14 @@ func (c *retryTags) Lookup(ctx context.Context, digest distribution.Descriptor)
 		if c.repo.shouldRetry(i, err) {
 			continue
 		}
-		return t, err
+		return t, c.repo.deniedError(err)
 	}
 }
 
 // repositoryVerifier ensures that manifests are verified when they are retrieved via digest
 type repositoryVerifier struct {
 	distribution.Repository
+	allowedAlgorithms []digest.Algorithm
 }
 
 // Manifests returns a ManifestService that checks whether manifests match their digest.
@@ -663,21 +1223,27 @@ func (r repositoryVerifier) Manifests(ctx context.Context, options ...distributi
 	if err != nil {
 		return nil, err
 	}
-	return manifestServiceVerifier{ManifestService: ms}, nil
+	return manifestServiceVerifier{ManifestService: ms, allowedAlgorithms: r.allowedAlgorithms}, nil
 }
 
 // Blobs returns a BlobStore that checks whether blob content returned from the server matches the expected digest.
 func (r repositoryVerifier) Blobs(ctx context.Context) distribution.BlobStore {
-	return blobStoreVerifier{BlobStore: r.Repository.Blobs(ctx)}
+	return blobStoreVerifier{BlobStore: r.Repository.Blobs(ctx), allowedAlgorithms: r.allowedAlgorithms}
 }
 
 // manifestServiceVerifier wraps the manifest service and ensures that content retrieved by digest matches that digest.
 type manifestServiceVerifier struct {
 	distribution.ManifestService
+	allowedAlgorithms []digest.Algorithm
 }
 
 // Get retrieves the manifest identified by the digest and guarantees it matches the content it is retrieved by.
 func (m manifestServiceVerifier) Get(ctx context.Context, dgst digest.Digest, options ...distribution.ManifestServiceOption) (distribution.Manifest, error) {
+	if len(dgst) > 0 {
+		if err := verifyDigestAlgorithm(dgst, m.allowedAlgorithms); err != nil {
+			return nil, err
+		}
+	}
 	manifest, err := m.ManifestService.Get(ctx, dgst, options...)
 	if err != nil {
 		return nil, err
@@ -742,10 +1308,16 @@ func ContentDigestForManifest(manifest distribution.Manifest, algo digest.Algori
 // blobStoreVerifier wraps the blobs service and ensures that content retrieved by digest matches that digest.
 type blobStoreVerifier struct {
 	distribution.BlobStore
+	allowedAlgorithms []digest.Algorithm
 }
 
 // Get retrieves the blob identified by the digest and guarantees it matches the content it is retrieved by.
 func (b blobStoreVerifier) Get(ctx context.Context, dgst digest.Digest) ([]byte, error) {
+	if len(dgst) > 0 {
+		if err := verifyDigestAlgorithm(dgst, b.allowedAlgorithms); err != nil {
+			return nil, err
+		}
+	}
 	data, err := b.BlobStore.Get(ctx, dgst)
 	if err != nil {
 		return nil, err
@@ -761,6 +1333,11 @@ func (b blobStoreVerifier) Get(ctx context.Context, dgst digest.Digest) ([]byte,
 
 // Open streams the blob identified by the digest and guarantees it matches the content it is retrieved by.
 func (b blobStoreVerifier) Open(ctx context.Context, dgst digest.Digest) (io.ReadSeekCloser, error) {
+	if len(dgst) > 0 {
+		if err := verifyDigestAlgorithm(dgst, b.allowedAlgorithms); err != nil {
+			return nil, err
+		}
+	}
 	rsc, err := b.BlobStore.Open(ctx, dgst)
 	if err != nil {
 		return nil, err
@@ -775,6 +1352,24 @@ func (b blobStoreVerifier) Open(ctx context.Context, dgst digest.Digest) (io.Rea
 	return rsc, nil
 }
 
+// verifyDigestAlgorithm returns an error if the content of the digest cannot be verified because its algorithm is
+// not available, or if the algorithm is not one of the allowed algorithms. All available algorithms are allowed if
+// allowed is empty.
+func verifyDigestAlgorithm(dgst digest.Digest, allowed []digest.Algorithm) error {
+	if err := dgst.Validate(); err != nil {
+		return fmt.Errorf("unable to verify the content of digest %s: %w", dgst, err)
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, algorithm := range allowed {
+		if dgst.Algorithm() == algorithm {
+			return nil
+		}
+	}
+	return fmt.Errorf("the digest algorithm %s of %s is not allowed, allowed algorithms: %v", dgst.Algorithm(), dgst, allowed)
+}
+
 // readSeekCloserVerifier performs validation over the stream returned by a io.ReadSeekCloser returned
 // by blobService.Open.
 type readSeekCloserVerifier struct {
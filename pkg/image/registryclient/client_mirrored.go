@@ -15,6 +15,7 @@ import (
 	"github.com/distribution/distribution/v3/registry/client/auth"
 	"github.com/opencontainers/go-digest"
 	"github.com/openshift/library-go/pkg/image/reference"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
 
 	distributionreference "github.com/distribution/distribution/v3/reference"
@@ -36,6 +37,15 @@ type AlternateBlobSourceStrategy interface {
 	OnFailure(ctx context.Context, locator reference.DockerImageReference) (alternateRepositories []reference.DockerImageReference, err error)
 }
 
+// MirrorSelector is consulted right before the locations returned by an AlternateBlobSourceStrategy are
+// searched for content and may change the order in which they are attempted, for instance to prefer the
+// mirrors that respond faster. It is invoked both for the result of FirstRequest and OnFailure.
+type MirrorSelector interface {
+	// SelectMirrors returns candidates in the order they should be attempted. Candidates that are not
+	// returned will not be searched. The candidates slice may be modified by the implementation.
+	SelectMirrors(ctx context.Context, locator reference.DockerImageReference, candidates []reference.DockerImageReference) []reference.DockerImageReference
+}
+
 // ManifestWithLocationService extends the ManifestService to allow clients to retrieve a manifest and
 // get the location of the mirrored manifest. Not all ManifestServices returned from a Repository will
 // support this interface and it must be conditional.
@@ -48,6 +58,18 @@ type ManifestWithLocationService interface {
 	GetWithLocation(ctx context.Context, dgst digest.Digest, options ...distribution.ManifestServiceOption) (distribution.Manifest, reference.DockerImageReference, error)
 }
 
+// BlobWithLocationService extends the BlobService to allow clients to open a blob and get the location
+// of the mirrored blob. Not all BlobServices returned from a Repository will support this interface and
+// it must be conditional.
+type BlobWithLocationService interface {
+	distribution.BlobService
+
+	// OpenWithLocation returns the registry URL the provided blob digest was opened from which may be Repository.Named(),
+	// or one of the blob mirrors if alternate location for blob sources was provided. It returns an error if the digest could not be
+	// located - if an error is returned the source reference (Repository.Named()) will be set.
+	OpenWithLocation(ctx context.Context, dgst digest.Digest) (io.ReadSeekCloser, reference.DockerImageReference, error)
+}
+
 // RepositoryWithLocation extends the Repository and allows clients to know which repository registry this talks to
 // as primary (as a complement to Named() which does not include the URL).
 type RepositoryWithLocation interface {
@@ -84,8 +106,10 @@ type blobMirroredRepository struct {
 	locator  repositoryLocator
 	insecure bool
 
-	strategy  AlternateBlobSourceStrategy
-	retriever blobMirroredRepoRetriever
+	strategy    AlternateBlobSourceStrategy
+	selector    MirrorSelector
+	maxAttempts int
+	retriever   blobMirroredRepoRetriever
 
 	lock  sync.Mutex
 	order []reference.DockerImageReference
@@ -168,27 +192,79 @@ func (r *blobMirroredRepository) errorRepos(ctx context.Context) ([]reference.Do
 	return r.order, nil
 }
 
+// selectRepos returns repos in the order the selector prefers, or unchanged if there is no selector.
+func (r *blobMirroredRepository) selectRepos(ctx context.Context, repos []reference.DockerImageReference) []reference.DockerImageReference {
+	if r.selector == nil || len(repos) == 0 {
+		return repos
+	}
+	// the selector must not reorder the cached list of alternates
+	candidates := make([]reference.DockerImageReference, len(repos))
+	copy(candidates, repos)
+	return r.selector.SelectMirrors(ctx, r.locator.ref, candidates)
+}
+
+// mirrorAttempts counts the repos attempted by a request across the initial repos and the alternates
+// returned on failure, so that the maximum number of attempts applies to the request as a whole.
+type mirrorAttempts struct {
+	max       int
+	attempted int
+	total     int
+	errs      []error
+	// halted is set when an attempt failed in a way that does not allow another attempt
+	halted bool
+}
+
+// limit records repos as candidates of the request and returns those that may still be attempted.
+func (a *mirrorAttempts) limit(repos []reference.DockerImageReference) []reference.DockerImageReference {
+	a.total += len(repos)
+	if a.max <= 0 {
+		return repos
+	}
+	remaining := a.max - a.attempted
+	if remaining <= 0 {
+		return nil
+	}
+	if len(repos) > remaining {
+		return repos[:remaining]
+	}
+	return repos
+}
+
+// err returns the error of the first attempt, or the errors of all attempts combined if repos were skipped
+// because the number of attempts was limited.
+func (a *mirrorAttempts) err() error {
+	if len(a.errs) == 0 {
+		return nil
+	}
+	if a.attempted < a.total {
+		return attemptsExhaustedError(a.attempted, a.total, a.errs)
+	}
+	return a.errs[0]
+}
+
+// attemptsExhaustedError combines the errors of all attempted repos when the number of attempts was limited.
+func attemptsExhaustedError(attempted, total int, errs []error) error {
+	return fmt.Errorf("gave up after attempting %d of %d locations: %w", attempted, total, utilerrors.NewAggregate(errs))
+}
+
 // attemptRepos will invoke fn on all repos until fn returns no error. fn is expected to be idempotent.
-func (r *blobMirroredRepository) attemptRepos(ctx context.Context, repos []reference.DockerImageReference, fn func(r RepositoryWithLocation) error) error {
-	var firstErr error
-	for _, ref := range repos {
+// The attempts are recorded in attempts, and the error of the request so far is returned if no repo succeeded.
+func (r *blobMirroredRepository) attemptRepos(ctx context.Context, repos []reference.DockerImageReference, attempts *mirrorAttempts, fn func(r RepositoryWithLocation) error) error {
+	for _, ref := range attempts.limit(repos) {
+		attempts.attempted++
 		klog.V(5).Infof("Attempting to connect to %s", ref)
 		repo, err := r.connect(ctx, ref)
 		if err != nil {
-			if firstErr == nil {
-				firstErr = err
-			}
+			attempts.errs = append(attempts.errs, err)
 			continue
 		}
 		if err := fn(repo); err != nil {
-			if firstErr == nil {
-				firstErr = err
-			}
+			attempts.errs = append(attempts.errs, err)
 			continue
 		}
 		return nil
 	}
-	return firstErr
+	return attempts.err()
 }
 
 // isRequestError reports whether the registry rejected the request or was not
@@ -198,52 +274,56 @@ func isRequestError(err error) bool {
 	var errorCode errcode.ErrorCode
 	responseError := &client.UnexpectedHTTPResponseError{}
 	statusError := &client.UnexpectedHTTPStatusError{}
+	scopeError := &ErrInsufficientScope{}
+	tooManyRequestsError := &ErrTooManyRequests{}
 	return errors.As(err, &errcode.Errors{}) ||
 		errors.As(err, &errcode.Error{}) ||
 		errors.As(err, &errorCode) ||
 		errors.As(err, &responseError) ||
 		errors.As(err, &statusError) ||
+		errors.As(err, &scopeError) ||
+		errors.As(err, &tooManyRequestsError) ||
 		errors.Is(err, auth.ErrNoBasicAuthCredentials)
 }
 
 // attemptFirstConnectedRepo will invoke fn on the first repo that successfully connects.
-func (r *blobMirroredRepository) attemptFirstConnectedRepo(ctx context.Context, repos []reference.DockerImageReference, fn func(r RepositoryWithLocation) error) error {
-	var firstErr error
-	for _, ref := range repos {
+// The attempts are recorded in attempts, and the error of the request so far is returned if no repo succeeded.
+func (r *blobMirroredRepository) attemptFirstConnectedRepo(ctx context.Context, repos []reference.DockerImageReference, attempts *mirrorAttempts, fn func(r RepositoryWithLocation) error) error {
+	for _, ref := range attempts.limit(repos) {
+		attempts.attempted++
 		klog.V(5).Infof("Attempting to connect to %s", ref)
 		repo, err := r.connect(ctx, ref)
 		if err != nil {
-			if firstErr == nil {
-				firstErr = err
-			}
+			attempts.errs = append(attempts.errs, err)
 			continue
 		}
 		if err := fn(repo); err != nil {
 			if !isRequestError(err) {
 				// The request may have been halfway through
 				// and we cannot make another attempt.
+				attempts.halted = true
 				return err
 			}
 			// The registry replied with an error like 4xx or 5xx,
 			// i.e. it hasn't served the blob and we can make
 			// another attempt with a different registry.
-			if firstErr == nil {
-				firstErr = err
-			}
+			attempts.errs = append(attempts.errs, err)
 			continue
 		}
 		return nil
 	}
-	return firstErr
+	return attempts.err()
 }
 
-// alternates accesses the set of repositories that may be valid alternatives for accessing content
+// alternates accesses the set of repositories that may be valid alternatives for accessing content.
+// The maximum number of attempts applies to the initial repos and the alternates returned on failure together.
 func (r *blobMirroredRepository) alternates(ctx context.Context, fn func(r RepositoryWithLocation) error) error {
 	repos, loaded, err := r.initialRepos(ctx)
 	if err != nil {
 		return err
 	}
-	if attemptErr := r.attemptRepos(ctx, repos, fn); attemptErr != nil {
+	attempts := &mirrorAttempts{max: r.maxAttempts}
+	if attemptErr := r.attemptRepos(ctx, r.selectRepos(ctx, repos), attempts, fn); attemptErr != nil {
 		if loaded {
 			return attemptErr
 		}
@@ -254,16 +334,15 @@ func (r *blobMirroredRepository) alternates(ctx context.Context, fn func(r Repos
 		if len(alternates) == 0 {
 			return attemptErr
 		}
-		if alternateErr := r.attemptRepos(ctx, alternates, fn); alternateErr != nil {
-			return attemptErr
-		}
+		return r.attemptRepos(ctx, r.selectRepos(ctx, alternates), attempts, fn)
 	}
 	return nil
 }
 
 // firstConnectedAlternate invokes fn on the first alternate that can be connected to. Use when the
 // function can only be invoked once (such as a method with side effects, like ServeBlob which writes
-// to the response).
+// to the response). The maximum number of attempts applies to the initial repos and the alternates
+// returned on failure together.
 func (r *blobMirroredRepository) firstConnectedAlternate(ctx context.Context, fn func(r RepositoryWithLocation) error) error {
 	repos, loaded, err := r.initialRepos(ctx)
 	if err != nil {
@@ -272,17 +351,16 @@ func (r *blobMirroredRepository) firstConnectedAlternate(ctx context.Context, fn
 	if len(repos) == 0 {
 		return errNoValidAlternates
 	}
-	if attemptErr := r.attemptFirstConnectedRepo(ctx, repos, fn); attemptErr != nil {
-		if loaded {
+	attempts := &mirrorAttempts{max: r.maxAttempts}
+	if attemptErr := r.attemptFirstConnectedRepo(ctx, r.selectRepos(ctx, repos), attempts, fn); attemptErr != nil {
+		if loaded || attempts.halted {
 			return attemptErr
 		}
 		alternates, err := r.errorRepos(ctx)
 		if err != nil {
 			return err
 		}
-		if alternateErr := r.attemptFirstConnectedRepo(ctx, alternates, fn); alternateErr != nil {
-			return attemptErr
-		}
+		return r.attemptFirstConnectedRepo(ctx, r.selectRepos(ctx, alternates), attempts, fn)
 	}
 	return nil
 }
@@ -437,6 +515,7 @@ type blobMirroredBlobstore struct {
 }
 
 var _ distribution.BlobService = blobMirroredBlobstore{}
+var _ BlobWithLocationService = blobMirroredBlobstore{}
 
 func (f blobMirroredBlobstore) Get(ctx context.Context, dgst digest.Digest) ([]byte, error) {
 	var data []byte
@@ -470,7 +549,13 @@ func (f blobMirroredBlobstore) ServeBlob(ctx context.Context, w http.ResponseWri
 }
 
 func (f blobMirroredBlobstore) Open(ctx context.Context, dgst digest.Digest) (io.ReadSeekCloser, error) {
+	rsc, _, err := f.OpenWithLocation(ctx, dgst)
+	return rsc, err
+}
+
+func (f blobMirroredBlobstore) OpenWithLocation(ctx context.Context, dgst digest.Digest) (io.ReadSeekCloser, reference.DockerImageReference, error) {
 	var rsc io.ReadSeekCloser
+	var ref = f.repo.locator.ref
 	err := f.repo.alternates(ctx, func(r RepositoryWithLocation) error {
 		var err error
 		rsc, err = r.Blobs(ctx).Open(ctx, dgst)
@@ -484,9 +569,12 @@ func (f blobMirroredBlobstore) Open(ctx context.Context, dgst digest.Digest) (io
 		// registry can serve the blob.
 		_, err = rsc.Read([]byte{})
 		klog.V(5).Infof("open (read) %s from %s: %v", dgst, r.Named(), err)
+		if err == nil {
+			ref = r.Ref()
+		}
 		return err
 	})
-	return rsc, err
+	return rsc, ref, err
 }
 
 func (f blobMirroredBlobstore) Create(ctx context.Context, options ...distribution.BlobCreateOption) (distribution.BlobWriter, error) {
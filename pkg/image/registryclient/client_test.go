@@ -4,18 +4,22 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"golang.org/x/time/rate"
 
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/rest"
 
 	"github.com/distribution/distribution/v3"
@@ -26,6 +30,7 @@ import (
 	registryclient "github.com/distribution/distribution/v3/registry/client"
 	"github.com/distribution/distribution/v3/registry/client/auth"
 	"github.com/opencontainers/go-digest"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	imagereference "github.com/openshift/library-go/pkg/image/reference"
 )
 
@@ -169,6 +174,7 @@ func TestPing(t *testing.T) {
 	testCases := []struct {
 		name     string
 		uri      url.URL
+		strict   bool
 		expectV2 bool
 		fn       http.HandlerFunc
 	}{
@@ -230,10 +236,48 @@ func TestPing(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:     "strict, no header, 200",
+			uri:      url.URL{Scheme: "https", Host: uri.Host},
+			strict:   true,
+			expectV2: false,
+			fn: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v2/" {
+					w.WriteHeader(200)
+					return
+				}
+			},
+		},
+		{
+			name:     "strict, no header, 401",
+			uri:      url.URL{Scheme: "https", Host: uri.Host},
+			strict:   true,
+			expectV2: false,
+			fn: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v2/" {
+					w.WriteHeader(401)
+					return
+				}
+			},
+		},
+		{
+			name:     "strict, has header, 200",
+			uri:      url.URL{Scheme: "https", Host: uri.Host},
+			strict:   true,
+			expectV2: true,
+			fn: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v2/" {
+					w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+					w.WriteHeader(200)
+					return
+				}
+			},
+		},
 	}
 
 	for _, test := range testCases {
 		fn = test.fn
+		retriever.RequireAPIVersionHeader = test.strict
 		_, err := retriever.ping(test.uri, true, retriever.InsecureTransport)
 		if (err != nil && strings.Contains(err.Error(), "does not support v2 API")) == test.expectV2 {
 			t.Errorf("%s: Expected ErrNotV2Registry, got %v", test.name, err)
@@ -241,6 +285,168 @@ func TestPing(t *testing.T) {
 	}
 }
 
+func TestPingCacheTTL(t *testing.T) {
+	var pings int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			pings++
+		}
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	uri, _ := url.Parse(server.URL)
+
+	now := time.Now()
+	defer func(fn func() time.Time) { nowFn = fn }(nowFn)
+	nowFn = func() time.Time { return now }
+
+	for _, tc := range []struct {
+		name          string
+		ttl           time.Duration
+		expectedPings int
+	}{
+		{name: "cached for the lifetime of the context", expectedPings: 1},
+		{name: "expired", ttl: time.Minute, expectedPings: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pings = 0
+			now = time.Now()
+			c := NewContext(http.DefaultTransport, http.DefaultTransport).WithPingCacheTTL(tc.ttl)
+			for i := 0; i < 3; i++ {
+				if _, _, err := c.Ping(context.Background(), uri, true); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if pings != 1 {
+				t.Fatalf("expected the ping to be cached, got %d pings", pings)
+			}
+			now = now.Add(2 * time.Minute)
+			if _, _, err := c.Ping(context.Background(), uri, true); err != nil {
+				t.Fatal(err)
+			}
+			if pings != tc.expectedPings {
+				t.Errorf("expected %d pings, got %d", tc.expectedPings, pings)
+			}
+		})
+	}
+}
+
+func TestPingCacheRealmChange(t *testing.T) {
+	var pings int
+	var server *httptest.Server
+	realm := "/token"
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token", "/moved/token":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"token":"token","expires_in":300}`))
+			return
+		case "/v2/":
+			pings++
+		}
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+realm+`",service="registry.test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	uri, _ := url.Parse(server.URL)
+	ref, err := imagereference.Parse(uri.Host + "/test/image:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewContext(http.DefaultTransport, http.DefaultTransport)
+	repo, err := c.RepositoryForRef(context.Background(), ref, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	realmOf := func() string {
+		challenges, err := c.Challenges.GetChallenges(url.URL{Scheme: "http", Host: uri.Host, Path: "/v2/"})
+		if err != nil || len(challenges) != 1 {
+			t.Fatalf("unexpected challenges %v: %v", challenges, err)
+		}
+		return challenges[0].Parameters["realm"]
+	}
+
+	// an unauthorized response with the same realm keeps the cached ping
+	if _, err := repo.Tags(context.Background()).Get(context.Background(), "latest"); err == nil {
+		t.Fatal("expected an unauthorized error")
+	}
+	if _, _, err := c.Ping(context.Background(), ref.RegistryURL(), true); err != nil {
+		t.Fatal(err)
+	}
+	if pings != 1 {
+		t.Errorf("expected the ping to be cached, got %d pings", pings)
+	}
+
+	// the registry moved its token server
+	realm = "/moved/token"
+	if _, err := repo.Tags(context.Background()).Get(context.Background(), "latest"); err == nil {
+		t.Fatal("expected an unauthorized error")
+	}
+	if actual := realmOf(); actual != server.URL+"/moved/token" {
+		t.Errorf("expected the challenge to be refreshed, got realm %q", actual)
+	}
+	if _, _, err := c.Ping(context.Background(), ref.RegistryURL(), true); err != nil {
+		t.Fatal(err)
+	}
+	if pings != 2 {
+		t.Errorf("expected the registry to be pinged again, got %d pings", pings)
+	}
+}
+
+func TestWithDialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	// the registry host is not resolvable, the custom dialer resolves it to the test server
+	var lock sync.Mutex
+	var dialed []string
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		lock.Lock()
+		dialed = append(dialed, address)
+		lock.Unlock()
+		if address != "registry.example.invalid:5000" {
+			return nil, fmt.Errorf("unexpected address %s", address)
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, serverURL.Host)
+	}
+
+	insecureTransport := &http.Transport{}
+	c := NewContext(http.DefaultTransport, insecureTransport).WithDialContext(dial)
+	if insecureTransport.DialContext != nil || http.DefaultTransport.(*http.Transport).DialContext == nil {
+		t.Fatalf("expected the transports passed to the context not to be modified")
+	}
+
+	_, src, err := c.Ping(context.Background(), &url.URL{Scheme: "http", Host: "registry.example.invalid:5000"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Host != "registry.example.invalid:5000" {
+		t.Errorf("unexpected registry URL %s", src)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if len(dialed) == 0 {
+		t.Fatal("expected the custom dialer to be used")
+	}
+	for _, address := range dialed {
+		if address != "registry.example.invalid:5000" {
+			t.Errorf("unexpected dialed address %s", address)
+		}
+	}
+}
+
 var unlimited = rate.NewLimiter(rate.Inf, 100)
 
 type temporaryError struct{}
@@ -272,6 +478,7 @@ func TestShouldRetry(t *testing.T) {
 		t.Fatal(sleeps)
 	}
 
+	defer func(fn func() time.Time) { nowFn = fn }(nowFn)
 	now := time.Unix(1, 0)
 	nowFn = func() time.Time {
 		return now
@@ -291,6 +498,93 @@ func TestShouldRetry(t *testing.T) {
 	}
 }
 
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	defer func(fn func() time.Time) { nowFn = fn }(nowFn)
+	nowFn = func() time.Time { return now }
+
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: ""},
+		{value: "invalid"},
+		{value: "-1"},
+		{value: "0", want: 0, wantOK: true},
+		{value: "120", want: 2 * time.Minute, wantOK: true},
+		{value: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second, wantOK: true},
+		{value: now.Add(-30 * time.Second).Format(http.TimeFormat), want: 0, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			// without Retry-After the response is left to the registry client
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &responseErrorTransport{rt: http.DefaultTransport}}
+	_, throttledErr := client.Get(server.URL)
+	tooManyRequests := &ErrTooManyRequests{}
+	if !errors.As(throttledErr, &tooManyRequests) {
+		t.Fatalf("expected ErrTooManyRequests, got %#v", throttledErr)
+	}
+	if tooManyRequests.RetryAfter != 3*time.Second {
+		t.Errorf("unexpected retry after %s", tooManyRequests.RetryAfter)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var sleeps []time.Duration
+	r := NewLimitedRetryRepository(imagereference.DockerImageReference{}, nil, 3, unlimited).(*retryRepository)
+	r.sleepFn = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	// the delay requested by the registry is honored
+	if !r.shouldRetry(0, throttledErr) {
+		t.Fatal("expected a throttled request to be retried")
+	}
+	// and capped
+	if !r.shouldRetry(1, &url.Error{Op: "Get", URL: server.URL, Err: &ErrTooManyRequests{RetryAfter: time.Hour}}) {
+		t.Fatal("expected a throttled request to be retried")
+	}
+	r.maxRetryAfter = 10 * time.Second
+	if !r.shouldRetry(2, &url.Error{Op: "Get", URL: server.URL, Err: &ErrTooManyRequests{RetryAfter: time.Minute}}) {
+		t.Fatal("expected a throttled request to be retried")
+	}
+	if r.shouldRetry(3, &url.Error{Op: "Get", URL: server.URL, Err: &ErrTooManyRequests{RetryAfter: time.Second}}) {
+		t.Fatal("expected retries to be exhausted")
+	}
+	if !reflect.DeepEqual(sleeps, []time.Duration{3 * time.Second, defaultMaxRetryAfter, 10 * time.Second}) {
+		t.Errorf("unexpected sleeps: %v", sleeps)
+	}
+}
+
 func TestRetryFailure(t *testing.T) {
 	sleeps := 0
 	sleepFn := func(time.Duration) { sleeps++ }
@@ -503,16 +797,254 @@ func TestRetryFailure(t *testing.T) {
 	}
 }
 
+// slowRepository fails the first attempts with failErr and then blocks every
+// request until the context of the request is done.
+type slowRepository struct {
+	mockRepository
+	failErr   error
+	failures  int
+	attempts  int
+	respond   bool
+	readDelay time.Duration
+}
+
+func (r *slowRepository) Manifests(ctx context.Context, options ...distribution.ManifestServiceOption) (distribution.ManifestService, error) {
+	return r, nil
+}
+
+func (r *slowRepository) Blobs(ctx context.Context) distribution.BlobStore {
+	return &slowBlobStore{repo: r, delay: r.readDelay}
+}
+
+func (r *slowRepository) wait(ctx context.Context) error {
+	r.attempts++
+	if r.attempts <= r.failures {
+		return r.failErr
+	}
+	if r.respond {
+		return nil
+	}
+	<-ctx.Done()
+	return &url.Error{Op: "Get", URL: "https://registry.test/v2/", Err: ctx.Err()}
+}
+
+func (r *slowRepository) Get(ctx context.Context, dgst digest.Digest, options ...distribution.ManifestServiceOption) (distribution.Manifest, error) {
+	return nil, r.wait(ctx)
+}
+
+type slowBlobStore struct {
+	distribution.BlobStore
+	repo  *slowRepository
+	delay time.Duration
+}
+
+func (s *slowBlobStore) Stat(ctx context.Context, dgst digest.Digest) (distribution.Descriptor, error) {
+	return distribution.Descriptor{}, s.repo.wait(ctx)
+}
+
+// Open returns a lazy reader like the registry client does: the request is made by the first read, which
+// blocks like the other requests of the repository, and every later read takes delay.
+func (s *slowBlobStore) Open(ctx context.Context, dgst digest.Digest) (io.ReadSeekCloser, error) {
+	return &lazyReadSeekCloser{fakeSeekCloser: fakeSeekCloser{Buffer: bytes.NewBufferString("hello")}, ctx: ctx, repo: s.repo, delay: s.delay}, nil
+}
+
+type lazyReadSeekCloser struct {
+	fakeSeekCloser
+	ctx   context.Context
+	repo  *slowRepository
+	delay time.Duration
+	read  bool
+}
+
+func (r *lazyReadSeekCloser) Read(p []byte) (int, error) {
+	if !r.read {
+		r.read = true
+		if err := r.repo.wait(r.ctx); err != nil {
+			return 0, err
+		}
+		return r.fakeSeekCloser.Read(p[:1])
+	}
+	time.Sleep(r.delay)
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.fakeSeekCloser.Read(p)
+}
+
+func (r *lazyReadSeekCloser) Close() error {
+	return nil
+}
+
+func TestRetryOperationTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	var sleeps []time.Duration
+	sleepFn := func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	// a hanging request is bounded by the timeout and not retried
+	repo := &slowRepository{}
+	r := NewLimitedRetryRepositoryWithTimeout(imagereference.DockerImageReference{}, repo, 4, unlimited, 50*time.Millisecond).(*retryRepository)
+	r.sleepFn = sleepFn
+	m, err := r.Manifests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.Get(ctx, digest.Digest("sha256:foo"))
+	if _, ok := err.(*ErrOperationTimeout); !ok {
+		t.Fatalf("expected ErrOperationTimeout, got %#v", err)
+	}
+	if repo.attempts != 1 || len(sleeps) != 0 {
+		t.Fatalf("expected a single attempt without retries, got %d attempts and %d sleeps", repo.attempts, len(sleeps))
+	}
+
+	// the deadline is reset between retries and the backoff does not exceed the timeout
+	repo = &slowRepository{failErr: errcode.ErrorCodeUnavailable.WithDetail(struct{}{}), failures: 2}
+	r = NewLimitedRetryRepositoryWithTimeout(imagereference.DockerImageReference{}, repo, 4, unlimited, 50*time.Millisecond).(*retryRepository)
+	sleeps = nil
+	r.sleepFn = sleepFn
+	_, err = r.Blobs(ctx).Stat(ctx, digest.Digest("sha256:foo"))
+	if _, ok := err.(*ErrOperationTimeout); !ok {
+		t.Fatalf("expected ErrOperationTimeout, got %#v", err)
+	}
+	if repo.attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", repo.attempts)
+	}
+	if !reflect.DeepEqual(sleeps, []time.Duration{50 * time.Millisecond, 50 * time.Millisecond}) {
+		t.Fatalf("unexpected backoff: %v", sleeps)
+	}
+
+	// a cancelled caller context is not reported as an operation timeout
+	repo = &slowRepository{}
+	r = NewLimitedRetryRepositoryWithTimeout(imagereference.DockerImageReference{}, repo, 4, unlimited, time.Minute).(*retryRepository)
+	r.sleepFn = sleepFn
+	cancelledCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = r.Blobs(ctx).Stat(cancelledCtx, digest.Digest("sha256:foo"))
+	if _, ok := err.(*ErrOperationTimeout); ok || err == nil {
+		t.Fatalf("expected the caller context error, got %#v", err)
+	}
+}
+
+func TestRetryOperationTimeoutBlobOpen(t *testing.T) {
+	ctx := context.Background()
+
+	// a blob that does not obtain a response on the first read is bounded by the timeout
+	repo := &slowRepository{}
+	r := NewLimitedRetryRepositoryWithTimeout(imagereference.DockerImageReference{}, repo, 4, unlimited, 50*time.Millisecond).(*retryRepository)
+	r.sleepFn = func(time.Duration) {}
+	rsc, err := r.Blobs(ctx).Open(ctx, digest.Digest("sha256:foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.ReadAll(rsc)
+	if _, ok := err.(*ErrOperationTimeout); !ok {
+		t.Fatalf("expected ErrOperationTimeout, got %#v", err)
+	}
+	rsc.Close()
+
+	// reading the content of the response may take longer than the timeout
+	repo = &slowRepository{respond: true, readDelay: 100 * time.Millisecond}
+	r = NewLimitedRetryRepositoryWithTimeout(imagereference.DockerImageReference{}, repo, 4, unlimited, 50*time.Millisecond).(*retryRepository)
+	rsc, err = r.Blobs(ctx).Open(ctx, digest.Digest("sha256:foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsc.Close()
+	data, err := io.ReadAll(rsc)
+	if err != nil {
+		t.Fatalf("unexpected error reading the blob: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("unexpected content: %q", data)
+	}
+}
+
+func TestRetryDeadline(t *testing.T) {
+	var sleeps []time.Duration
+	r := NewLimitedRetryRepositoryWithTimeout(imagereference.DockerImageReference{}, &mockRepository{}, 4, unlimited, time.Minute).(*retryRepository)
+	r.sleepFn = func(d time.Duration) { sleeps = append(sleeps, d) }
+	unavailable := errcode.ErrorCodeUnavailable.WithDetail(struct{}{})
+
+	// the backoff does not exceed the time left before the deadline
+	if !r.shouldRetryUntil(0, unavailable, time.Now().Add(10*time.Second)) {
+		t.Fatal("expected a retry before the deadline")
+	}
+	if len(sleeps) != 1 || sleeps[0] > 10*time.Second {
+		t.Fatalf("unexpected backoff: %v", sleeps)
+	}
+
+	// no retry is attempted once the deadline has passed
+	if r.shouldRetryUntil(1, unavailable, time.Now().Add(-time.Second)) {
+		t.Fatal("expected no retry after the deadline")
+	}
+	if len(sleeps) != 1 {
+		t.Fatalf("unexpected backoff: %v", sleeps)
+	}
+
+	// the deadline covers every attempt of the operation
+	deadline := r.retryDeadline()
+	if remaining := time.Until(deadline); remaining <= 4*time.Minute || remaining > 5*time.Minute {
+		t.Fatalf("unexpected retry deadline in %v", remaining)
+	}
+	if r.attemptTimeout(time.Now().Add(time.Second)) > time.Second {
+		t.Fatal("expected the attempt to be bounded by the retry deadline")
+	}
+}
+
+const ociIndexFixture = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {
+      "mediaType": "application/vnd.oci.image.manifest.v1+json",
+      "digest": "sha256:b5b2b2c507a0944348e0303114d8d93aaaa081732b86451d9bce1f432a537bc7",
+      "size": 512,
+      "platform": {
+        "architecture": "amd64",
+        "os": "linux"
+      }
+    }
+  ]
+}`
+
 func Test_verifyManifest_Get(t *testing.T) {
+	ociIndex, _, err := distribution.UnmarshalManifest(imagespecv1.MediaTypeImageIndex, []byte(ociIndexFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ociManifest, _, err := distribution.UnmarshalManifest(imagespecv1.MediaTypeImageManifest, []byte(imageManifestFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	tests := []struct {
 		name     string
 		dgst     digest.Digest
 		err      error
 		manifest distribution.Manifest
 		options  []distribution.ManifestServiceOption
+		allowed  []digest.Algorithm
 		want     distribution.Manifest
 		wantErr  bool
 	}{
+		{
+			name:     "OCI image index",
+			dgst:     digest.FromString(ociIndexFixture),
+			manifest: ociIndex,
+			want:     ociIndex,
+		},
+		{
+			name:     "OCI image manifest",
+			dgst:     digest.FromString(imageManifestFixture),
+			manifest: ociManifest,
+			want:     ociManifest,
+		},
+		{
+			name:     "OCI image index with mismatched digest",
+			dgst:     digest.FromString(imageManifestFixture),
+			manifest: ociIndex,
+			wantErr:  true,
+		},
 		{
 			dgst:     payload1Digest,
 			manifest: &fakeManifest{payload: []byte(payload1)},
@@ -533,12 +1065,45 @@ func Test_verifyManifest_Get(t *testing.T) {
 			manifest: &fakeManifest{payload: []byte(payload1), err: fmt.Errorf("unknown")},
 			wantErr:  true,
 		},
+		{
+			name:     "sha512 digest",
+			dgst:     payload1SHA512Digest,
+			manifest: &fakeManifest{payload: []byte(payload1)},
+			want:     &fakeManifest{payload: []byte(payload1)},
+		},
+		{
+			name:     "sha512 digest with mismatched content",
+			dgst:     payload1SHA512Digest,
+			manifest: &fakeManifest{payload: []byte(payload2)},
+			wantErr:  true,
+		},
+		{
+			name:     "sha512 digest with allowed algorithm",
+			dgst:     payload2SHA512Digest,
+			allowed:  []digest.Algorithm{digest.SHA256, digest.SHA512},
+			manifest: &fakeManifest{payload: []byte(payload2)},
+			want:     &fakeManifest{payload: []byte(payload2)},
+		},
+		{
+			name:     "sha512 digest with disallowed algorithm",
+			dgst:     payload1SHA512Digest,
+			allowed:  []digest.Algorithm{digest.SHA256},
+			manifest: &fakeManifest{payload: []byte(payload1)},
+			wantErr:  true,
+		},
+		{
+			name:     "unavailable algorithm",
+			dgst:     digest.Digest("sha3:" + payload1Digest.Encoded()),
+			manifest: &fakeManifest{payload: []byte(payload1)},
+			wantErr:  true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ms := &fakeManifestService{err: tt.err, manifest: tt.manifest}
 			m := manifestServiceVerifier{
-				ManifestService: ms,
+				ManifestService:   ms,
+				allowedAlgorithms: tt.allowed,
 			}
 			ctx := context.Background()
 			got, err := m.Get(ctx, tt.dgst, tt.options...)
@@ -553,6 +1118,72 @@ func Test_verifyManifest_Get(t *testing.T) {
 	}
 }
 
+func TestRetryManifestDefaultMediaTypes(t *testing.T) {
+	mediaTypes := func(options []distribution.ManifestServiceOption) [][]string {
+		var types [][]string
+		for _, option := range options {
+			if opt, ok := option.(distribution.WithManifestMediaTypesOption); ok {
+				types = append(types, opt.MediaTypes)
+			}
+		}
+		return types
+	}
+
+	ms := &fakeManifestService{manifest: &fakeManifest{payload: []byte(payload1)}}
+	r := NewLimitedRetryRepository(imagereference.DockerImageReference{}, nil, 0, unlimited).(*retryRepository)
+	m := retryManifest{ManifestService: ms, repo: r}
+	if _, err := m.Get(context.Background(), payload1Digest); err != nil {
+		t.Fatal(err)
+	}
+	if got := mediaTypes(ms.options); !reflect.DeepEqual(got, [][]string{DefaultManifestMediaTypes}) {
+		t.Errorf("expected the default media types to be accepted, got %v", got)
+	}
+
+	requested := []string{schema2.MediaTypeManifest}
+	if _, err := m.Get(context.Background(), payload1Digest, distribution.WithManifestMediaTypes(requested)); err != nil {
+		t.Fatal(err)
+	}
+	if got := mediaTypes(ms.options); !reflect.DeepEqual(got, [][]string{requested}) {
+		t.Errorf("expected only the requested media types to be accepted, got %v", got)
+	}
+}
+
+func TestDefaultMediaTypesWithoutDigestVerification(t *testing.T) {
+	var accepted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/":
+			w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+			w.WriteHeader(http.StatusOK)
+		case "/v2/test/manifests/" + payload1Digest.String():
+			accepted = r.Header.Values("Accept")
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	uri, _ := url.Parse(server.URL)
+
+	c := NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials)
+	c.DisableDigestVerification = true
+	repo, err := c.Repository(context.Background(), uri, "test", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := repo.Manifests(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.Get(context.Background(), payload1Digest); err == nil {
+		t.Fatal("expected an error for a missing manifest")
+	}
+	if !reflect.DeepEqual(accepted, DefaultManifestMediaTypes) {
+		t.Errorf("expected the default media types to be accepted, got %v", accepted)
+	}
+}
+
 func Test_verifyManifest_Put(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -619,6 +1250,9 @@ const (
 var (
 	payload1Digest = digest.SHA256.FromString(payload1)
 	payload2Digest = digest.SHA256.FromString(payload2)
+
+	payload1SHA512Digest = digest.SHA512.FromString(payload1)
+	payload2SHA512Digest = digest.SHA512.FromString(payload2)
 )
 
 type fakeManifest struct {
@@ -639,6 +1273,7 @@ type fakeManifestService struct {
 	digest   digest.Digest
 	manifest distribution.Manifest
 	err      error
+	options  []distribution.ManifestServiceOption
 }
 
 func (s *fakeManifestService) Exists(ctx context.Context, dgst digest.Digest) (bool, error) {
@@ -646,6 +1281,7 @@ func (s *fakeManifestService) Exists(ctx context.Context, dgst digest.Digest) (b
 }
 
 func (s *fakeManifestService) Get(ctx context.Context, dgst digest.Digest, options ...distribution.ManifestServiceOption) (distribution.Manifest, error) {
+	s.options = options
 	return s.manifest, s.err
 }
 
@@ -663,6 +1299,7 @@ func Test_blobStoreVerifier_Get(t *testing.T) {
 		bytes   []byte
 		err     error
 		dgst    digest.Digest
+		allowed []digest.Algorithm
 		want    []byte
 		wantErr bool
 	}{
@@ -687,12 +1324,39 @@ func Test_blobStoreVerifier_Get(t *testing.T) {
 			err:     fmt.Errorf("unknown"),
 			wantErr: true,
 		},
+		{
+			name:  "sha512 digest",
+			dgst:  payload1SHA512Digest,
+			bytes: []byte(payload1),
+			want:  []byte(payload1),
+		},
+		{
+			name:    "sha512 digest with mismatched content",
+			dgst:    payload2SHA512Digest,
+			bytes:   []byte(payload1),
+			wantErr: true,
+		},
+		{
+			name:    "sha512 digest with allowed algorithm",
+			dgst:    payload2SHA512Digest,
+			allowed: []digest.Algorithm{digest.SHA512},
+			bytes:   []byte(payload2),
+			want:    []byte(payload2),
+		},
+		{
+			name:    "sha512 digest with disallowed algorithm",
+			dgst:    payload1SHA512Digest,
+			allowed: []digest.Algorithm{digest.SHA256},
+			bytes:   []byte(payload1),
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			bs := &fakeBlobStore{err: tt.err, bytes: tt.bytes}
 			b := blobStoreVerifier{
-				BlobStore: bs,
+				BlobStore:         bs,
+				allowedAlgorithms: tt.allowed,
 			}
 			ctx := context.Background()
 			got, err := b.Get(ctx, tt.dgst)
@@ -713,6 +1377,7 @@ func Test_blobStoreVerifier_Open(t *testing.T) {
 		bytes   []byte
 		err     error
 		dgst    digest.Digest
+		allowed []digest.Algorithm
 		want    func(t *testing.T, got io.ReadSeekCloser)
 		wantErr bool
 	}{
@@ -778,12 +1443,45 @@ func Test_blobStoreVerifier_Open(t *testing.T) {
 			err:     fmt.Errorf("unknown"),
 			wantErr: true,
 		},
+		{
+			name:  "sha512 digest",
+			dgst:  payload2SHA512Digest,
+			bytes: []byte(payload2),
+			want: func(t *testing.T, got io.ReadSeekCloser) {
+				data, err := io.ReadAll(got)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal([]byte(payload2), data) {
+					t.Fatalf("contents not equal: %s", hex.Dump(data))
+				}
+			},
+		},
+		{
+			name:  "sha512 digest with mismatched content",
+			dgst:  payload1SHA512Digest,
+			bytes: []byte(payload2),
+			want: func(t *testing.T, got io.ReadSeekCloser) {
+				_, err := io.ReadAll(got)
+				if err == nil || !strings.Contains(err.Error(), "content integrity error") || !strings.Contains(err.Error(), payload2SHA512Digest.String()) {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name:    "sha512 digest with disallowed algorithm",
+			dgst:    payload1SHA512Digest,
+			allowed: []digest.Algorithm{digest.SHA256},
+			bytes:   []byte(payload1),
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			bs := &fakeBlobStore{err: tt.err, bytes: tt.bytes}
 			b := blobStoreVerifier{
-				BlobStore: bs,
+				BlobStore:         bs,
+				allowedAlgorithms: tt.allowed,
 			}
 			ctx := context.Background()
 			got, err := b.Open(ctx, tt.dgst)
@@ -1647,3 +2345,428 @@ func TestReadMirrorFallback(t *testing.T) {
 		t.Fatalf("unexpected data from blob: %q", string(data))
 	}
 }
+
+type fakeMirrorRetriever struct {
+	repos     map[string]distribution.Repository
+	connected []string
+}
+
+func (r *fakeMirrorRetriever) connectToRegistry(ctx context.Context, locator repositoryLocator, insecure bool) (RepositoryWithLocation, error) {
+	r.connected = append(r.connected, locator.ref.Exact())
+	repo, ok := r.repos[locator.ref.Exact()]
+	if !ok {
+		return nil, fmt.Errorf("unable to connect to %s", locator.ref.Exact())
+	}
+	return NewLimitedRetryRepository(locator.ref, repo, 0, unlimited), nil
+}
+
+type reverseMirrorSelector struct {
+	calls int
+}
+
+func (s *reverseMirrorSelector) SelectMirrors(ctx context.Context, locator imagereference.DockerImageReference, candidates []imagereference.DockerImageReference) []imagereference.DockerImageReference {
+	s.calls++
+	for i, j := 0, len(candidates)-1; i < j; i, j = i+1, j-1 {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	}
+	return candidates
+}
+
+func TestMirrorSelector(t *testing.T) {
+	source := imagereference.DockerImageReference{Registry: "source.test", Namespace: "ns", Name: "image"}
+	first := imagereference.DockerImageReference{Registry: "first.test", Namespace: "ns", Name: "image"}
+	second := imagereference.DockerImageReference{Registry: "second.test", Namespace: "ns", Name: "image"}
+
+	missing := func() distribution.Repository {
+		return &mockRepository{blobs: &mockBlobStore{statErr: distribution.ErrBlobUnknown}}
+	}
+	found := func() distribution.Repository {
+		return &mockRepository{blobs: &mockBlobStore{}}
+	}
+
+	tests := []struct {
+		name          string
+		strategy      *fakeAlternateBlobStrategy
+		selector      *reverseMirrorSelector
+		repos         map[string]distribution.Repository
+		wantConnected []string
+		wantErr       bool
+	}{
+		{
+			name:          "no selector preserves the strategy order",
+			strategy:      &fakeAlternateBlobStrategy{FirstAlternates: []imagereference.DockerImageReference{first, second, source}},
+			repos:         map[string]distribution.Repository{first.Exact(): missing(), second.Exact(): found(), source.Exact(): found()},
+			wantConnected: []string{first.Exact(), second.Exact()},
+		},
+		{
+			name:          "selector reorders the first request alternates",
+			strategy:      &fakeAlternateBlobStrategy{FirstAlternates: []imagereference.DockerImageReference{first, second, source}},
+			selector:      &reverseMirrorSelector{},
+			repos:         map[string]distribution.Repository{first.Exact(): found(), second.Exact(): missing(), source.Exact(): missing()},
+			wantConnected: []string{source.Exact(), second.Exact(), first.Exact()},
+		},
+		{
+			name:          "selector reorders the on failure alternates",
+			strategy:      &fakeAlternateBlobStrategy{FailureAlternates: []imagereference.DockerImageReference{first, second}},
+			selector:      &reverseMirrorSelector{},
+			repos:         map[string]distribution.Repository{source.Exact(): missing(), first.Exact(): found(), second.Exact(): missing()},
+			wantConnected: []string{source.Exact(), second.Exact(), first.Exact()},
+		},
+		{
+			name:          "selector result still falls back on failure",
+			strategy:      &fakeAlternateBlobStrategy{FailureAlternates: []imagereference.DockerImageReference{first, second}},
+			selector:      &reverseMirrorSelector{},
+			repos:         map[string]distribution.Repository{source.Exact(): missing(), first.Exact(): missing(), second.Exact(): missing()},
+			wantConnected: []string{source.Exact(), second.Exact(), first.Exact()},
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retriever := &fakeMirrorRetriever{repos: tt.repos}
+			named, err := reference.WithName(source.RepositoryName())
+			if err != nil {
+				t.Fatal(err)
+			}
+			repo := &blobMirroredRepository{
+				locator:   repositoryLocator{ref: source, named: named},
+				strategy:  tt.strategy,
+				retriever: retriever,
+			}
+			if tt.selector != nil {
+				repo.selector = tt.selector
+			}
+
+			_, err = repo.Blobs(context.Background()).Stat(context.Background(), payload1Digest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(retriever.connected, tt.wantConnected) {
+				t.Errorf("expected repositories to be attempted in order %v, got %v", tt.wantConnected, retriever.connected)
+			}
+			if tt.selector != nil && tt.selector.calls == 0 {
+				t.Errorf("expected the selector to be invoked")
+			}
+			if tt.strategy.FirstAlternates != nil && tt.strategy.FirstAlternates[0] != first {
+				t.Errorf("the selector must not modify the alternates returned by the strategy")
+			}
+		})
+	}
+}
+
+func TestContextCopy(t *testing.T) {
+	selector := &reverseMirrorSelector{}
+	c := NewContext(http.DefaultTransport, http.DefaultTransport).
+		WithCredentials(NoCredentials).
+		WithMirrorSelector(selector).
+		WithMaxMirrorAttempts(2).
+		WithOperationTimeout(time.Minute).
+		WithMaxRetryAfter(time.Second).
+		WithPingCacheTTL(time.Hour).
+		WithAllowedDigestAlgorithms(digest.SHA256)
+	c.DisableDigestVerification = true
+
+	copied := c.Copy()
+	if copied.Selector != MirrorSelector(selector) {
+		t.Errorf("expected the mirror selector to be copied, got %#v", copied.Selector)
+	}
+	if copied.Credentials != NoCredentials {
+		t.Errorf("expected the credentials to be copied, got %#v", copied.Credentials)
+	}
+	if copied.MaxMirrorAttempts != 2 || copied.OperationTimeout != time.Minute || copied.MaxRetryAfter != time.Second || copied.PingCacheTTL != time.Hour {
+		t.Errorf("expected the limits to be copied, got %#v", copied)
+	}
+	if !copied.DisableDigestVerification || !reflect.DeepEqual(copied.AllowedDigestAlgorithms, []digest.Algorithm{digest.SHA256}) {
+		t.Errorf("expected the digest verification settings to be copied, got %#v", copied)
+	}
+}
+
+func TestMaxMirrorAttempts(t *testing.T) {
+	source := imagereference.DockerImageReference{Registry: "source.test", Namespace: "ns", Name: "image"}
+	var mirrors []imagereference.DockerImageReference
+	repos := map[string]distribution.Repository{}
+	for i := 0; i < 5; i++ {
+		mirror := imagereference.DockerImageReference{Registry: fmt.Sprintf("mirror-%d.test", i), Namespace: "ns", Name: "image"}
+		mirrors = append(mirrors, mirror)
+		repos[mirror.Exact()] = &mockRepository{blobs: &mockBlobStore{statErr: distribution.ErrBlobUnknown}}
+	}
+
+	retriever := &fakeMirrorRetriever{repos: repos}
+	named, err := reference.WithName(source.RepositoryName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &blobMirroredRepository{
+		locator:     repositoryLocator{ref: source, named: named},
+		strategy:    &fakeAlternateBlobStrategy{FirstAlternates: mirrors},
+		maxAttempts: 2,
+		retriever:   retriever,
+	}
+
+	_, err = repo.Blobs(context.Background()).Stat(context.Background(), payload1Digest)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	wantConnected := []string{mirrors[0].Exact(), mirrors[1].Exact()}
+	if !reflect.DeepEqual(retriever.connected, wantConnected) {
+		t.Errorf("expected only %v to be attempted, got %v", wantConnected, retriever.connected)
+	}
+	if !strings.Contains(err.Error(), "gave up after attempting 2 of 5 locations") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var aggregate utilerrors.Aggregate
+	if !errors.As(err, &aggregate) || len(aggregate.Errors()) != 2 {
+		t.Errorf("expected the errors of both attempts to be combined, got %v", err)
+	}
+	if !errors.Is(err, distribution.ErrBlobUnknown) {
+		t.Errorf("expected the combined error to contain %v, got %v", distribution.ErrBlobUnknown, err)
+	}
+
+	// the attempts of the source and of the alternates returned on failure are counted together
+	repos[source.Exact()] = &mockRepository{blobs: &mockBlobStore{statErr: distribution.ErrBlobUnknown}}
+	retriever = &fakeMirrorRetriever{repos: repos}
+	repo = &blobMirroredRepository{
+		locator:     repositoryLocator{ref: source, named: named},
+		strategy:    &fakeAlternateBlobStrategy{FailureAlternates: mirrors},
+		maxAttempts: 2,
+		retriever:   retriever,
+	}
+	_, err = repo.Blobs(context.Background()).Stat(context.Background(), payload1Digest)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	wantConnected = []string{source.Exact(), mirrors[0].Exact()}
+	if !reflect.DeepEqual(retriever.connected, wantConnected) {
+		t.Errorf("expected only %v to be attempted, got %v", wantConnected, retriever.connected)
+	}
+	if !strings.Contains(err.Error(), "gave up after attempting 2 of 6 locations") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !errors.As(err, &aggregate) || len(aggregate.Errors()) != 2 {
+		t.Errorf("expected the errors of both attempts to be combined, got %v", err)
+	}
+}
+
+type readableRepository struct {
+	mockRepository
+	readErr error
+}
+
+func (r *readableRepository) Blobs(ctx context.Context) distribution.BlobStore {
+	return &readableBlobStore{readErr: r.readErr}
+}
+
+type readableBlobStore struct {
+	distribution.BlobStore
+	readErr error
+}
+
+func (s *readableBlobStore) Open(ctx context.Context, dgst digest.Digest) (io.ReadSeekCloser, error) {
+	return &erroringReadSeekCloser{fakeSeekCloser: fakeSeekCloser{Buffer: bytes.NewBufferString("hello")}, err: s.readErr}, nil
+}
+
+type erroringReadSeekCloser struct {
+	fakeSeekCloser
+	err error
+}
+
+func (r *erroringReadSeekCloser) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.fakeSeekCloser.Read(p)
+}
+
+func TestBlobWithLocationService_OpenWithLocation(t *testing.T) {
+	source := imagereference.DockerImageReference{Registry: "source.test", Namespace: "ns", Name: "image"}
+	first := imagereference.DockerImageReference{Registry: "first.test", Namespace: "ns", Name: "image"}
+	second := imagereference.DockerImageReference{Registry: "second.test", Namespace: "ns", Name: "image"}
+
+	missing := &readableRepository{readErr: distribution.ErrBlobUnknown}
+	found := &readableRepository{}
+
+	tests := []struct {
+		name     string
+		strategy AlternateBlobSourceStrategy
+		repos    map[string]distribution.Repository
+		wantRef  imagereference.DockerImageReference
+		wantErr  bool
+	}{
+		{
+			name:    "no alternates",
+			repos:   map[string]distribution.Repository{source.Exact(): found},
+			wantRef: source,
+		},
+		{
+			name:     "served by the second alternate",
+			strategy: &fakeAlternateBlobStrategy{FirstAlternates: []imagereference.DockerImageReference{first, second, source}},
+			repos:    map[string]distribution.Repository{first.Exact(): missing, second.Exact(): found, source.Exact(): found},
+			wantRef:  second,
+		},
+		{
+			name:     "served by an alternate on failure",
+			strategy: &fakeAlternateBlobStrategy{FailureAlternates: []imagereference.DockerImageReference{first, second}},
+			repos:    map[string]distribution.Repository{source.Exact(): missing, first.Exact(): found, second.Exact(): found},
+			wantRef:  first,
+		},
+		{
+			name:     "not found defaults to the source",
+			strategy: &fakeAlternateBlobStrategy{FirstAlternates: []imagereference.DockerImageReference{first, second}},
+			repos:    map[string]distribution.Repository{first.Exact(): missing, second.Exact(): missing},
+			wantRef:  source,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			named, err := reference.WithName(source.RepositoryName())
+			if err != nil {
+				t.Fatal(err)
+			}
+			repo := &blobMirroredRepository{
+				locator:   repositoryLocator{ref: source, named: named},
+				strategy:  tt.strategy,
+				retriever: &fakeMirrorRetriever{repos: tt.repos},
+			}
+			bs, ok := repo.Blobs(context.Background()).(BlobWithLocationService)
+			if !ok {
+				t.Fatalf("expected blob store to implement location retrieval")
+			}
+			rsc, ref, err := bs.OpenWithLocation(context.Background(), payload1Digest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ref != tt.wantRef {
+				t.Errorf("expected blob to be served from %s, got %s", tt.wantRef.Exact(), ref.Exact())
+			}
+			if err != nil {
+				return
+			}
+			data, err := io.ReadAll(rsc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "hello" {
+				t.Errorf("unexpected blob content %q", string(data))
+			}
+		})
+	}
+}
+
+func TestInsufficientScope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/":
+			w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+			w.WriteHeader(http.StatusOK)
+		case "/v2/test/image/manifests/" + payload1Digest.String():
+			w.Header().Set("WWW-Authenticate", `Bearer realm="https://auth.registry.test/token",service="registry.test",scope="repository:test/image:pull",error="insufficient_scope",error_description="pull access denied"`)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":[{"code":"DENIED","message":"requested access to the resource is denied"}]}`))
+		case "/v2/test/image/blobs/" + payload1Digest.String():
+			w.Header().Set("WWW-Authenticate", `Bearer realm="https://auth.registry.test/token",service="registry.test",scope="repository:test/image:pull,push",error="insufficient_scope"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/v2/test/image/blobs/" + payload2Digest.String():
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	uri, _ := url.Parse(server.URL)
+
+	c := NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials)
+	repo, err := c.Repository(context.Background(), uri, "test/image", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := repo.Manifests(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ms.Get(context.Background(), payload1Digest)
+	scopeErr := &ErrInsufficientScope{}
+	if !errors.As(err, &scopeErr) {
+		t.Fatalf("expected ErrInsufficientScope, got %#v", err)
+	}
+	if scopeErr.Scope != "repository:test/image:pull" {
+		t.Errorf("unexpected scope %q", scopeErr.Scope)
+	}
+	if scopeErr.Description != "pull access denied" {
+		t.Errorf("unexpected description %q", scopeErr.Description)
+	}
+
+	_, err = repo.Blobs(context.Background()).Stat(context.Background(), payload1Digest)
+	scopeErr = &ErrInsufficientScope{}
+	if !errors.As(err, &scopeErr) {
+		t.Fatalf("expected ErrInsufficientScope, got %#v", err)
+	}
+	if scopeErr.Scope != "repository:test/image:pull,push" {
+		t.Errorf("unexpected scope %q", scopeErr.Scope)
+	}
+
+	// a denied request without an insufficient_scope challenge is not affected
+	_, err = repo.Blobs(context.Background()).Stat(context.Background(), payload2Digest)
+	if err == nil || errors.As(err, &scopeErr) {
+		t.Fatalf("expected a generic error, got %#v", err)
+	}
+}
+
+func TestHeadFallback(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/":
+			w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+			w.WriteHeader(http.StatusOK)
+		case "/v2/test/head/blobs/" + payload1Digest.String():
+			methods = append(methods, "head "+r.Method)
+			w.Header().Set("Content-Length", "4")
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", payload1Digest.String())
+			w.WriteHeader(http.StatusOK)
+			if r.Method == http.MethodGet {
+				w.Write([]byte("test"))
+			}
+		case "/v2/test/get/blobs/" + payload1Digest.String():
+			methods = append(methods, "get "+r.Method)
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Length", "4")
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", payload1Digest.String())
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("test"))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	uri, _ := url.Parse(server.URL)
+
+	c := NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials)
+	for _, name := range []string{"head", "get"} {
+		repo, err := c.Repository(context.Background(), uri, "test/"+name, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc, err := repo.Blobs(context.Background()).Stat(context.Background(), payload1Digest)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if desc.Size != 4 {
+			t.Errorf("%s: unexpected size %d", name, desc.Size)
+		}
+	}
+
+	// existence is checked with HEAD, GET is only used when the registry rejects HEAD
+	expected := []string{"head HEAD", "get HEAD", "get GET"}
+	if !reflect.DeepEqual(methods, expected) {
+		t.Errorf("expected requests %v, got %v", expected, methods)
+	}
+}
@@ -0,0 +1,97 @@
+package registryclient
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/distribution/distribution/v3/registry/api/errcode"
+)
+
+// ErrRepositoryDenied is returned when the registry denies access to a repository by policy, for instance
+// because the repository is quarantined. Unlike authentication failures, which are reported by the registry
+// as unauthorized, the request is not expected to succeed with other credentials. Repositories returned by
+// Context report policy denials of any request as ErrRepositoryDenied.
+type ErrRepositoryDenied struct {
+	// Repository is the repository access was denied to.
+	Repository string
+	// Message is the message of the registry explaining the denial, if any.
+	Message string
+	// Detail is the detail the registry returned with the denial, if any.
+	Detail interface{}
+	// Err is the error returned by the registry.
+	Err error
+}
+
+func (e *ErrRepositoryDenied) Error() string {
+	if len(e.Message) == 0 {
+		return fmt.Sprintf("access to repository %s is denied by the registry", e.Repository)
+	}
+	return fmt.Sprintf("access to repository %s is denied by the registry: %s", e.Repository, e.Message)
+}
+
+func (e *ErrRepositoryDenied) Unwrap() error {
+	return e.Err
+}
+
+// RepositoryDeniedError returns an ErrRepositoryDenied wrapping err if the registry answered a request for
+// repository with the DENIED error code and a detail describing the policy that denied it. Registries also
+// answer requests lacking permissions with DENIED, but without a detail, so those errors are returned
+// unchanged like any other error, including unauthorized errors. Callers can then tell policy denials apart
+// from authentication failures with errors.As.
+func RepositoryDeniedError(repository string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var denied *ErrRepositoryDenied
+	if errors.As(err, &denied) {
+		return err
+	}
+	codeErr, ok := deniedError(err)
+	if !ok || !hasDetail(codeErr.Detail) {
+		return err
+	}
+	return &ErrRepositoryDenied{
+		Repository: repository,
+		Message:    codeErr.Message,
+		Detail:     codeErr.Detail,
+		Err:        err,
+	}
+}
+
+// deniedError returns the first error with the DENIED error code in err.
+func deniedError(err error) (errcode.Error, bool) {
+	var errs errcode.Errors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			if codeErr, ok := deniedError(e); ok {
+				return codeErr, true
+			}
+		}
+		return errcode.Error{}, false
+	}
+	var codeErr errcode.Error
+	if errors.As(err, &codeErr) {
+		return codeErr, codeErr.Code == errcode.ErrorCodeDenied
+	}
+	var code errcode.ErrorCode
+	if errors.As(err, &code) && code == errcode.ErrorCodeDenied {
+		return errcode.Error{Code: code, Message: code.Message()}, true
+	}
+	return errcode.Error{}, false
+}
+
+// hasDetail returns true if the detail of an error code carries any information.
+func hasDetail(detail interface{}) bool {
+	switch t := detail.(type) {
+	case nil:
+		return false
+	case string:
+		return len(t) > 0
+	case map[string]interface{}:
+		return len(t) > 0
+	case []interface{}:
+		return len(t) > 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,100 @@
+package registryclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/distribution/distribution/v3/registry/api/errcode"
+)
+
+func TestRepositoryDeniedError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		switch r.URL.Path {
+		case "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case "/v2/test/quarantined/manifests/latest":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":[{"code":"DENIED","message":"repository is quarantined","detail":{"policy":"vulnerabilities"}}]}`))
+		case "/v2/test/forbidden/manifests/latest":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":[{"code":"DENIED","message":"requested access to the resource is denied"}]}`))
+		case "/v2/test/private/manifests/latest":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	uri, _ := url.Parse(server.URL)
+
+	getErr := func(repository string) error {
+		repo, err := NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials).Repository(context.Background(), uri, repository, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = repo.Tags(context.Background()).Get(context.Background(), "latest")
+		if err == nil {
+			t.Fatalf("expected an error for %s", repository)
+		}
+		return err
+	}
+
+	// policy denials are reported by the client
+	err := getErr("test/quarantined")
+	var denied *ErrRepositoryDenied
+	if !errors.As(err, &denied) {
+		t.Fatalf("expected ErrRepositoryDenied, got %#v", err)
+	}
+	if denied.Repository != "test/quarantined" || denied.Message != "repository is quarantined" {
+		t.Errorf("unexpected denied error: %#v", denied)
+	}
+	if expected := map[string]interface{}{"policy": "vulnerabilities"}; !reflect.DeepEqual(denied.Detail, expected) {
+		t.Errorf("expected detail %v, got %v", expected, denied.Detail)
+	}
+	if err.Error() != "access to repository test/quarantined is denied by the registry: repository is quarantined" {
+		t.Errorf("unexpected message: %v", err)
+	}
+	var errs errcode.Errors
+	if !errors.As(err, &errs) {
+		t.Errorf("expected the registry error to be wrapped, got %#v", err)
+	}
+
+	if err := RepositoryDeniedError("test/quarantined", err); err != error(denied) {
+		t.Errorf("expected ErrRepositoryDenied to be returned unchanged, got %#v", err)
+	}
+
+	// authentication failures and denials without a policy detail are not policy denials
+	for _, repository := range []string{"test/private", "test/forbidden"} {
+		if err := getErr(repository); errors.As(err, &denied) {
+			t.Errorf("%s: expected no ErrRepositoryDenied, got %#v", repository, err)
+		}
+	}
+
+	// wrapped errors with a detail are mapped, other errors are returned unchanged
+	detailErr := errcode.ErrorCodeDenied.WithDetail(map[string]interface{}{"policy": "quarantine"})
+	err = RepositoryDeniedError("test/wrapped", fmt.Errorf("get manifest: %w", detailErr))
+	if !errors.As(err, &denied) || denied.Repository != "test/wrapped" {
+		t.Errorf("expected ErrRepositoryDenied, got %#v", err)
+	}
+	if err := RepositoryDeniedError("test/wrapped", errcode.ErrorCodeDenied); errors.As(err, &denied) {
+		t.Errorf("expected the error code without detail to be returned unchanged, got %#v", err)
+	}
+	otherErr := fmt.Errorf("other")
+	if err := RepositoryDeniedError("test/other", otherErr); err != otherErr {
+		t.Errorf("expected the error to be returned unchanged, got %#v", err)
+	}
+	if err := RepositoryDeniedError("test/other", nil); err != nil {
+		t.Errorf("expected no error, got %#v", err)
+	}
+}
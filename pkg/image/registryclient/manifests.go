@@ -0,0 +1,280 @@
+package registryclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/distribution/distribution/v3/manifest/schema1"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	"github.com/distribution/distribution/v3/registry/api/errcode"
+	"github.com/distribution/distribution/v3/registry/client"
+	"github.com/opencontainers/go-digest"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ListPlatforms retrieves the manifest list identified by dgst from repo and returns the platform of each
+// manifest it references, in the order they appear in the list. An error is returned if dgst does not
+// identify a manifest list or image index.
+func ListPlatforms(ctx context.Context, repo distribution.Repository, dgst digest.Digest) ([]manifestlist.PlatformSpec, error) {
+	ms, err := repo.Manifests(ctx)
+	if err != nil {
+		return nil, repositoryDeniedError(repo, err)
+	}
+	manifest, err := ms.Get(ctx, dgst, distribution.WithManifestMediaTypes([]string{manifestlist.MediaTypeManifestList, imagespecv1.MediaTypeImageIndex}))
+	if err != nil {
+		return nil, repositoryDeniedError(repo, err)
+	}
+	list, ok := manifest.(*manifestlist.DeserializedManifestList)
+	if !ok {
+		return nil, fmt.Errorf("the manifest %s is not a manifest list", dgst)
+	}
+	platforms := make([]manifestlist.PlatformSpec, 0, len(list.Manifests))
+	for _, m := range list.Manifests {
+		platforms = append(platforms, m.Platform)
+	}
+	return platforms, nil
+}
+
+// TotalImageSize returns the compressed size of the image identified by dgst in repo, the sum of the sizes
+// of its config and layers, without downloading any blob. If dgst identifies a manifest list or image index,
+// the size of the image matching platform is returned, an empty variant in platform matches any variant.
+// Blobs whose size is not recorded in the manifest are looked up with Stat.
+func TotalImageSize(ctx context.Context, repo distribution.Repository, dgst digest.Digest, platform *manifestlist.PlatformSpec) (int64, error) {
+	ms, err := repo.Manifests(ctx)
+	if err != nil {
+		return 0, repositoryDeniedError(repo, err)
+	}
+	manifest, err := ms.Get(ctx, dgst, distribution.WithManifestMediaTypes([]string{
+		manifestlist.MediaTypeManifestList, imagespecv1.MediaTypeImageIndex,
+		schema2.MediaTypeManifest, imagespecv1.MediaTypeImageManifest,
+	}))
+	if err != nil {
+		return 0, repositoryDeniedError(repo, err)
+	}
+	if list, ok := manifest.(*manifestlist.DeserializedManifestList); ok {
+		if platform == nil {
+			return 0, fmt.Errorf("the manifest %s is a manifest list, a platform must be selected", dgst)
+		}
+		child, ok := selectPlatform(list, *platform)
+		if !ok {
+			return 0, fmt.Errorf("the manifest list %s has no manifest for platform %s", dgst, platformString(*platform))
+		}
+		if manifest, err = ms.Get(ctx, child); err != nil {
+			return 0, repositoryDeniedError(repo, err)
+		}
+		if _, ok := manifest.(*manifestlist.DeserializedManifestList); ok {
+			return 0, fmt.Errorf("the manifest %s referenced by the manifest list %s is a manifest list", child, dgst)
+		}
+	}
+
+	var size int64
+	for _, ref := range manifest.References() {
+		if ref.Size <= 0 {
+			desc, err := repo.Blobs(ctx).Stat(ctx, ref.Digest)
+			if err != nil {
+				return 0, fmt.Errorf("unable to determine the size of blob %s: %w", ref.Digest, repositoryDeniedError(repo, err))
+			}
+			ref.Size = desc.Size
+		}
+		size += ref.Size
+	}
+	return size, nil
+}
+
+// maxManifestListDepth is the maximum nesting of manifest lists FlattenManifestList follows.
+const maxManifestListDepth = 8
+
+// FlattenManifestList retrieves the manifest list identified by dgst from repo and returns the digests of the
+// leaf manifests it references, following nested manifest lists and image indexes. The digests are returned in
+// the order they appear in the lists, without duplicates. The payload of every retrieved list is verified
+// against its digest, and lists nested deeper than maxManifestListDepth are rejected to prevent cycles. An
+// error is returned if dgst does not identify a manifest list or image index.
+func FlattenManifestList(ctx context.Context, repo distribution.Repository, dgst digest.Digest) ([]digest.Digest, error) {
+	ms, err := repo.Manifests(ctx)
+	if err != nil {
+		return nil, repositoryDeniedError(repo, err)
+	}
+	var leaves []digest.Digest
+	seen := make(map[digest.Digest]struct{})
+	if err := flattenManifestList(ctx, ms, dgst, 0, seen, &leaves); err != nil {
+		return nil, repositoryDeniedError(repo, err)
+	}
+	return leaves, nil
+}
+
+func flattenManifestList(ctx context.Context, ms distribution.ManifestService, dgst digest.Digest, depth int, seen map[digest.Digest]struct{}, leaves *[]digest.Digest) error {
+	if depth > maxManifestListDepth {
+		return fmt.Errorf("the manifest list %s is nested deeper than %d manifest lists", dgst, maxManifestListDepth)
+	}
+	manifest, err := ms.Get(ctx, dgst, distribution.WithManifestMediaTypes([]string{manifestlist.MediaTypeManifestList, imagespecv1.MediaTypeImageIndex}))
+	if err != nil {
+		return err
+	}
+	list, ok := manifest.(*manifestlist.DeserializedManifestList)
+	if !ok {
+		return fmt.Errorf("the manifest %s is not a manifest list", dgst)
+	}
+	if err := dgst.Validate(); err != nil {
+		return err
+	}
+	_, payload, err := list.Payload()
+	if err != nil {
+		return err
+	}
+	if actual := dgst.Algorithm().FromBytes(payload); actual != dgst {
+		return fmt.Errorf("the content of the manifest list %s does not match its digest, got %s", dgst, actual)
+	}
+	for _, m := range list.Manifests {
+		switch m.MediaType {
+		case manifestlist.MediaTypeManifestList, imagespecv1.MediaTypeImageIndex:
+			if err := flattenManifestList(ctx, ms, m.Digest, depth+1, seen, leaves); err != nil {
+				return err
+			}
+		default:
+			if _, ok := seen[m.Digest]; ok {
+				continue
+			}
+			seen[m.Digest] = struct{}{}
+			*leaves = append(*leaves, m.Digest)
+		}
+	}
+	return nil
+}
+
+// TagExists reports whether tag exists in repo without downloading its manifest. The manifest of the tag is
+// requested with HEAD, falling back to GET only for registries that do not answer HEAD requests. A tag or
+// repository that is not found is reported as false, any other error is returned. Requests are retried and
+// authenticated like any other request of repo.
+func TagExists(ctx context.Context, repo distribution.Repository, tag string) (bool, error) {
+	_, err := repo.Tags(ctx).Get(ctx, tag)
+	if err == nil {
+		return true, nil
+	}
+	if isNotFound(err) {
+		return false, nil
+	}
+	return false, repositoryDeniedError(repo, err)
+}
+
+// repositoryDeniedError returns err as an ErrRepositoryDenied if the registry denied a request for repo by
+// policy, so that the helpers report denials the same way for repositories not created by a Context.
+func repositoryDeniedError(repo distribution.Repository, err error) error {
+	if codeErr, ok := deniedError(err); !ok || !hasDetail(codeErr.Detail) {
+		return err
+	}
+	return RepositoryDeniedError(repo.Named().Name(), err)
+}
+
+// isNotFound reports whether err is a not found response from the registry.
+func isNotFound(err error) bool {
+	var tagErr distribution.ErrTagUnknown
+	if errors.As(err, &tagErr) {
+		return true
+	}
+	var errs errcode.Errors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			if isNotFound(e) {
+				return true
+			}
+		}
+		return false
+	}
+	var codeErr errcode.Error
+	if errors.As(err, &codeErr) {
+		return codeErr.Code.Descriptor().HTTPStatusCode == http.StatusNotFound
+	}
+	var code errcode.ErrorCode
+	if errors.As(err, &code) {
+		return code.Descriptor().HTTPStatusCode == http.StatusNotFound
+	}
+	responseError := &client.UnexpectedHTTPResponseError{}
+	if errors.As(err, &responseError) {
+		return responseError.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// ManifestKind is the kind of content a manifest describes.
+type ManifestKind string
+
+const (
+	// ManifestKindImage is a manifest of a runnable container image.
+	ManifestKindImage ManifestKind = "Image"
+	// ManifestKindIndex is a manifest list or image index referencing other manifests.
+	ManifestKindIndex ManifestKind = "Index"
+	// ManifestKindArtifact is a manifest of a non-image artifact, like a signature, an SBOM
+	// or another attestation attached to an image through the OCI referrers API.
+	ManifestKindArtifact ManifestKind = "Artifact"
+)
+
+// artifactManifest holds the fields of an OCI manifest used to tell artifacts from images.
+type artifactManifest struct {
+	MediaType    string `json:"mediaType,omitempty"`
+	ArtifactType string `json:"artifactType,omitempty"`
+	Config       struct {
+		MediaType string `json:"mediaType,omitempty"`
+	} `json:"config"`
+}
+
+// ClassifyManifest returns the kind of the given manifest. A manifest is an artifact if it declares
+// an artifactType, or if its config is not a container image config, which is how artifacts were
+// stored before artifactType was introduced. Tooling can use it to skip manifests that are not
+// runnable images.
+func ClassifyManifest(manifest distribution.Manifest) (ManifestKind, error) {
+	mediaType, payload, err := manifest.Payload()
+	if err != nil {
+		return "", err
+	}
+	var m artifactManifest
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return "", fmt.Errorf("unable to parse the manifest: %w", err)
+	}
+	if len(mediaType) == 0 {
+		mediaType = m.MediaType
+	}
+
+	switch mediaType {
+	case manifestlist.MediaTypeManifestList, imagespecv1.MediaTypeImageIndex:
+		return ManifestKindIndex, nil
+	case schema1.MediaTypeManifest, schema1.MediaTypeSignedManifest:
+		return ManifestKindImage, nil
+	case schema2.MediaTypeManifest, imagespecv1.MediaTypeImageManifest:
+		if len(m.ArtifactType) > 0 {
+			return ManifestKindArtifact, nil
+		}
+		switch m.Config.MediaType {
+		case schema2.MediaTypeImageConfig, imagespecv1.MediaTypeImageConfig:
+			return ManifestKindImage, nil
+		}
+		return ManifestKindArtifact, nil
+	default:
+		return "", fmt.Errorf("unsupported manifest media type %q", mediaType)
+	}
+}
+
+func selectPlatform(list *manifestlist.DeserializedManifestList, platform manifestlist.PlatformSpec) (digest.Digest, bool) {
+	for _, m := range list.Manifests {
+		if m.Platform.OS != platform.OS || m.Platform.Architecture != platform.Architecture {
+			continue
+		}
+		if len(platform.Variant) > 0 && m.Platform.Variant != platform.Variant {
+			continue
+		}
+		return m.Digest, true
+	}
+	return "", false
+}
+
+func platformString(platform manifestlist.PlatformSpec) string {
+	s := platform.OS + "/" + platform.Architecture
+	if len(platform.Variant) > 0 {
+		s += "/" + platform.Variant
+	}
+	return s
+}
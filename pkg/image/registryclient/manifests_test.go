@@ -0,0 +1,466 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	"github.com/opencontainers/go-digest"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type fakeRepository struct {
+	distribution.Repository
+	manifests distribution.ManifestService
+}
+
+func (r *fakeRepository) Manifests(ctx context.Context, options ...distribution.ManifestServiceOption) (distribution.ManifestService, error) {
+	return r.manifests, nil
+}
+
+func TestListPlatforms(t *testing.T) {
+	platforms := []manifestlist.PlatformSpec{
+		{Architecture: "amd64", OS: "linux"},
+		{Architecture: "arm64", OS: "linux", Variant: "v8"},
+		{Architecture: "ppc64le", OS: "linux"},
+		{Architecture: "s390x", OS: "linux"},
+	}
+	var descriptors []manifestlist.ManifestDescriptor
+	for i, platform := range platforms {
+		descriptors = append(descriptors, manifestlist.ManifestDescriptor{
+			Descriptor: distribution.Descriptor{
+				MediaType: schema2.MediaTypeManifest,
+				Digest:    digest.SHA256.FromString(platform.Architecture),
+				Size:      int64(100 + i),
+			},
+			Platform: platform,
+		})
+	}
+	list, err := manifestlist.FromDescriptors(descriptors)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		manifest distribution.Manifest
+		want     []manifestlist.PlatformSpec
+		wantErr  bool
+	}{
+		{
+			name:     "manifest list",
+			manifest: list,
+			want:     platforms,
+		},
+		{
+			name:     "not a manifest list",
+			manifest: &fakeManifest{payload: []byte(payload1)},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{manifests: &fakeManifestService{manifest: tt.manifest}}
+			got, err := ListPlatforms(context.Background(), repo, payload1Digest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ListPlatforms() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListPlatforms() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+type fakeManifestsByDigest struct {
+	distribution.ManifestService
+	manifests map[digest.Digest]distribution.Manifest
+}
+
+func (s *fakeManifestsByDigest) Get(ctx context.Context, dgst digest.Digest, options ...distribution.ManifestServiceOption) (distribution.Manifest, error) {
+	m, ok := s.manifests[dgst]
+	if !ok {
+		return nil, distribution.ErrManifestUnknownRevision{Revision: dgst}
+	}
+	return m, nil
+}
+
+type fakeBlobSizes struct {
+	distribution.BlobStore
+	sizes map[digest.Digest]int64
+}
+
+func (s *fakeBlobSizes) Stat(ctx context.Context, dgst digest.Digest) (distribution.Descriptor, error) {
+	size, ok := s.sizes[dgst]
+	if !ok {
+		return distribution.Descriptor{}, distribution.ErrBlobUnknown
+	}
+	return distribution.Descriptor{Digest: dgst, Size: size}, nil
+}
+
+type fakeRepositoryWithBlobs struct {
+	fakeRepository
+	blobs distribution.BlobStore
+}
+
+func (r *fakeRepositoryWithBlobs) Blobs(ctx context.Context) distribution.BlobStore {
+	return r.blobs
+}
+
+func TestTotalImageSize(t *testing.T) {
+	newManifest := func(configSize int64, layerSizes ...int64) *schema2.DeserializedManifest {
+		m := schema2.Manifest{
+			Versioned: schema2.SchemaVersion,
+			Config:    distribution.Descriptor{MediaType: schema2.MediaTypeImageConfig, Digest: digest.SHA256.FromString("config"), Size: configSize},
+		}
+		for i, size := range layerSizes {
+			m.Layers = append(m.Layers, distribution.Descriptor{MediaType: schema2.MediaTypeLayer, Digest: digest.SHA256.FromString(fmt.Sprintf("layer-%d", i)), Size: size})
+		}
+		dm, err := schema2.FromStruct(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dm
+	}
+	amd64 := newManifest(100, 1000, 2000)
+	arm64 := newManifest(100, 3000)
+	unknownSize := newManifest(100, 0)
+	amd64Digest := digest.SHA256.FromString("amd64")
+	arm64Digest := digest.SHA256.FromString("arm64")
+	list, err := manifestlist.FromDescriptors([]manifestlist.ManifestDescriptor{
+		{Descriptor: distribution.Descriptor{MediaType: schema2.MediaTypeManifest, Digest: amd64Digest}, Platform: manifestlist.PlatformSpec{OS: "linux", Architecture: "amd64"}},
+		{Descriptor: distribution.Descriptor{MediaType: schema2.MediaTypeManifest, Digest: arm64Digest}, Platform: manifestlist.PlatformSpec{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		manifest distribution.Manifest
+		platform *manifestlist.PlatformSpec
+		want     int64
+		wantErr  bool
+	}{
+		{
+			name:     "image manifest",
+			manifest: amd64,
+			want:     3100,
+		},
+		{
+			name:     "layer size looked up with stat",
+			manifest: unknownSize,
+			want:     600,
+		},
+		{
+			name:     "manifest list with platform",
+			manifest: list,
+			platform: &manifestlist.PlatformSpec{OS: "linux", Architecture: "arm64"},
+			want:     3100,
+		},
+		{
+			name:     "manifest list with platform and variant",
+			manifest: list,
+			platform: &manifestlist.PlatformSpec{OS: "linux", Architecture: "arm64", Variant: "v8"},
+			want:     3100,
+		},
+		{
+			name:     "manifest list without platform",
+			manifest: list,
+			wantErr:  true,
+		},
+		{
+			name:     "manifest list without matching platform",
+			manifest: list,
+			platform: &manifestlist.PlatformSpec{OS: "linux", Architecture: "s390x"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepositoryWithBlobs{
+				fakeRepository: fakeRepository{manifests: &fakeManifestsByDigest{manifests: map[digest.Digest]distribution.Manifest{
+					payload1Digest: tt.manifest,
+					amd64Digest:    amd64,
+					arm64Digest:    arm64,
+				}}},
+				blobs: &fakeBlobSizes{sizes: map[digest.Digest]int64{digest.SHA256.FromString("layer-0"): 500}},
+			}
+			got, err := TotalImageSize(context.Background(), repo, payload1Digest, tt.platform)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TotalImageSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("TotalImageSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlattenManifestList(t *testing.T) {
+	manifests := map[digest.Digest]distribution.Manifest{}
+	newList := func(mediaType string, children ...distribution.Descriptor) digest.Digest {
+		var descriptors []manifestlist.ManifestDescriptor
+		for _, child := range children {
+			descriptors = append(descriptors, manifestlist.ManifestDescriptor{Descriptor: child, Platform: manifestlist.PlatformSpec{OS: "linux", Architecture: "amd64"}})
+		}
+		list, err := manifestlist.FromDescriptorsWithMediaType(descriptors, mediaType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, payload, err := list.Payload()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dgst := digest.FromBytes(payload)
+		manifests[dgst] = list
+		return dgst
+	}
+	leaf := func(name string) distribution.Descriptor {
+		return distribution.Descriptor{MediaType: imagespecv1.MediaTypeImageManifest, Digest: digest.SHA256.FromString(name), Size: 100}
+	}
+	index := func(dgst digest.Digest) distribution.Descriptor {
+		return distribution.Descriptor{MediaType: imagespecv1.MediaTypeImageIndex, Digest: dgst, Size: 100}
+	}
+
+	singleLevel := newList(manifestlist.MediaTypeManifestList, leaf("amd64"), leaf("arm64"))
+	nestedIndex := newList(imagespecv1.MediaTypeImageIndex, leaf("arm64"), leaf("ppc64le"))
+	nested := newList(imagespecv1.MediaTypeImageIndex, leaf("amd64"), index(nestedIndex), leaf("s390x"))
+	deep := newList(imagespecv1.MediaTypeImageIndex, leaf("amd64"))
+	for i := 0; i < maxManifestListDepth+1; i++ {
+		deep = newList(imagespecv1.MediaTypeImageIndex, index(deep))
+	}
+	tampered := digest.SHA256.FromString("tampered")
+	manifests[tampered] = manifests[singleLevel]
+	image := digest.SHA256.FromString("image")
+	manifests[image] = &fakeManifest{payload: []byte(payload1)}
+
+	tests := []struct {
+		name    string
+		dgst    digest.Digest
+		want    []digest.Digest
+		wantErr bool
+	}{
+		{
+			name: "single level manifest list",
+			dgst: singleLevel,
+			want: []digest.Digest{leaf("amd64").Digest, leaf("arm64").Digest},
+		},
+		{
+			name: "nested index",
+			dgst: nested,
+			want: []digest.Digest{leaf("amd64").Digest, leaf("arm64").Digest, leaf("ppc64le").Digest, leaf("s390x").Digest},
+		},
+		{
+			name:    "nesting deeper than the limit",
+			dgst:    deep,
+			wantErr: true,
+		},
+		{
+			name:    "content does not match the digest",
+			dgst:    tampered,
+			wantErr: true,
+		},
+		{
+			name:    "not a manifest list",
+			dgst:    image,
+			wantErr: true,
+		},
+		{
+			name:    "missing manifest",
+			dgst:    digest.SHA256.FromString("missing"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{manifests: &fakeManifestsByDigest{manifests: manifests}}
+			got, err := FlattenManifestList(context.Background(), repo, tt.dgst)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FlattenManifestList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FlattenManifestList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+const (
+	imageManifestFixture = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.manifest.v1+json",
+  "config": {
+    "mediaType": "application/vnd.oci.image.config.v1+json",
+    "digest": "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a",
+    "size": 2
+  },
+  "layers": [
+    {
+      "mediaType": "application/vnd.oci.image.layer.v1.tar+gzip",
+      "digest": "sha256:ca3d163bab055381827226140568f3bef7eaac187cebd76878e0b63e9e442356",
+      "size": 1024
+    }
+  ]
+}`
+	artifactManifestFixture = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.manifest.v1+json",
+  "artifactType": "application/vnd.dev.cosign.artifact.sig.v1+json",
+  "config": {
+    "mediaType": "application/vnd.oci.empty.v1+json",
+    "digest": "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a",
+    "size": 2
+  },
+  "layers": [
+    {
+      "mediaType": "application/vnd.dev.cosign.simplesigning.v1+json",
+      "digest": "sha256:ca3d163bab055381827226140568f3bef7eaac187cebd76878e0b63e9e442356",
+      "size": 241
+    }
+  ],
+  "subject": {
+    "mediaType": "application/vnd.oci.image.manifest.v1+json",
+    "digest": "sha256:b5b2b2c507a0944348e0303114d8d93aaaa081732b86451d9bce1f432a537bc7",
+    "size": 512
+  }
+}`
+	legacyAttestationManifestFixture = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.manifest.v1+json",
+  "config": {
+    "mediaType": "application/vnd.in-toto+json",
+    "digest": "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a",
+    "size": 2
+  },
+  "layers": []
+}`
+)
+
+func TestClassifyManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest distribution.Manifest
+		want     ManifestKind
+		wantErr  bool
+	}{
+		{
+			name:     "oci image",
+			manifest: &fakeManifest{mediaType: imagespecv1.MediaTypeImageManifest, payload: []byte(imageManifestFixture)},
+			want:     ManifestKindImage,
+		},
+		{
+			name:     "media type from the payload",
+			manifest: &fakeManifest{payload: []byte(imageManifestFixture)},
+			want:     ManifestKindImage,
+		},
+		{
+			name:     "artifact with artifactType",
+			manifest: &fakeManifest{mediaType: imagespecv1.MediaTypeImageManifest, payload: []byte(artifactManifestFixture)},
+			want:     ManifestKindArtifact,
+		},
+		{
+			name:     "artifact with a non-image config",
+			manifest: &fakeManifest{mediaType: imagespecv1.MediaTypeImageManifest, payload: []byte(legacyAttestationManifestFixture)},
+			want:     ManifestKindArtifact,
+		},
+		{
+			name:     "docker image",
+			manifest: &fakeManifest{mediaType: schema2.MediaTypeManifest, payload: []byte(`{"config":{"mediaType":"application/vnd.docker.container.image.v1+json"}}`)},
+			want:     ManifestKindImage,
+		},
+		{
+			name:     "image index",
+			manifest: &fakeManifest{mediaType: imagespecv1.MediaTypeImageIndex, payload: []byte(`{"manifests":[]}`)},
+			want:     ManifestKindIndex,
+		},
+		{
+			name:     "unknown media type",
+			manifest: &fakeManifest{mediaType: "application/unknown", payload: []byte(payload1)},
+			wantErr:  true,
+		},
+		{
+			name:     "invalid payload",
+			manifest: &fakeManifest{mediaType: imagespecv1.MediaTypeImageManifest, payload: []byte("{")},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClassifyManifest(tt.manifest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ClassifyManifest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ClassifyManifest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagExists(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		switch r.URL.Path {
+		case "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case "/v2/test/image/manifests/latest":
+			methods = append(methods, r.Method)
+			w.Header().Set("Docker-Content-Digest", payload1Digest.String())
+			w.Header().Set("Content-Type", schema2.MediaTypeManifest)
+			w.Header().Set("Content-Length", "100")
+			w.WriteHeader(http.StatusOK)
+		case "/v2/test/image/manifests/denied":
+			methods = append(methods, r.Method)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":[{"code":"DENIED","message":"requested access to the resource is denied"}]}`))
+		default:
+			methods = append(methods, r.Method)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"code":"MANIFEST_UNKNOWN","message":"manifest unknown"}]}`))
+		}
+	}))
+	defer server.Close()
+	uri, _ := url.Parse(server.URL)
+
+	repo, err := NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials).Repository(context.Background(), uri, "test/image", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tag         string
+		want        bool
+		wantErr     bool
+		wantMethods []string
+	}{
+		{tag: "latest", want: true, wantMethods: []string{http.MethodHead}},
+		{tag: "missing", want: false, wantMethods: []string{http.MethodHead, http.MethodGet}},
+		{tag: "denied", wantErr: true, wantMethods: []string{http.MethodHead, http.MethodGet}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			methods = nil
+			got, err := TagExists(context.Background(), repo, tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TagExists() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("TagExists() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(methods, tt.wantMethods) {
+				t.Errorf("expected requests %v, got %v", tt.wantMethods, methods)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package registryclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/distribution/distribution/v3/manifest/schema1"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	"github.com/opencontainers/go-digest"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// DefaultManifestMediaTypes are the manifest media types accepted when a manifest is retrieved without
+// distribution.WithManifestMediaTypes. Manifest lists and image indexes are preferred over image manifests.
+var DefaultManifestMediaTypes = []string{
+	manifestlist.MediaTypeManifestList,
+	imagespecv1.MediaTypeImageIndex,
+	schema2.MediaTypeManifest,
+	imagespecv1.MediaTypeImageManifest,
+	schema1.MediaTypeSignedManifest,
+}
+
+func init() {
+	// the distribution client does not know OCI image manifests, an OCI manifest schema registered by
+	// another package takes precedence
+	_ = distribution.RegisterManifestSchema(imagespecv1.MediaTypeImageManifest, func(b []byte) (distribution.Manifest, distribution.Descriptor, error) {
+		m := new(DeserializedOCIManifest)
+		if err := m.UnmarshalJSON(b); err != nil {
+			return nil, distribution.Descriptor{}, err
+		}
+		return m, distribution.Descriptor{Digest: digest.FromBytes(b), Size: int64(len(b)), MediaType: imagespecv1.MediaTypeImageManifest}, nil
+	})
+}
+
+// DeserializedOCIManifest is an OCI image manifest retrieved from a registry. OCI image manifests share the
+// structure of Docker schema2 manifests, the manifest keeps its canonical representation to preserve its digest.
+type DeserializedOCIManifest struct {
+	schema2.Manifest
+
+	canonical []byte
+}
+
+// UnmarshalJSON populates a new manifest from the canonical JSON of an OCI image manifest.
+func (m *DeserializedOCIManifest) UnmarshalJSON(b []byte) error {
+	var mfst schema2.Manifest
+	if err := json.Unmarshal(b, &mfst); err != nil {
+		return err
+	}
+	if len(mfst.MediaType) > 0 && mfst.MediaType != imagespecv1.MediaTypeImageManifest {
+		return fmt.Errorf("mediaType in manifest should be '%s' not '%s'", imagespecv1.MediaTypeImageManifest, mfst.MediaType)
+	}
+	m.Manifest = mfst
+	m.canonical = append([]byte(nil), b...)
+	return nil
+}
+
+// MarshalJSON returns the canonical representation of the manifest.
+func (m *DeserializedOCIManifest) MarshalJSON() ([]byte, error) {
+	if len(m.canonical) == 0 {
+		return nil, errors.New("JSON representation not initialized in DeserializedOCIManifest")
+	}
+	return m.canonical, nil
+}
+
+// Payload returns the media type and the canonical representation of the manifest.
+func (m *DeserializedOCIManifest) Payload() (string, []byte, error) {
+	return imagespecv1.MediaTypeImageManifest, m.canonical, nil
+}
+
+// hasManifestMediaTypes returns true if options select the accepted manifest media types.
+func hasManifestMediaTypes(options []distribution.ManifestServiceOption) bool {
+	for _, option := range options {
+		if _, ok := option.(distribution.WithManifestMediaTypesOption); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package registryclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+
+	"github.com/distribution/distribution/v3/registry/api/errcode"
+	"github.com/distribution/distribution/v3/registry/client"
+	"github.com/distribution/distribution/v3/registry/client/auth"
+
+	imagereference "github.com/openshift/library-go/pkg/image/reference"
+)
+
+// ErrAuthenticationFailed is returned by PreflightAuth when the registry can be reached but does not accept
+// the credentials, either because the token server refuses to issue a token or the registry rejects them.
+type ErrAuthenticationFailed struct {
+	Registry string
+	Err      error
+}
+
+func (e *ErrAuthenticationFailed) Error() string {
+	return fmt.Sprintf("authentication to registry %q failed: %v", e.Registry, e.Err)
+}
+
+func (e *ErrAuthenticationFailed) Unwrap() error {
+	return e.Err
+}
+
+// PreflightAuth contacts the registry of ref and authenticates for the scopes a Repository for ref would
+// request, without fetching any content. It allows callers to fail fast on bad credentials instead of at the
+// first pull. Errors reaching the registry or its token server, including cancellation of ctx, are returned
+// unchanged. Only credentials refused by the token server or the registry are returned as an
+// ErrAuthenticationFailed. The obtained tokens are reused by later requests of this context.
+func (c *Context) PreflightAuth(ctx context.Context, ref imagereference.DockerImageReference, insecure bool) error {
+	rt, src, err := c.Ping(ctx, ref.RegistryURL(), insecure)
+	if err != nil {
+		return err
+	}
+	rt = c.repositoryTransport(rt, src, ref.RepositoryName(), ref)
+
+	target := *src
+	target.Path = path.Join(target.Path, "v2") + "/"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return err
+	}
+	// the authorizer obtains a token for the request, a failure to do so is returned as an error
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		if isAuthenticationError(err) {
+			return &ErrAuthenticationFailed{Registry: src.Host, Err: err}
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &ErrAuthenticationFailed{Registry: src.Host, Err: fmt.Errorf("the registry rejected the credentials (%s)", resp.Status)}
+	}
+	return nil
+}
+
+// isAuthenticationError returns true if err reports that the token server or the registry refused the
+// credentials, as opposed to a failure to reach them.
+func isAuthenticationError(err error) bool {
+	if errors.Is(err, auth.ErrNoBasicAuthCredentials) || errors.Is(err, auth.ErrNoToken) {
+		return true
+	}
+	scopeError := &ErrInsufficientScope{}
+	if errors.As(err, &scopeError) {
+		return true
+	}
+	var errs errcode.Errors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			if isAuthenticationError(e) {
+				return true
+			}
+		}
+		return false
+	}
+	var codeErr errcode.Error
+	if errors.As(err, &codeErr) {
+		return isAuthenticationStatus(codeErr.Code.Descriptor().HTTPStatusCode)
+	}
+	var code errcode.ErrorCode
+	if errors.As(err, &code) {
+		return isAuthenticationStatus(code.Descriptor().HTTPStatusCode)
+	}
+	responseError := &client.UnexpectedHTTPResponseError{}
+	if errors.As(err, &responseError) {
+		return isAuthenticationStatus(responseError.StatusCode)
+	}
+	return false
+}
+
+func isAuthenticationStatus(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// MirrorStatus is the result of checking a single location of a reference with CheckMirrors.
+type MirrorStatus struct {
+	// Mirror is the location that was checked.
+	Mirror imagereference.DockerImageReference
+	// Reachable is true if the registry of the location answered as a registry.
+	Reachable bool
+	// Authorized is true if the registry accepted the credentials for the repository of the location.
+	Authorized bool
+	// Err is the error reaching or authenticating to the registry, if any.
+	Err error
+}
+
+// CheckMirrors reports whether each location the AlternateBlobSourceStrategy of the context returns for ref can
+// be reached and authorized, without pulling any content. It allows callers to detect misconfigured mirrors
+// before pulling from them. The locations are those returned by FirstRequest, or by OnFailure if FirstRequest
+// returns none, and only ref itself is checked if the context has no strategy. The locations are checked
+// concurrently and the statuses are returned in the order the strategy returned them. Errors of the strategy
+// are returned, errors of a location are reported in its status. The registries are pinged and authenticated
+// like PreflightAuth does, so later requests of this context reuse the results.
+func (c *Context) CheckMirrors(ctx context.Context, ref imagereference.DockerImageReference, insecure bool) ([]MirrorStatus, error) {
+	mirrors := []imagereference.DockerImageReference{ref}
+	if c.Alternates != nil {
+		alternates, err := c.Alternates.FirstRequest(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if alternates == nil {
+			if alternates, err = c.Alternates.OnFailure(ctx, ref); err != nil {
+				return nil, err
+			}
+		}
+		if alternates != nil {
+			mirrors = alternates
+		}
+	}
+
+	statuses := make([]MirrorStatus, len(mirrors))
+	var wg sync.WaitGroup
+	for i, mirror := range mirrors {
+		wg.Add(1)
+		go func(i int, mirror imagereference.DockerImageReference) {
+			defer wg.Done()
+			err := c.PreflightAuth(ctx, mirror, insecure)
+			var authErr *ErrAuthenticationFailed
+			statuses[i] = MirrorStatus{
+				Mirror:     mirror,
+				Reachable:  err == nil || errors.As(err, &authErr),
+				Authorized: err == nil,
+				Err:        err,
+			}
+		}(i, mirror)
+	}
+	wg.Wait()
+	return statuses, nil
+}
@@ -0,0 +1,243 @@
+package registryclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	imagereference "github.com/openshift/library-go/pkg/image/reference"
+)
+
+func TestPreflightAuth(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "secret" {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED","message":"invalid username or password"}]}`))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"token":"valid-token","expires_in":300}`))
+			return
+		}
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		if r.Header.Get("Authorization") != "Bearer valid-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry.test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/v2/" {
+			t.Errorf("unexpected request for content %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	uri, _ := url.Parse(server.URL)
+	ref, err := imagereference.Parse(uri.Host + "/test/image:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credentials := func(password string) *BasicCredentials {
+		creds := NewBasicCredentials()
+		realm, _ := url.Parse(server.URL + "/token")
+		creds.Add(realm, "user", password)
+		return creds
+	}
+
+	// bad credentials are reported before any content is requested
+	err = NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(credentials("wrong")).PreflightAuth(context.Background(), ref, true)
+	var authErr *ErrAuthenticationFailed
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected an authentication error, got %v", err)
+	}
+	if authErr.Registry != uri.Host || !strings.Contains(err.Error(), "authentication to registry") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// valid credentials pass the preflight
+	if err := NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(credentials("secret")).PreflightAuth(context.Background(), ref, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a cancelled context is not reported as an authentication failure
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(credentials("secret")).PreflightAuth(cancelled, ref, true)
+	if err == nil || errors.As(err, &authErr) {
+		t.Fatalf("expected a cancellation error, got %v", err)
+	}
+
+	// errors reaching the registry after a cached ping are not reported as authentication failures
+	cached := NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(credentials("secret"))
+	if _, _, err := cached.Ping(context.Background(), ref.RegistryURL(), true); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+	err = cached.PreflightAuth(context.Background(), ref, true)
+	if err == nil || errors.As(err, &authErr) {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+
+	// errors reaching the registry are not reported as authentication failures
+	err = NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(credentials("secret")).PreflightAuth(context.Background(), ref, true)
+	if err == nil || errors.As(err, &authErr) {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+}
+
+func TestPreflightAuthUnreachableTokenServer(t *testing.T) {
+	tokenServer := httptest.NewServer(http.NotFoundHandler())
+	tokenServer.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+tokenServer.URL+`/token",service="registry.test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	uri, _ := url.Parse(server.URL)
+	ref, err := imagereference.Parse(uri.Host + "/test/image:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials).PreflightAuth(context.Background(), ref, true)
+	var authErr *ErrAuthenticationFailed
+	if err == nil || errors.As(err, &authErr) {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+}
+
+func TestCheckMirrors(t *testing.T) {
+	reachable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		if r.URL.Path != "/v2/" {
+			t.Errorf("unexpected request for content %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer reachable.Close()
+	unauthorized := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		w.Header().Set("WWW-Authenticate", `Basic realm="registry.test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer unauthorized.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	parse := func(server *httptest.Server) imagereference.DockerImageReference {
+		uri, _ := url.Parse(server.URL)
+		ref, err := imagereference.Parse(uri.Host + "/test/image:latest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ref
+	}
+	source, mirror, unauthorizedMirror, unreachableMirror := parse(reachable), parse(reachable), parse(unauthorized), parse(unreachable)
+	mirror.Namespace = "mirror"
+
+	tests := []struct {
+		name       string
+		strategy   AlternateBlobSourceStrategy
+		expected   []MirrorStatus
+		expectErrs []bool
+		expectErr  bool
+	}{
+		{
+			name:     "no strategy",
+			expected: []MirrorStatus{{Mirror: source, Reachable: true, Authorized: true}},
+		},
+		{
+			name: "mirrors of the first request",
+			strategy: &fakeAlternateBlobStrategy{
+				FirstAlternates: []imagereference.DockerImageReference{mirror, unauthorizedMirror, unreachableMirror, source},
+			},
+			expected: []MirrorStatus{
+				{Mirror: mirror, Reachable: true, Authorized: true},
+				{Mirror: unauthorizedMirror, Reachable: true},
+				{Mirror: unreachableMirror},
+				{Mirror: source, Reachable: true, Authorized: true},
+			},
+			expectErrs: []bool{false, true, true, false},
+		},
+		{
+			name: "mirrors on failure",
+			strategy: &fakeAlternateBlobStrategy{
+				FailureAlternates: []imagereference.DockerImageReference{unreachableMirror},
+			},
+			expected:   []MirrorStatus{{Mirror: unreachableMirror}},
+			expectErrs: []bool{true},
+		},
+		{
+			name:      "strategy error",
+			strategy:  &fakeAlternateBlobStrategy{FirstErr: errors.New("no mirrors")},
+			expectErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials)
+			if tc.strategy != nil {
+				c = c.WithAlternateBlobSourceStrategy(tc.strategy)
+			}
+			statuses, err := c.CheckMirrors(context.Background(), source, true)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(statuses) != len(tc.expected) {
+				t.Fatalf("expected %d statuses, got %#v", len(tc.expected), statuses)
+			}
+			for i, status := range statuses {
+				expectErr := len(tc.expectErrs) > i && tc.expectErrs[i]
+				if (status.Err != nil) != expectErr {
+					t.Errorf("%s: unexpected error: %v", status.Mirror, status.Err)
+				}
+				status.Err = nil
+				if status != tc.expected[i] {
+					t.Errorf("expected status %#v, got %#v", tc.expected[i], status)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckMirrorsConnectionFailureAfterPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		w.WriteHeader(http.StatusOK)
+	}))
+	uri, _ := url.Parse(server.URL)
+	mirror, err := imagereference.Parse(uri.Host + "/mirror/image:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := mirror
+	source.Registry = "source.test"
+
+	c := NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials).
+		WithAlternateBlobSourceStrategy(&fakeAlternateBlobStrategy{FirstAlternates: []imagereference.DockerImageReference{mirror}})
+	if _, _, err := c.Ping(context.Background(), mirror.RegistryURL(), true); err != nil {
+		t.Fatal(err)
+	}
+	// the mirror goes down after the ping was cached
+	server.Close()
+
+	statuses, err := c.CheckMirrors(context.Background(), source, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 {
+		t.Fatalf("expected 1 status, got %#v", statuses)
+	}
+	if statuses[0].Reachable || statuses[0].Authorized || statuses[0].Err == nil {
+		t.Errorf("expected the mirror to be reported unreachable, got %#v", statuses[0])
+	}
+}
@@ -0,0 +1,83 @@
+package registryclient
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+)
+
+// WithRecorder wraps the transports of the context so that every HTTP exchange with a registry is
+// written to dir. The recorded exchanges may later be served with WithReplay, which allows tests to
+// be written against real registries and then run deterministically without network access.
+func (c *Context) WithRecorder(dir string) *Context {
+	c.Transport = &recordingTransport{dir: dir, rt: c.Transport}
+	if c.InsecureTransport != nil {
+		c.InsecureTransport = &recordingTransport{dir: dir, rt: c.InsecureTransport}
+	}
+	return c
+}
+
+// WithReplay replaces the transports of the context with one that serves responses previously
+// recorded to dir by WithRecorder. No request will reach the network, and a request without a
+// recorded response returns an error.
+func (c *Context) WithReplay(dir string) *Context {
+	c.Transport = &replayTransport{dir: dir}
+	c.InsecureTransport = c.Transport
+	return c
+}
+
+// recordingFileName returns the name of the file used to store the response to req. Requests are
+// identified by method and URL, so repeated requests replay the most recently recorded response.
+func recordingFileName(dir string, req *http.Request) string {
+	return filepath.Join(dir, fmt.Sprintf("%x.http", sha256.Sum256([]byte(req.Method+" "+req.URL.String()))))
+}
+
+// recordingTransport stores every response returned by rt in dir.
+type recordingTransport struct {
+	dir string
+	rt  http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// DumpResponse consumes the body and replaces it with an equivalent in memory reader
+	data, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unable to record response for %s %s: %v", req.Method, req.URL, err)
+	}
+	if err := os.MkdirAll(t.dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(recordingFileName(t.dir, req), data, 0644); err != nil {
+		return nil, fmt.Errorf("unable to record response for %s %s: %v", req.Method, req.URL, err)
+	}
+	return resp, nil
+}
+
+// replayTransport serves responses stored by a recordingTransport from dir.
+type replayTransport struct {
+	dir string
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	data, err := os.ReadFile(recordingFileName(t.dir, req))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL)
+		}
+		return nil, err
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+}
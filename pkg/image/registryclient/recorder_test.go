@@ -0,0 +1,55 @@
+package registryclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestRecorderReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/v2/" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.Error(w, "unexpected request", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		w.WriteHeader(http.StatusOK)
+	}))
+	uri, _ := url.Parse(server.URL)
+
+	recorder := NewContext(http.DefaultTransport, http.DefaultTransport).WithRecorder(dir)
+	if _, _, err := recorder.Ping(context.Background(), uri, false); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected 1 request, got %d", requests)
+	}
+
+	// replay must not reach the network
+	server.Close()
+
+	replay := NewContext(http.DefaultTransport, http.DefaultTransport).WithReplay(dir)
+	_, src, err := replay.Ping(context.Background(), uri, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Host != uri.Host {
+		t.Errorf("unexpected registry URL: %s", src)
+	}
+	if requests != 1 {
+		t.Fatalf("expected no additional requests, got %d", requests)
+	}
+
+	// requests that were never recorded fail
+	other := &url.URL{Scheme: "http", Host: "other.registry.test"}
+	if _, _, err := replay.Ping(context.Background(), other, false); err == nil {
+		t.Fatal("expected an error for a request without a recorded response")
+	}
+}
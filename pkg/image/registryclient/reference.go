@@ -0,0 +1,49 @@
+package registryclient
+
+import (
+	"fmt"
+	"strings"
+
+	imagereference "github.com/openshift/library-go/pkg/image/reference"
+)
+
+// ReferenceKind describes how an image reference identifies an image.
+type ReferenceKind string
+
+const (
+	// ReferenceKindRepository is a reference without tag and digest, it refers to the default tag of the repository.
+	ReferenceKindRepository ReferenceKind = "Repository"
+	// ReferenceKindTagged is a reference with a tag only.
+	ReferenceKindTagged ReferenceKind = "Tagged"
+	// ReferenceKindDigested is a reference with a digest only.
+	ReferenceKindDigested ReferenceKind = "Digested"
+	// ReferenceKindTaggedAndDigested is a reference with both a tag and a digest. The digest identifies the image,
+	// the tag is informational.
+	ReferenceKindTaggedAndDigested ReferenceKind = "TaggedAndDigested"
+)
+
+// ClassifyReference parses the image reference provided by a user and returns it along with its kind. The
+// returned error describes why the reference is not valid and can be shown to the user as is.
+func ClassifyReference(spec string) (imagereference.DockerImageReference, ReferenceKind, error) {
+	if len(strings.TrimSpace(spec)) == 0 {
+		return imagereference.DockerImageReference{}, "", fmt.Errorf("an image reference is required")
+	}
+	ref, err := imagereference.Parse(spec)
+	if err != nil {
+		return imagereference.DockerImageReference{}, "", fmt.Errorf("%q is not a valid image reference: %w", spec, err)
+	}
+	if len(ref.Name) == 0 {
+		return imagereference.DockerImageReference{}, "", fmt.Errorf("%q is not a valid image reference: a repository name is required after the registry", spec)
+	}
+
+	switch {
+	case len(ref.Tag) > 0 && len(ref.ID) > 0:
+		return ref, ReferenceKindTaggedAndDigested, nil
+	case len(ref.ID) > 0:
+		return ref, ReferenceKindDigested, nil
+	case len(ref.Tag) > 0:
+		return ref, ReferenceKindTagged, nil
+	default:
+		return ref, ReferenceKindRepository, nil
+	}
+}
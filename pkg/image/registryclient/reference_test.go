@@ -0,0 +1,83 @@
+package registryclient
+
+import (
+	"testing"
+
+	imagereference "github.com/openshift/library-go/pkg/image/reference"
+)
+
+func TestClassifyReference(t *testing.T) {
+	const digest = "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	tests := []struct {
+		name    string
+		spec    string
+		want    imagereference.DockerImageReference
+		kind    ReferenceKind
+		wantErr string
+	}{
+		{
+			name: "repository only",
+			spec: "quay.io/openshift/origin-cli",
+			want: imagereference.DockerImageReference{Registry: "quay.io", Namespace: "openshift", Name: "origin-cli"},
+			kind: ReferenceKindRepository,
+		},
+		{
+			name: "tag only",
+			spec: "quay.io/openshift/origin-cli:4.20",
+			want: imagereference.DockerImageReference{Registry: "quay.io", Namespace: "openshift", Name: "origin-cli", Tag: "4.20"},
+			kind: ReferenceKindTagged,
+		},
+		{
+			name: "digest only",
+			spec: "quay.io/openshift/origin-cli@" + digest,
+			want: imagereference.DockerImageReference{Registry: "quay.io", Namespace: "openshift", Name: "origin-cli", ID: digest},
+			kind: ReferenceKindDigested,
+		},
+		{
+			name: "tag and digest",
+			spec: "quay.io/openshift/origin-cli:4.20@" + digest,
+			want: imagereference.DockerImageReference{Registry: "quay.io", Namespace: "openshift", Name: "origin-cli", Tag: "4.20", ID: digest},
+			kind: ReferenceKindTaggedAndDigested,
+		},
+		{
+			name:    "empty",
+			spec:    " ",
+			wantErr: "an image reference is required",
+		},
+		{
+			name:    "uppercase repository",
+			spec:    "quay.io/OpenShift/origin-cli:4.20",
+			wantErr: `"quay.io/OpenShift/origin-cli:4.20" is not a valid image reference: repository name must be lowercase`,
+		},
+		{
+			name:    "invalid digest",
+			spec:    "quay.io/openshift/origin-cli@sha256:abc",
+			wantErr: `"quay.io/openshift/origin-cli@sha256:abc" is not a valid image reference: invalid reference format`,
+		},
+		{
+			name:    "registry only",
+			spec:    "quay.io",
+			wantErr: `"quay.io" is not a valid image reference: a repository name is required after the registry`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, kind, err := ClassifyReference(tt.spec)
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ClassifyReference() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ClassifyReference() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ClassifyReference() = %#v, want %#v", got, tt.want)
+			}
+			if kind != tt.kind {
+				t.Errorf("ClassifyReference() kind = %s, want %s", kind, tt.kind)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package registryclient
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Stats is a snapshot of the requests made by the repositories of a Context and of the data they
+// transferred. It is only collected if enabled with WithStats.
+type Stats struct {
+	// ManifestGets is the number of manifests retrieved, not counting retries.
+	ManifestGets int64
+	// BlobOpens is the number of blobs opened, not counting retries.
+	BlobOpens int64
+	// BytesRead is the number of bytes read from opened blobs.
+	BytesRead int64
+	// Retries is the number of requests retried after a temporary failure.
+	Retries int64
+}
+
+// pullStats holds the counters of a Context. They are shared by all repositories of the context,
+// which may be used concurrently.
+type pullStats struct {
+	manifestGets atomic.Int64
+	blobOpens    atomic.Int64
+	bytesRead    atomic.Int64
+	retries      atomic.Int64
+}
+
+// WithStats makes the repositories created from this context count their requests and the bytes
+// read from blobs, which are returned by Stats, for instance to report the statistics of a pull
+// once it completed. Stats are not collected by default.
+func (c *Context) WithStats() *Context {
+	c.CollectStats = true
+	return c
+}
+
+// Stats returns the statistics collected by the repositories created from this context since stats
+// collection was enabled with WithStats. It returns zero statistics if collection is disabled. Copies
+// of the context collect their own statistics.
+func (c *Context) Stats() Stats {
+	c.lock.Lock()
+	s := c.stats
+	c.lock.Unlock()
+	if s == nil {
+		return Stats{}
+	}
+	return Stats{
+		ManifestGets: s.manifestGets.Load(),
+		BlobOpens:    s.blobOpens.Load(),
+		BytesRead:    s.bytesRead.Load(),
+		Retries:      s.retries.Load(),
+	}
+}
+
+// pullStats returns the counters of the context, or nil if stats collection is disabled.
+func (c *Context) pullStats() *pullStats {
+	if !c.CollectStats {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.stats == nil {
+		c.stats = &pullStats{}
+	}
+	return c.stats
+}
+
+// countingReadSeekCloser adds the bytes read from a blob to the stats of its repository.
+type countingReadSeekCloser struct {
+	io.ReadSeekCloser
+	stats *pullStats
+}
+
+func (r *countingReadSeekCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadSeekCloser.Read(p)
+	r.stats.bytesRead.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,107 @@
+package registryclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	"github.com/opencontainers/go-digest"
+)
+
+func TestStats(t *testing.T) {
+	layer := "layer data"
+	layerDigest := digest.FromString(layer)
+	manifest := `{"schemaVersion":2,"mediaType":"` + schema2.MediaTypeManifest + `","config":{"mediaType":"` + schema2.MediaTypeImageConfig + `","size":2,"digest":"` + digest.FromString("{}").String() + `"},"layers":[{"mediaType":"` + schema2.MediaTypeLayer + `","size":10,"digest":"` + layerDigest.String() + `"}]}`
+	manifestDigest := digest.FromString(manifest)
+
+	var lock sync.Mutex
+	throttled := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		switch r.URL.Path {
+		case "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case "/v2/test/image/manifests/" + manifestDigest.String():
+			// the first request is throttled and retried
+			lock.Lock()
+			throttle := !throttled
+			throttled = true
+			lock.Unlock()
+			if throttle {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Header().Set("Content-Type", schema2.MediaTypeManifest)
+			w.Header().Set("Docker-Content-Digest", manifestDigest.String())
+			w.Write([]byte(manifest))
+		case "/v2/test/image/blobs/" + layerDigest.String():
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", layerDigest.String())
+			w.Write([]byte(layer))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	uri, _ := url.Parse(server.URL)
+
+	pull := func(c *Context) {
+		ctx := context.Background()
+		repo, err := c.Repository(ctx, uri, "test/image", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ms, err := repo.Manifests(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ms.Get(ctx, manifestDigest); err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				rc, err := repo.Blobs(ctx).Open(ctx, layerDigest)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				defer rc.Close()
+				if _, err := io.Copy(io.Discard, rc); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	// stats are not collected by default
+	c := NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials).WithMaxRetryAfter(time.Millisecond)
+	pull(c)
+	if stats := c.Stats(); stats != (Stats{}) {
+		t.Errorf("expected no stats, got %#v", stats)
+	}
+
+	throttled = false
+	c = NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials).WithMaxRetryAfter(time.Millisecond).WithStats()
+	pull(c)
+	expected := Stats{ManifestGets: 1, BlobOpens: 3, BytesRead: 3 * int64(len(layer)), Retries: 1}
+	if stats := c.Stats(); stats != expected {
+		t.Errorf("expected stats %#v, got %#v", expected, stats)
+	}
+
+	// copies collect their own stats
+	if stats := c.Copy().Stats(); stats != (Stats{}) {
+		t.Errorf("expected no stats for a copy, got %#v", stats)
+	}
+}
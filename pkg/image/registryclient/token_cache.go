@@ -0,0 +1,233 @@
+package registryclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/distribution/distribution/v3/registry/client/auth"
+)
+
+// defaultTokenCacheTTL matches the minimum lifetime registry token servers grant to bearer tokens.
+const defaultTokenCacheTTL = 60 * time.Second
+
+// TokenCache stores bearer tokens issued by registry token servers so that they can be shared by
+// multiple Contexts, avoiding an authentication round-trip for every Context talking to the same
+// registry. Tokens are keyed by registry host, requested scopes and user name, and are kept until the
+// expiry reported by the token server, but no longer than the TTL of the cache. A token rejected by the
+// registry is dropped from the cache.
+type TokenCache struct {
+	ttl time.Duration
+
+	lock   sync.Mutex
+	tokens map[tokenCacheKey]cachedToken
+}
+
+type tokenCacheKey struct {
+	host     string
+	scopes   string
+	username string
+}
+
+type cachedToken struct {
+	token      string
+	expiration time.Time
+}
+
+// NewTokenCache returns a TokenCache keeping tokens for at most ttl. A ttl of zero keeps tokens for at
+// most 60 seconds, the minimum lifetime of registry tokens.
+func NewTokenCache(ttl time.Duration) *TokenCache {
+	if ttl <= 0 {
+		ttl = defaultTokenCacheTTL
+	}
+	return &TokenCache{
+		ttl:    ttl,
+		tokens: make(map[tokenCacheKey]cachedToken),
+	}
+}
+
+func (c *TokenCache) get(key tokenCacheKey) (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	cached, ok := c.tokens[key]
+	if !ok {
+		return "", false
+	}
+	if !nowFn().Before(cached.expiration) {
+		delete(c.tokens, key)
+		return "", false
+	}
+	return cached.token, true
+}
+
+// set stores token until expiration, or until the TTL of the cache if that is earlier or expiration is
+// unknown.
+func (c *TokenCache) set(key tokenCacheKey, token string, expiration time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if limit := nowFn().Add(c.ttl); expiration.IsZero() || expiration.After(limit) {
+		expiration = limit
+	}
+	c.tokens[key] = cachedToken{token: token, expiration: expiration}
+}
+
+// forget drops every entry holding token.
+func (c *TokenCache) forget(token string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for key, cached := range c.tokens {
+		if cached.token == token {
+			delete(c.tokens, key)
+		}
+	}
+}
+
+// forgetRejectedToken drops the bearer token of the request of an unauthorized response from the cache,
+// so that the next request obtains a new token instead of reusing the rejected one until it expires.
+func (c *TokenCache) forgetRejectedToken(resp *http.Response) {
+	if resp.Request == nil {
+		return
+	}
+	if token, ok := strings.CutPrefix(resp.Request.Header.Get("Authorization"), "Bearer "); ok && len(token) > 0 {
+		c.forget(token)
+	}
+}
+
+// WithTokenCache shares the bearer tokens of this context with all other contexts using the same cache.
+func (c *Context) WithTokenCache(cache *TokenCache) *Context {
+	c.TokenCache = cache
+	return c
+}
+
+// cachingTokenHandler authorizes requests with tokens from the cache, and stores the tokens obtained
+// by a token handler in the cache along with the expiry reported by the token server.
+type cachingTokenHandler struct {
+	auth.AuthenticationHandler
+
+	cache  *TokenCache
+	host   string
+	scopes []auth.Scope
+	creds  auth.CredentialStore
+	tokens *tokenResponseTransport
+}
+
+func newCachingTokenHandler(cache *TokenCache, host string, options auth.TokenHandlerOptions) auth.AuthenticationHandler {
+	tokens := &tokenResponseTransport{rt: options.Transport}
+	options.Transport = tokens
+	return &cachingTokenHandler{
+		AuthenticationHandler: auth.NewTokenHandlerWithOptions(options),
+		cache:                 cache,
+		host:                  host,
+		scopes:                options.Scopes,
+		creds:                 options.Credentials,
+		tokens:                tokens,
+	}
+}
+
+func (h *cachingTokenHandler) AuthorizeRequest(req *http.Request, params map[string]string) error {
+	// cross repository mounts request additional scopes, these tokens are not cached by the
+	// token handler either
+	if len(req.URL.Query().Get("from")) > 0 {
+		return h.AuthenticationHandler.AuthorizeRequest(req, params)
+	}
+
+	key := h.key(params)
+	if token, ok := h.cache.get(key); ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+	if err := h.AuthenticationHandler.AuthorizeRequest(req, params); err != nil {
+		return err
+	}
+	if token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "); len(token) > 0 {
+		h.cache.set(key, token, h.tokens.expiration(token))
+	}
+	return nil
+}
+
+func (h *cachingTokenHandler) key(params map[string]string) tokenCacheKey {
+	scopes := make([]string, 0, len(h.scopes))
+	for _, scope := range h.scopes {
+		scopes = append(scopes, scope.String())
+	}
+	var username string
+	if realm, err := url.Parse(params["realm"]); err == nil {
+		username, _ = h.creds.Basic(realm)
+	}
+	return tokenCacheKey{
+		host:     h.host,
+		scopes:   strings.Join(scopes, " "),
+		username: username,
+	}
+}
+
+// tokenResponseTransport records the expiry of the last token issued by the token server. The token
+// handler does not expose the expiry of its tokens, and only holds a single token at a time.
+type tokenResponseTransport struct {
+	rt http.RoundTripper
+
+	lock           sync.Mutex
+	lastToken      string
+	lastExpiration time.Time
+}
+
+// tokenResponse holds the fields of both the basic auth and the OAuth token responses.
+type tokenResponse struct {
+	Token       string    `json:"token"`
+	AccessToken string    `json:"access_token"`
+	ExpiresIn   int       `json:"expires_in"`
+	IssuedAt    time.Time `json:"issued_at"`
+}
+
+func (t *tokenResponseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return resp, nil
+	}
+	token := tr.Token
+	if len(token) == 0 {
+		token = tr.AccessToken
+	}
+	if len(token) == 0 {
+		return resp, nil
+	}
+	// the token handler applies the same defaults
+	if tr.ExpiresIn < int(defaultTokenCacheTTL/time.Second) {
+		tr.ExpiresIn = int(defaultTokenCacheTTL / time.Second)
+	}
+	if tr.IssuedAt.IsZero() {
+		tr.IssuedAt = nowFn()
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.lastToken = token
+	t.lastExpiration = tr.IssuedAt.Add(time.Duration(tr.ExpiresIn) * time.Second)
+	return resp, nil
+}
+
+// expiration returns the expiry reported by the token server for token, or a zero time if it is unknown.
+func (t *tokenResponseTransport) expiration(token string) time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if token != t.lastToken {
+		return time.Time{}
+	}
+	return t.lastExpiration
+}
@@ -0,0 +1,186 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenCache(t *testing.T) {
+	tokenRequests := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokenRequests++
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"token":"shared-token","expires_in":300}`))
+			return
+		}
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		if r.Header.Get("Authorization") != "Bearer shared-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry.test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case "/v2/test/image/blobs/" + payload1Digest.String():
+			w.Header().Set("Content-Length", "4")
+			w.Header().Set("Docker-Content-Digest", payload1Digest.String())
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	uri, _ := url.Parse(server.URL)
+
+	stat := func(c *Context) {
+		t.Helper()
+		repo, err := c.Repository(context.Background(), uri, "test/image", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.Blobs(context.Background()).Stat(context.Background(), payload1Digest); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cache := NewTokenCache(0)
+	stat(NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials).WithTokenCache(cache))
+	if tokenRequests != 1 {
+		t.Fatalf("expected 1 token request, got %d", tokenRequests)
+	}
+
+	// a second context sharing the cache reuses the token
+	stat(NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials).WithTokenCache(cache))
+	if tokenRequests != 1 {
+		t.Fatalf("expected the token to be reused, got %d token requests", tokenRequests)
+	}
+
+	// a context without the cache fetches its own token
+	stat(NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials))
+	if tokenRequests != 2 {
+		t.Fatalf("expected 2 token requests, got %d", tokenRequests)
+	}
+
+	// expired tokens are fetched again
+	defer func(now func() time.Time) { nowFn = now }(nowFn)
+	nowFn = func() time.Time { return time.Now().Add(2 * defaultTokenCacheTTL) }
+	stat(NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials).WithTokenCache(cache))
+	if tokenRequests != 3 {
+		t.Fatalf("expected 3 token requests, got %d", tokenRequests)
+	}
+}
+
+// tokenServer issues a new token for every token request with the given lifetime, and accepts only the
+// tokens that have not been revoked.
+type tokenServer struct {
+	*httptest.Server
+	expiresIn int
+	issued    int
+	revoked   map[string]bool
+}
+
+func newTokenServer(t *testing.T, expiresIn int) *tokenServer {
+	s := &tokenServer{expiresIn: expiresIn, revoked: make(map[string]bool)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			s.issued++
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"token":"token-%d","expires_in":%d}`, s.issued, s.expiresIn)
+			return
+		}
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.revoked[token] {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+s.URL+`/token",service="registry.test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case "/v2/test/image/blobs/" + payload1Digest.String():
+			w.Header().Set("Content-Length", "4")
+			w.Header().Set("Docker-Content-Digest", payload1Digest.String())
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return s
+}
+
+func (s *tokenServer) stat(cache *TokenCache) error {
+	uri, _ := url.Parse(s.URL)
+	c := NewContext(http.DefaultTransport, http.DefaultTransport).WithCredentials(NoCredentials).WithTokenCache(cache)
+	repo, err := c.Repository(context.Background(), uri, "test/image", true)
+	if err != nil {
+		return err
+	}
+	_, err = repo.Blobs(context.Background()).Stat(context.Background(), payload1Digest)
+	return err
+}
+
+func TestTokenCacheServerExpiry(t *testing.T) {
+	server := newTokenServer(t, 120)
+	defer server.Close()
+
+	cache := NewTokenCache(time.Hour)
+	if err := server.stat(cache); err != nil {
+		t.Fatal(err)
+	}
+	defer func(now func() time.Time) { nowFn = now }(nowFn)
+
+	// the token is kept while the token server reports it valid
+	nowFn = func() time.Time { return time.Now().Add(time.Minute) }
+	if err := server.stat(cache); err != nil {
+		t.Fatal(err)
+	}
+	if server.issued != 1 {
+		t.Fatalf("expected the token to be reused, got %d token requests", server.issued)
+	}
+
+	// the token is fetched again once it expired, even though the TTL of the cache is longer
+	nowFn = func() time.Time { return time.Now().Add(3 * time.Minute) }
+	if err := server.stat(cache); err != nil {
+		t.Fatal(err)
+	}
+	if server.issued != 2 {
+		t.Fatalf("expected the expired token to be fetched again, got %d token requests", server.issued)
+	}
+}
+
+func TestTokenCacheRejectedToken(t *testing.T) {
+	server := newTokenServer(t, 300)
+	defer server.Close()
+
+	cache := NewTokenCache(0)
+	if err := server.stat(cache); err != nil {
+		t.Fatal(err)
+	}
+
+	// a revoked token fails the request authorized from the cache and is dropped
+	server.revoked["token-1"] = true
+	if err := server.stat(cache); err == nil {
+		t.Fatal("expected the revoked token to be rejected")
+	}
+
+	// the next request obtains a new token instead of reusing the rejected one
+	if err := server.stat(cache); err != nil {
+		t.Fatal(err)
+	}
+	if server.issued != 2 {
+		t.Fatalf("expected a new token to be fetched, got %d token requests", server.issued)
+	}
+}
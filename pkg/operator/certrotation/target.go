@@ -4,13 +4,16 @@ import (
 	"context"
 	"crypto/x509"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/klog/v2"
 
@@ -281,13 +284,23 @@ type ServingRotation struct {
 	Hostnames              ServingHostnameFunc
 	CertificateExtensionFn []crypto.CertificateExtensionFunc
 	HostnamesChanged       <-chan struct{}
+
+	// AdditionalHostnamesFn is optional. It is called with the hostnames returned by Hostnames right before the
+	// certificate is signed and returns the hostnames to sign, for SANs that are not known when the controller
+	// is constructed. Each hostname it adds must be a valid DNS name or IP address. The certificate is
+	// regenerated whenever the returned hostnames differ from the ones in the existing certificate.
+	AdditionalHostnamesFn func(hostnames []string) []string
 }
 
 func (r *ServingRotation) NewCertificate(signer *crypto.CA, validity time.Duration) (*crypto.TLSCertificateConfig, error) {
-	if len(r.Hostnames()) == 0 {
+	hostnames, err := r.hostnames()
+	if err != nil {
+		return nil, err
+	}
+	if len(hostnames) == 0 {
 		return nil, fmt.Errorf("no hostnames set")
 	}
-	return signer.MakeServerCertForDuration(sets.New(r.Hostnames()...), validity, r.CertificateExtensionFn...)
+	return signer.MakeServerCertForDuration(sets.New(hostnames...), validity, r.CertificateExtensionFn...)
 }
 
 func (r *ServingRotation) RecheckChannel() <-chan struct{} {
@@ -304,8 +317,13 @@ func (r *ServingRotation) NeedNewTargetCertKeyPair(currentCertSecret *corev1.Sec
 }
 
 func (r *ServingRotation) missingHostnames(annotations map[string]string) string {
+	hostnames, err := r.hostnames()
+	if err != nil {
+		// the error is returned by NewCertificate
+		return err.Error()
+	}
 	existingHostnames := sets.New(strings.Split(annotations[CertificateHostnames], ",")...)
-	requiredHostnames := sets.New(r.Hostnames()...)
+	requiredHostnames := sets.New(hostnames...)
 	if !existingHostnames.Equal(requiredHostnames) {
 		existingNotRequired := existingHostnames.Difference(requiredHostnames)
 		requiredNotExisting := requiredHostnames.Difference(existingHostnames)
@@ -315,6 +333,36 @@ func (r *ServingRotation) missingHostnames(annotations map[string]string) string
 	return ""
 }
 
+// hostnames returns the hostnames to sign, including the ones added by AdditionalHostnamesFn.
+func (r *ServingRotation) hostnames() ([]string, error) {
+	hostnames := r.Hostnames()
+	if r.AdditionalHostnamesFn == nil {
+		return hostnames, nil
+	}
+
+	configured := sets.New(hostnames...)
+	augmented := r.AdditionalHostnamesFn(append([]string{}, hostnames...))
+	var errs []error
+	for _, hostname := range augmented {
+		if configured.Has(hostname) {
+			continue
+		}
+		if net.ParseIP(hostname) != nil {
+			continue
+		}
+		if msgs := validation.IsWildcardDNS1123Subdomain(hostname); len(msgs) == 0 {
+			continue
+		}
+		if msgs := validation.IsDNS1123Subdomain(hostname); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("additional hostname %q is neither a valid DNS name nor an IP address: %s", hostname, strings.Join(msgs, ", ")))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	return augmented, nil
+}
+
 func (r *ServingRotation) SetAnnotations(cert *crypto.TLSCertificateConfig, annotations map[string]string) map[string]string {
 	hostnames := sets.Set[string]{}
 	for _, ip := range cert.Certs[0].IPAddresses {
@@ -333,6 +333,51 @@ func TestServerHostnameCheck(t *testing.T) {
 	}
 }
 
+func TestServingRotationAdditionalHostnames(t *testing.T) {
+	ca, err := newTestCACertificate(pkix.Name{CommonName: "signer-tests"}, int64(1), metav1.Duration{Duration: time.Hour * 24 * 60}, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	additional := []string{"vanity.example.com", "10.0.0.1"}
+	r := &ServingRotation{
+		Hostnames: func() []string { return []string{"foo"} },
+		AdditionalHostnamesFn: func(hostnames []string) []string {
+			return append(hostnames, additional...)
+		},
+	}
+	if reason := r.missingHostnames(map[string]string{CertificateHostnames: "foo"}); reason != `"" are existing and not required, "10.0.0.1,vanity.example.com" are required and not existing` {
+		t.Errorf("unexpected reason: %q", reason)
+	}
+
+	cert, err := r.NewCertificate(ca, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := r.SetAnnotations(cert, map[string]string{})
+	if annotations[CertificateHostnames] != "10.0.0.1,foo,vanity.example.com" {
+		t.Errorf("unexpected hostnames: %q", annotations[CertificateHostnames])
+	}
+	if reason := r.missingHostnames(annotations); len(reason) > 0 {
+		t.Errorf("expected no regeneration, got %q", reason)
+	}
+
+	// removing an additional hostname requires a new certificate
+	additional = []string{"vanity.example.com"}
+	if reason := r.missingHostnames(annotations); reason != `"10.0.0.1" are existing and not required, "" are required and not existing` {
+		t.Errorf("unexpected reason: %q", reason)
+	}
+
+	// invalid additional hostnames are not signed
+	additional = []string{"Not_A_Hostname"}
+	if reason := r.missingHostnames(annotations); len(reason) == 0 {
+		t.Errorf("expected a reason for the invalid hostname")
+	}
+	if _, err := r.NewCertificate(ca, time.Hour); err == nil {
+		t.Errorf("expected an error for the invalid hostname")
+	}
+}
+
 func TestEnsureTargetSignerCertKeyPair(t *testing.T) {
 	tests := []struct {
 		name string
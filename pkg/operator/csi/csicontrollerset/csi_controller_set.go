@@ -17,6 +17,7 @@ import (
 	"github.com/openshift/library-go/pkg/operator/csi/csiconfigobservercontroller"
 	"github.com/openshift/library-go/pkg/operator/csi/csidrivercontrollerservicecontroller"
 	"github.com/openshift/library-go/pkg/operator/csi/csidrivernodeservicecontroller"
+	"github.com/openshift/library-go/pkg/operator/csi/csidriverobjectcontroller"
 	"github.com/openshift/library-go/pkg/operator/csi/csistorageclasscontroller"
 	"github.com/openshift/library-go/pkg/operator/deploymentcontroller"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -40,6 +41,7 @@ type CSIControllerSet struct {
 	csiConfigObserverController           factory.Controller
 	csiDriverControllerServiceController  factory.Controller
 	csiDriverNodeServiceController        factory.Controller
+	csiDriverObjectController             factory.Controller
 	serviceMonitorController              factory.Controller
 	csiStorageclassController             factory.Controller
 
@@ -57,6 +59,7 @@ func (c *CSIControllerSet) Run(ctx context.Context, workers int) {
 		c.csiConfigObserverController,
 		c.csiDriverControllerServiceController,
 		c.csiDriverNodeServiceController,
+		c.csiDriverObjectController,
 		c.serviceMonitorController,
 		c.csiStorageclassController,
 	}, c.conditionalStaticResourcesControllers...) {
@@ -226,6 +229,31 @@ func (c *CSIControllerSet) WithCSIDriverNodeService(
 	return c
 }
 
+// WithCSIDriverObjectController returns a *ControllerSet that reconciles the CSIDriver object from the manifest in file.
+func (c *CSIControllerSet) WithCSIDriverObjectController(
+	name string,
+	assetFunc resourceapply.AssetFunc,
+	file string,
+	kubeClient kubernetes.Interface,
+	informerFactory informers.SharedInformerFactory,
+	optionalCSIDriverHooks ...csidriverobjectcontroller.CSIDriverHookFunc,
+) *CSIControllerSet {
+	manifestFile, err := assetFunc(file)
+	if err != nil {
+		panic(fmt.Sprintf("asset: Asset(%v): %v", file, err))
+	}
+	c.csiDriverObjectController = csidriverobjectcontroller.NewCSIDriverObjectController(
+		name,
+		manifestFile,
+		c.eventRecorder,
+		c.operatorClient,
+		kubeClient,
+		informerFactory.Storage().V1().CSIDrivers(),
+		optionalCSIDriverHooks...,
+	)
+	return c
+}
+
 // WithServiceMonitorController returns a *ControllerSet that creates ServiceMonitor.
 func (c *CSIControllerSet) WithServiceMonitorController(
 	name string,
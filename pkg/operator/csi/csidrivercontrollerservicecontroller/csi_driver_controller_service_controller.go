@@ -34,6 +34,7 @@ import (
 // LIVENESS_PROBE_IMAGE
 //
 // The names above should be wrapped by a ${}, e.g., ${DIVER_IMAGE} in static file.
+// Sidecars passed to WithOptionalContainersHook are removed from the Deployment when their environment variable is not set.
 //
 // 2. Log level
 //
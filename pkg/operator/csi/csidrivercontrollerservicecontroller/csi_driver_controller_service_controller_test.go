@@ -242,6 +242,66 @@ func TestDeploymentHook(t *testing.T) {
 	}
 }
 
+func TestOptionalContainers(t *testing.T) {
+	// Initialize
+	images := defaultImages()
+	images.snapshotter = ""
+	for envName, image := range map[string]string{
+		driverImageEnvName:        images.csiDriver,
+		provisionerImageEnvName:   images.provisioner,
+		attacherImageEnvName:      images.attacher,
+		resizerImageEnvName:       images.resizer,
+		snapshotterImageEnvName:   images.snapshotter,
+		livenessProbeImageEnvName: images.livenessProbe,
+		kubeRBACProxyImageEnvName: images.kubeRBACProxy,
+	} {
+		t.Setenv(envName, image)
+	}
+	coreClient := fakecore.NewSimpleClientset()
+	coreInformerFactory := coreinformers.NewSharedInformerFactory(coreClient, 0 /*no resync */)
+	initialInfras := []runtime.Object{makeInfra()}
+	configClient := fakeconfig.NewSimpleClientset(initialInfras...)
+	configInformerFactory := configinformers.NewSharedInformerFactory(configClient, 0)
+	configInformerFactory.Config().V1().Infrastructures().Informer().GetIndexer().Add(initialInfras[0])
+	driverInstance := makeFakeDriverInstance()
+	fakeOperatorClient := v1helpers.NewFakeOperatorClient(&driverInstance.Spec, &driverInstance.Status, nil /*triggerErr func*/)
+	controller := NewCSIDriverControllerServiceController(
+		controllerName,
+		makeFakeManifest(),
+		events.NewInMemoryRecorder(operandName, clocktesting.NewFakePassiveClock(time.Now())),
+		fakeOperatorClient,
+		coreClient,
+		coreInformerFactory.Apps().V1().Deployments(),
+		configInformerFactory,
+		nil, /* optional informers */
+		WithOptionalContainersHook(resizerContainerName, snapshotterContainerName),
+	)
+
+	// Act
+	err := controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test-csi-driver", clocktesting.NewFakePassiveClock(time.Now()))))
+	if err != nil {
+		t.Fatalf("sync() returned unexpected error: %v", err)
+	}
+
+	// Assert
+	actualDeployment, err := coreClient.AppsV1().Deployments(operandNamespace).Get(context.TODO(), deploymentName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get Deployment %s: %v", deploymentName, err)
+	}
+
+	// The snapshotter has no image and is removed, the resizer is optional but has an image
+	containers := actualDeployment.Spec.Template.Spec.Containers
+	if idx := getIndex(containers, snapshotterContainerName); idx > -1 {
+		t.Errorf("Expected container %s to be removed, got image %q", snapshotterContainerName, containers[idx].Image)
+	}
+	if idx := getIndex(containers, resizerContainerName); idx == -1 || containers[idx].Image != images.resizer {
+		t.Errorf("Expected container %s with image %q", resizerContainerName, images.resizer)
+	}
+	if idx := getIndex(containers, csiDriverContainerName); idx == -1 {
+		t.Errorf("Expected container %s to be kept", csiDriverContainerName)
+	}
+}
+
 func defaultImages() images {
 	return images{
 		csiDriver:     "quay.io/openshift/origin-test-csi-driver:latest",
@@ -6,12 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
 	corev1 "k8s.io/client-go/informers/core/v1"
 
 	configv1 "github.com/openshift/api/config/v1"
@@ -160,6 +163,28 @@ func WithReplicasHook(configInformer configinformers.SharedInformerFactory) dc.D
 	}
 }
 
+// WithOptionalContainersHook creates a deployment hook that removes the given containers from the deployment
+// when their image was not provided. WithPlaceholdersHook replaces an image placeholder like ${SNAPSHOTTER_IMAGE}
+// only when its environment variable is set, so a container whose image is empty or still a ${} placeholder
+// has no image to run and is dropped instead of being deployed with an invalid image.
+func WithOptionalContainersHook(containerNames ...string) dc.DeploymentHookFunc {
+	return func(_ *opv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		optional := sets.New(containerNames...)
+		podSpec := &deployment.Spec.Template.Spec
+		podSpec.Containers = slices.DeleteFunc(podSpec.Containers, func(container v1.Container) bool {
+			return optional.Has(container.Name) && !hasImage(container)
+		})
+		podSpec.InitContainers = slices.DeleteFunc(podSpec.InitContainers, func(container v1.Container) bool {
+			return optional.Has(container.Name) && !hasImage(container)
+		})
+		return nil
+	}
+}
+
+func hasImage(container v1.Container) bool {
+	return len(container.Image) > 0 && !strings.HasPrefix(container.Image, "${")
+}
+
 // WithPlaceholdersHook is a manifest hook which replaces the variable with appropriate values set
 func WithPlaceholdersHook(configInformer configinformers.SharedInformerFactory) dc.ManifestHookFunc {
 	return func(spec *opv1.OperatorSpec, manifest []byte) ([]byte, error) {
@@ -23,6 +23,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	appsinformersv1 "k8s.io/client-go/informers/apps/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
@@ -36,6 +37,26 @@ const (
 	kubeRBACProxyImageEnvName       = "KUBE_RBAC_PROXY_IMAGE"
 )
 
+// defaultImageReplacement maps the image placeholders of the manifest to the environment variables
+// holding the images.
+var defaultImageReplacement = map[string]string{
+	"${DRIVER_IMAGE}":                driverImageEnvName,
+	"${NODE_DRIVER_REGISTRAR_IMAGE}": nodeDriverRegistrarImageEnvName,
+	"${LIVENESS_PROBE_IMAGE}":        livenessProbeImageEnvName,
+	"${KUBE_RBAC_PROXY_IMAGE}":       kubeRBACProxyImageEnvName,
+}
+
+// Reasons of the <name>Available and <name>Progressing conditions while the CSI Node Service is not fully deployed.
+const (
+	// DeployingReason is used while the DaemonSet controller has not acted on changes of the DaemonSet yet.
+	DeployingReason = "Deploying"
+	// UpdatingReason is used while the DaemonSet is rolling out updated pods to the nodes.
+	UpdatingReason = "Updating"
+	// PodsUnavailableReason is used when the rollout is done, but some of the pods are not available,
+	// e.g. because they can't be scheduled or keep failing.
+	PodsUnavailableReason = "PodsUnavailable"
+)
+
 // DaemonSetHookFunc is a hook function to modify the DaemonSet.
 type DaemonSetHookFunc func(*opv1.OperatorSpec, *appsv1.DaemonSet) error
 
@@ -56,6 +77,8 @@ type DaemonSetHookFunc func(*opv1.OperatorSpec, *appsv1.DaemonSet) error
 // LIVENESS_PROBE_IMAGE
 //
 // The names above should be wrapped by a ${}, e.g., ${DIVER_IMAGE} in static file.
+// NewCSIDriverNodeServiceControllerWithImageReplacement allows to use different placeholders
+// and environment variables, e.g. when running several CSI Node Services in one operator.
 //
 // 2. Log level
 //
@@ -85,6 +108,8 @@ type CSIDriverNodeServiceController struct {
 	// Also, in that scenario the Degraded status is set to True.
 	optionalDaemonSetHooks []DaemonSetHookFunc
 	optionalManifestHooks  []dc.ManifestHookFunc
+	// imageReplacement maps the image placeholders of the manifest to the environment variables holding the images.
+	imageReplacement map[string]string
 }
 
 func NewCSIDriverNodeServiceController(
@@ -96,6 +121,34 @@ func NewCSIDriverNodeServiceController(
 	dsInformer appsinformersv1.DaemonSetInformer,
 	optionalInformers []factory.Informer,
 	optionalDaemonSetHooks ...DaemonSetHookFunc,
+) factory.Controller {
+	return NewCSIDriverNodeServiceControllerWithImageReplacement(
+		instanceName,
+		manifest,
+		defaultImageReplacement,
+		recorder,
+		operatorClient,
+		kubeClient,
+		dsInformer,
+		optionalInformers,
+		optionalDaemonSetHooks...,
+	)
+}
+
+// NewCSIDriverNodeServiceControllerWithImageReplacement does what NewCSIDriverNodeServiceController does, but
+// replaces the container images using the given imageReplacement, which maps manifest placeholders such as
+// ${DRIVER_IMAGE} to the names of the environment variables holding the images. Placeholders whose
+// environment variable is not set are left untouched.
+func NewCSIDriverNodeServiceControllerWithImageReplacement(
+	instanceName string,
+	manifest []byte,
+	imageReplacement map[string]string,
+	recorder events.Recorder,
+	operatorClient v1helpers.OperatorClientWithFinalizers,
+	kubeClient kubernetes.Interface,
+	dsInformer appsinformersv1.DaemonSetInformer,
+	optionalInformers []factory.Informer,
+	optionalDaemonSetHooks ...DaemonSetHookFunc,
 ) factory.Controller {
 	c := &CSIDriverNodeServiceController{
 		instanceName:           instanceName,
@@ -108,6 +161,7 @@ func NewCSIDriverNodeServiceController(
 		optionalManifestHooks: []dc.ManifestHookFunc{
 			csidrivercontrollerservicecontroller.WithServingInfo(),
 		},
+		imageReplacement: imageReplacement,
 	}
 	informers := append(optionalInformers, operatorClient.Informer(), dsInformer.Informer())
 	return factory.New().WithInformers(
@@ -159,7 +213,8 @@ func (c *CSIDriverNodeServiceController) sync(ctx context.Context, syncContext f
 
 func (c *CSIDriverNodeServiceController) syncManaged(ctx context.Context, opSpec *opv1.OperatorSpec, opStatus *opv1.OperatorStatus, syncContext factory.SyncContext) error {
 	klog.V(4).Infof("syncManaged")
-	if management.IsOperatorRemovable() {
+	if removable, reason := management.RemovableState(); removable {
+		klog.V(4).Infof("Ensuring finalizer %s: %s", c.instanceName, reason)
 		if err := v1helpers.EnsureFinalizer(ctx, c.operatorClient, c.instanceName); err != nil {
 			return err
 		}
@@ -202,11 +257,16 @@ func (c *CSIDriverNodeServiceController) syncManaged(ctx context.Context, opSpec
 			WithMessage("DaemonSet is available").
 			WithReason("AsExpected")
 
+	} else if isRolledOut(daemonSet) && daemonSet.Status.NumberUnavailable > 0 {
+		availableCondition = availableCondition.
+			WithStatus(opv1.ConditionFalse).
+			WithMessage("Waiting for the DaemonSet pods of the CSI Node Service to become available").
+			WithReason(PodsUnavailableReason)
 	} else {
 		availableCondition = availableCondition.
 			WithStatus(opv1.ConditionFalse).
 			WithMessage("Waiting for the DaemonSet to deploy the CSI Node Service").
-			WithReason("Deploying")
+			WithReason(DeployingReason)
 	}
 	status = status.WithConditions(availableCondition)
 
@@ -217,11 +277,11 @@ func (c *CSIDriverNodeServiceController) syncManaged(ctx context.Context, opSpec
 		WithMessage("DaemonSet is not progressing").
 		WithReason("AsExpected")
 
-	if ok, msg := isProgressing(opStatus, daemonSet); ok {
+	if ok, reason, msg := isProgressing(opStatus, daemonSet); ok {
 		progressingCondition = progressingCondition.
 			WithStatus(opv1.ConditionTrue).
 			WithMessage(msg).
-			WithReason("Deploying")
+			WithReason(reason)
 	}
 	status = status.WithConditions(progressingCondition)
 
@@ -233,7 +293,7 @@ func (c *CSIDriverNodeServiceController) syncManaged(ctx context.Context, opSpec
 }
 
 func (c *CSIDriverNodeServiceController) getDaemonSet(opSpec *opv1.OperatorSpec) (*appsv1.DaemonSet, error) {
-	manifest := replacePlaceholders(c.manifest, opSpec)
+	manifest := replacePlaceholders(c.manifest, opSpec, c.imageReplacement)
 
 	for i, hook := range c.optionalManifestHooks {
 		var err error
@@ -253,41 +313,33 @@ func (c *CSIDriverNodeServiceController) getDaemonSet(opSpec *opv1.OperatorSpec)
 	return required, nil
 }
 
-func isProgressing(status *opv1.OperatorStatus, daemonSet *appsv1.DaemonSet) (bool, string) {
+func isProgressing(status *opv1.OperatorStatus, daemonSet *appsv1.DaemonSet) (bool, string, string) {
 	switch {
 	case daemonSet.Generation != daemonSet.Status.ObservedGeneration:
-		return true, "Waiting for DaemonSet to act on changes"
+		return true, DeployingReason, "Waiting for DaemonSet to act on changes"
 	case daemonSet.Status.UpdatedNumberScheduled < daemonSet.Status.DesiredNumberScheduled:
-		return true, fmt.Sprintf("Waiting for DaemonSet to update %d node pods", daemonSet.Status.DesiredNumberScheduled)
+		return true, UpdatingReason, fmt.Sprintf("Waiting for DaemonSet to update %d node pods", daemonSet.Status.DesiredNumberScheduled)
 	case daemonSet.Status.NumberUnavailable > 0:
-		return true, "Waiting for DaemonSet to deploy node pods"
+		return true, PodsUnavailableReason, "Waiting for DaemonSet to deploy node pods"
 	}
-	return false, ""
+	return false, "", ""
+}
+
+// isRolledOut returns true when the DaemonSet controller acted on the latest changes and all node pods are updated.
+func isRolledOut(daemonSet *appsv1.DaemonSet) bool {
+	return daemonSet.Generation == daemonSet.Status.ObservedGeneration &&
+		daemonSet.Status.UpdatedNumberScheduled >= daemonSet.Status.DesiredNumberScheduled
 }
 
-func replacePlaceholders(manifest []byte, spec *opv1.OperatorSpec) []byte {
+func replacePlaceholders(manifest []byte, spec *opv1.OperatorSpec, imageReplacement map[string]string) []byte {
 	pairs := []string{}
 
 	// Replace container images by env vars if they are set
-	csiDriver := os.Getenv(driverImageEnvName)
-	if csiDriver != "" {
-		pairs = append(pairs, []string{"${DRIVER_IMAGE}", csiDriver}...)
-	}
-
-	nodeDriverRegistrar := os.Getenv(nodeDriverRegistrarImageEnvName)
-	if nodeDriverRegistrar != "" {
-		pairs = append(pairs, []string{"${NODE_DRIVER_REGISTRAR_IMAGE}", nodeDriverRegistrar}...)
-
-	}
-
-	livenessProbe := os.Getenv(livenessProbeImageEnvName)
-	if livenessProbe != "" {
-		pairs = append(pairs, []string{"${LIVENESS_PROBE_IMAGE}", livenessProbe}...)
-	}
-
-	kubeRBACProxy := os.Getenv(kubeRBACProxyImageEnvName)
-	if kubeRBACProxy != "" {
-		pairs = append(pairs, []string{"${KUBE_RBAC_PROXY_IMAGE}", kubeRBACProxy}...)
+	for _, placeholder := range sets.List(sets.KeySet(imageReplacement)) {
+		image := os.Getenv(imageReplacement[placeholder])
+		if image != "" {
+			pairs = append(pairs, []string{placeholder, image}...)
+		}
 	}
 
 	// Log level
@@ -313,5 +365,7 @@ func (c *CSIDriverNodeServiceController) syncDeleting(ctx context.Context, opSpe
 	}
 
 	// All removed, remove the finalizer as the last step
+	_, reason := management.RemovableState()
+	syncContext.Recorder().Eventf("FinalizerRemoved", "Removing finalizer %s after deleting DaemonSet %s/%s: %s", c.instanceName, required.Namespace, required.Name, reason)
 	return v1helpers.RemoveFinalizer(ctx, c.operatorClient, c.instanceName)
 }
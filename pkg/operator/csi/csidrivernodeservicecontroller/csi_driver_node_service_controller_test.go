@@ -68,7 +68,11 @@ type testCase struct {
 	removable       bool
 	initialObjects  testObjects
 	expectedObjects testObjects
-	expectErr       bool
+	// expectedReasons are the expected reasons of the driver conditions, by condition type
+	expectedReasons map[string]string
+	// expectedEvents are the expected messages of the recorded events, by event reason
+	expectedEvents map[string]string
+	expectErr      bool
 }
 
 type testObjects struct {
@@ -300,6 +304,13 @@ func withDaemonSetStatus(numberReady, updatedNumber, numberAvailable, numberUnav
 	}
 }
 
+func withDaemonSetDesiredNumberScheduled(desired int32) daemonSetModifier {
+	return func(instance *appsv1.DaemonSet) *appsv1.DaemonSet {
+		instance.Status.DesiredNumberScheduled = desired
+		return instance
+	}
+}
+
 func withDaemonSetGeneration(generations ...int64) daemonSetModifier {
 	return func(instance *appsv1.DaemonSet) *appsv1.DaemonSet {
 		instance.Generation = generations[0]
@@ -393,6 +404,127 @@ func TestDaemonSetHook(t *testing.T) {
 	}
 }
 
+func TestNodePlacementDaemonSetHook(t *testing.T) {
+	manifestTolerations := []v1.Toleration{{Key: "node-role.kubernetes.io/master", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule}}
+	manifestTolerationsHook := func(_ *opv1.OperatorSpec, ds *appsv1.DaemonSet) error {
+		ds.Spec.Template.Spec.Tolerations = manifestTolerations
+		return nil
+	}
+
+	testCases := []struct {
+		name                 string
+		observedConfig       string
+		expectedNodeSelector map[string]string
+		expectedTolerations  []v1.Toleration
+	}{
+		{
+			name:                "no observed config keeps the existing constraints",
+			expectedTolerations: manifestTolerations,
+		},
+		{
+			name:                "observed config without node placement keeps the existing constraints",
+			observedConfig:      `{"targetcsiconfig": {"proxy": {"HTTP_PROXY": "http://foo.bar.proxy"}}}`,
+			expectedTolerations: manifestTolerations,
+		},
+		{
+			name:                 "node placement from observed config",
+			observedConfig:       `{"nodePlacement": {"nodeSelector": {"node-role.kubernetes.io/infra": ""}, "tolerations": [{"key": "node-role.kubernetes.io/infra", "operator": "Exists", "effect": "NoSchedule"}]}}`,
+			expectedNodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+			expectedTolerations:  []v1.Toleration{{Key: "node-role.kubernetes.io/infra", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize
+			coreClient := fakecore.NewSimpleClientset()
+			coreInformerFactory := coreinformers.NewSharedInformerFactory(coreClient, 0 /*no resync */)
+			driverInstance := makeFakeDriverInstance()
+			if len(tc.observedConfig) > 0 {
+				driverInstance.Spec.ObservedConfig = runtime.RawExtension{Raw: []byte(tc.observedConfig)}
+			}
+			fakeOperatorClient := v1helpers.NewFakeOperatorClient(&driverInstance.Spec, &driverInstance.Status, nil /*triggerErr func*/)
+			controller := NewCSIDriverNodeServiceController(
+				controllerName,
+				makeFakeManifest(),
+				events.NewInMemoryRecorder(operandName, clocktesting.NewFakePassiveClock(time.Now())),
+				fakeOperatorClient,
+				coreClient,
+				coreInformerFactory.Apps().V1().DaemonSets(),
+				nil, /* optional informers */
+				manifestTolerationsHook,
+				WithNodePlacementDaemonSetHook(),
+			)
+
+			// Act
+			err := controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test-csi-driver", clocktesting.NewFakePassiveClock(time.Now()))))
+			if err != nil {
+				t.Fatalf("sync() returned unexpected error: %v", err)
+			}
+
+			// Assert
+			actualDaemonSet, err := coreClient.AppsV1().DaemonSets(operandNamespace).Get(context.TODO(), daemonSetName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get DaemonSet %s: %v", daemonSetName, err)
+			}
+			if !equality.Semantic.DeepEqual(actualDaemonSet.Spec.Template.Spec.NodeSelector, tc.expectedNodeSelector) {
+				t.Errorf("unexpected nodeSelector: %s", cmp.Diff(tc.expectedNodeSelector, actualDaemonSet.Spec.Template.Spec.NodeSelector))
+			}
+			if !equality.Semantic.DeepEqual(actualDaemonSet.Spec.Template.Spec.Tolerations, tc.expectedTolerations) {
+				t.Errorf("unexpected tolerations: %s", cmp.Diff(tc.expectedTolerations, actualDaemonSet.Spec.Template.Spec.Tolerations))
+			}
+		})
+	}
+}
+
+func TestImageReplacement(t *testing.T) {
+	// Initialize
+	t.Setenv("OPERATOR_NAME", "test")
+	t.Setenv("FOO_DRIVER_IMAGE", "quay.io/openshift/foo-driver:latest")
+	t.Setenv("FOO_REGISTRAR_IMAGE", "quay.io/openshift/foo-registrar:latest")
+	t.Setenv(livenessProbeImageEnvName, "quay.io/openshift/origin-csi-livenessprobe:latest")
+	coreClient := fakecore.NewSimpleClientset()
+	coreInformerFactory := coreinformers.NewSharedInformerFactory(coreClient, 0 /*no resync */)
+	driverInstance := makeFakeDriverInstance()
+	fakeOperatorClient := v1helpers.NewFakeOperatorClient(&driverInstance.Spec, &driverInstance.Status, nil /*triggerErr func*/)
+	controller := NewCSIDriverNodeServiceControllerWithImageReplacement(
+		controllerName,
+		makeFakeManifest(),
+		map[string]string{
+			"${DRIVER_IMAGE}":                "FOO_DRIVER_IMAGE",
+			"${NODE_DRIVER_REGISTRAR_IMAGE}": "FOO_REGISTRAR_IMAGE",
+		},
+		events.NewInMemoryRecorder(operandName, clocktesting.NewFakePassiveClock(time.Now())),
+		fakeOperatorClient,
+		coreClient,
+		coreInformerFactory.Apps().V1().DaemonSets(),
+		nil, /* optional informers */
+	)
+
+	// Act
+	err := controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test-csi-driver", clocktesting.NewFakePassiveClock(time.Now()))))
+	if err != nil {
+		t.Fatalf("sync() returned unexpected error: %v", err)
+	}
+
+	// Assert
+	actualDaemonSet, err := coreClient.AppsV1().DaemonSets(operandNamespace).Get(context.TODO(), daemonSetName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get DaemonSet %s: %v", daemonSetName, err)
+	}
+
+	// Only the configured placeholders are replaced, LIVENESS_PROBE_IMAGE is not mapped
+	expectedImages := map[string]string{
+		csiDriverContainerName:           "quay.io/openshift/foo-driver:latest",
+		nodeDriverRegistrarContainerName: "quay.io/openshift/foo-registrar:latest",
+		livenessProbeContainerName:       "${LIVENESS_PROBE_IMAGE}",
+	}
+	for _, container := range actualDaemonSet.Spec.Template.Spec.Containers {
+		if expected, ok := expectedImages[container.Name]; ok && container.Image != expected {
+			t.Errorf("expected container %s to use image %q, got %q", container.Name, expected, container.Image)
+		}
+	}
+}
+
 func TestSync(t *testing.T) {
 	const (
 		replica0 = 0
@@ -424,6 +556,10 @@ func TestSync(t *testing.T) {
 					withTrueConditions(conditionProgressing),
 					withFalseConditions(conditionAvailable)), // Degraded is set later on
 			},
+			expectedReasons: map[string]string{
+				conditionProgressing: DeployingReason,
+				conditionAvailable:   DeployingReason,
+			},
 		},
 		{
 			// DaemonSet is fully deployed and its status is synced to CR
@@ -479,6 +615,10 @@ func TestSync(t *testing.T) {
 					withTrueConditions(conditionProgressing), // The operator is Progressing
 					withFalseConditions(conditionAvailable)), // The operator is not Available (node not running...)
 			},
+			expectedReasons: map[string]string{
+				conditionProgressing: PodsUnavailableReason,
+				conditionAvailable:   PodsUnavailableReason,
+			},
 		},
 		{
 			// DaemonSet is updating pods
@@ -490,6 +630,7 @@ func TestSync(t *testing.T) {
 					argsLevel2,
 					defaultImages(),
 					withDaemonSetGeneration(1, 1),
+					withDaemonSetDesiredNumberScheduled(replica1),
 					withDaemonSetStatus(replica0, replica0, replica1, replica1)), // the DaemonSet is updating 1 pod
 				driver: makeFakeDriverInstance(
 					// withStatus(replica1),
@@ -502,12 +643,16 @@ func TestSync(t *testing.T) {
 					argsLevel2,
 					defaultImages(),
 					withDaemonSetGeneration(1, 1),
+					withDaemonSetDesiredNumberScheduled(replica1),
 					withDaemonSetStatus(replica0, replica0, replica1, replica1)), // no change to the DaemonSet
 				driver: makeFakeDriverInstance(
 					// withStatus(replica0),
 					withGenerations(1),
 					withTrueConditions(conditionAvailable, conditionProgressing)), // The operator is Progressing, but still Available
 			},
+			expectedReasons: map[string]string{
+				conditionProgressing: UpdatingReason,
+			},
 		},
 		{
 			// User changes log level and it's projected into the DaemonSet
@@ -635,6 +780,9 @@ func TestSync(t *testing.T) {
 				driver: makeFakeDriverInstance(
 					withGenerations(1)),
 			},
+			expectedEvents: map[string]string{
+				"FinalizerRemoved": "Removing finalizer " + controllerName + " after deleting DaemonSet " + operandNamespace + "/" + daemonSetName + ": the operator was set removable",
+			},
 		},
 		{
 			// DaemonSet updates TLS config
@@ -671,7 +819,8 @@ func TestSync(t *testing.T) {
 			}
 
 			// Act
-			err := ctx.controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, events.NewInMemoryRecorder("test-csi-driver", clocktesting.NewFakePassiveClock(time.Now()))))
+			recorder := events.NewInMemoryRecorder("test-csi-driver", clocktesting.NewFakePassiveClock(time.Now()))
+			err := ctx.controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, recorder))
 
 			// Assert
 			// Check error
@@ -682,6 +831,22 @@ func TestSync(t *testing.T) {
 				t.Error("sync() unexpectedly succeeded when error was expected")
 			}
 
+			// Check expectedEvents
+			for reason, expectedMessage := range test.expectedEvents {
+				found := false
+				for _, event := range recorder.Events() {
+					if event.Reason == reason {
+						found = true
+						if event.Message != expectedMessage {
+							t.Errorf("Expected event %s to have message %q, got %q", reason, expectedMessage, event.Message)
+						}
+					}
+				}
+				if !found {
+					t.Errorf("Event %s not found", reason)
+				}
+			}
+
 			// Check expectedObjects.daemonSet
 			if test.expectedObjects.daemonSet != nil {
 				dsName := test.expectedObjects.daemonSet.Name
@@ -712,6 +877,14 @@ func TestSync(t *testing.T) {
 				if err != nil {
 					t.Errorf("Failed to get Driver: %v", err)
 				}
+				for conditionType, expectedReason := range test.expectedReasons {
+					condition := v1helpers.FindOperatorCondition(actualStatus.Conditions, conditionType)
+					if condition == nil {
+						t.Errorf("Condition %s not found", conditionType)
+					} else if condition.Reason != expectedReason {
+						t.Errorf("Expected condition %s to have reason %q, got %q", conditionType, expectedReason, condition.Reason)
+					}
+				}
 				sanitizeInstanceStatus(actualStatus)
 				sanitizeInstanceStatus(&test.expectedObjects.driver.Status)
 				if !equality.Semantic.DeepEqual(test.expectedObjects.driver.Status, *actualStatus) {
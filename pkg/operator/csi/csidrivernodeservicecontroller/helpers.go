@@ -7,12 +7,16 @@ import (
 
 	opv1 "github.com/openshift/api/operator/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	corev1 "k8s.io/client-go/informers/core/v1"
 
 	"github.com/openshift/library-go/pkg/operator/csi/csiconfigobservercontroller"
 	"github.com/openshift/library-go/pkg/operator/resource/resourcehash"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"sigs.k8s.io/yaml"
 )
 
 // WithObservedProxyDaemonSetHook creates a hook that injects into the daemonSet's containers the observed proxy config.
@@ -29,6 +33,58 @@ func WithObservedProxyDaemonSetHook() DaemonSetHookFunc {
 	}
 }
 
+// NodePlacementConfigPath returns the path for the node placement in the observed config. This is a
+// function to avoid exposing a slice that could potentially be appended.
+func NodePlacementConfigPath() []string {
+	return []string{"nodePlacement"}
+}
+
+// nodePlacement is the node placement of the CSI Node Service in the observed config.
+type nodePlacement struct {
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	Tolerations  []v1.Toleration   `json:"tolerations,omitempty"`
+}
+
+// WithNodePlacementDaemonSetHook creates a hook that sets the node selector and tolerations of the DaemonSet
+// from the node placement in the observed config, e.g.:
+//
+//	nodePlacement:
+//	  nodeSelector:
+//	    node-role.kubernetes.io/worker: ""
+//	  tolerations:
+//	  - key: node-role.kubernetes.io/infra
+//	    operator: Exists
+//
+// Each of the fields replaces the value from the manifest only when it is set in the observed config,
+// the DaemonSet is left untouched when there is no observed node placement.
+func WithNodePlacementDaemonSetHook() DaemonSetHookFunc {
+	return func(opSpec *opv1.OperatorSpec, daemonSet *appsv1.DaemonSet) error {
+		var config map[string]interface{}
+		if err := yaml.Unmarshal(opSpec.ObservedConfig.Raw, &config); err != nil {
+			return fmt.Errorf("failed to unmarshal the observedConfig: %w", err)
+		}
+		placementConfig, found, err := unstructured.NestedMap(config, NodePlacementConfigPath()...)
+		if err != nil {
+			return fmt.Errorf("couldn't get the node placement from observedConfig: %w", err)
+		}
+		if !found {
+			return nil
+		}
+
+		var placement nodePlacement
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(placementConfig, &placement); err != nil {
+			return fmt.Errorf("invalid node placement in observedConfig: %w", err)
+		}
+		if len(placement.NodeSelector) > 0 {
+			daemonSet.Spec.Template.Spec.NodeSelector = placement.NodeSelector
+		}
+		if len(placement.Tolerations) > 0 {
+			daemonSet.Spec.Template.Spec.Tolerations = placement.Tolerations
+		}
+		return nil
+	}
+}
+
 func WithCABundleDaemonSetHook(
 	configMapNamespace string,
 	configMapName string,
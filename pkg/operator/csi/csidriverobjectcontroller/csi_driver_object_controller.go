@@ -0,0 +1,173 @@
+package csidriverobjectcontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	opv1 "github.com/openshift/api/operator/v1"
+	applyoperatorv1 "github.com/openshift/client-go/operator/applyconfigurations/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	storageinformersv1 "k8s.io/client-go/informers/storage/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// Reasons of the <name>Available and <name>Progressing conditions while the CSIDriver object is not reconciled.
+const (
+	// RecreatingReason is used while a CSIDriver object with changed immutable fields is being deleted,
+	// before it is created again.
+	RecreatingReason = "Recreating"
+)
+
+// CSIDriverHookFunc is a hook function to modify the CSIDriver.
+type CSIDriverHookFunc func(*opv1.OperatorSpec, *storagev1.CSIDriver) error
+
+// CSIDriverObjectController is a controller that reconciles the storage.k8s.io CSIDriver object of a CSI driver
+// from a static manifest.
+//
+// On every sync, this controller reads the CSIDriver from the manifest, runs the optional hooks on it and
+// applies it. Most of the fields of the CSIDriver spec, such as attachRequired or fsGroupPolicy, are immutable.
+// When they change, the existing CSIDriver object is deleted and created again with the new spec.
+//
+// This controller produces the following conditions:
+//
+// <name>Available: indicates that the CSIDriver object matches the manifest.
+// <name>Progressing: indicates that the CSIDriver object is being re-created.
+// <name>Degraded: produced when the sync() method returns an error.
+type CSIDriverObjectController struct {
+	// instanceName is the name to identify what instance this belongs too: FooDriver for instance
+	instanceName string
+	// controllerInstanceName is the name to identify this instance of this particular control loop: FooDriver-CSIDriverObject for instance.
+	controllerInstanceName string
+	manifest               []byte
+	operatorClient         v1helpers.OperatorClient
+	kubeClient             kubernetes.Interface
+	// Optional hook functions to modify the CSIDriver.
+	// If one of these functions returns an error, the sync
+	// fails indicating the ordinal position of the failed function.
+	// Also, in that scenario the Degraded status is set to True.
+	optionalCSIDriverHooks []CSIDriverHookFunc
+}
+
+func NewCSIDriverObjectController(
+	instanceName string,
+	manifest []byte,
+	recorder events.Recorder,
+	operatorClient v1helpers.OperatorClient,
+	kubeClient kubernetes.Interface,
+	csiDriverInformer storageinformersv1.CSIDriverInformer,
+	optionalCSIDriverHooks ...CSIDriverHookFunc,
+) factory.Controller {
+	c := &CSIDriverObjectController{
+		instanceName:           instanceName,
+		controllerInstanceName: factory.ControllerInstanceName(instanceName, "CSIDriverObject"),
+		manifest:               manifest,
+		operatorClient:         operatorClient,
+		kubeClient:             kubeClient,
+		optionalCSIDriverHooks: optionalCSIDriverHooks,
+	}
+	return factory.New().WithInformers(
+		operatorClient.Informer(),
+		csiDriverInformer.Informer(),
+	).WithSync(
+		c.sync,
+	).WithControllerInstanceName(
+		c.controllerInstanceName,
+	).ResyncEvery(
+		time.Minute,
+	).WithSyncDegradedOnError(
+		operatorClient,
+	).ToController(
+		c.instanceName, // don't change what is passed here unless you also remove the old FooDegraded condition
+		recorder.WithComponentSuffix("csi-driver-object_"+strings.ToLower(instanceName)),
+	)
+}
+
+func (c *CSIDriverObjectController) Name() string {
+	return c.instanceName
+}
+
+func (c *CSIDriverObjectController) sync(ctx context.Context, syncContext factory.SyncContext) error {
+	opSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if errors.IsNotFound(err) && management.IsOperatorRemovable() {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if opSpec.ManagementState != opv1.Managed {
+		return nil
+	}
+
+	required, err := c.getCSIDriver(opSpec)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = resourceapply.ApplyCSIDriver(ctx, c.kubeClient.StorageV1(), syncContext.Recorder(), required)
+	if err != nil {
+		// the CSIDriver is re-created because of changed immutable fields, but the old object is still being deleted
+		existing, getErr := c.kubeClient.StorageV1().CSIDrivers().Get(ctx, required.Name, metav1.GetOptions{})
+		if getErr == nil && existing.DeletionTimestamp != nil {
+			klog.V(2).Infof("CSIDriver %s is being re-created, waiting for the old object to be deleted", required.Name)
+			status := applyoperatorv1.OperatorStatus().
+				WithConditions(
+					applyoperatorv1.OperatorCondition().
+						WithType(c.instanceName+opv1.OperatorStatusTypeAvailable).
+						WithStatus(opv1.ConditionFalse).
+						WithMessage(fmt.Sprintf("Waiting for CSIDriver %s to be re-created", required.Name)).
+						WithReason(RecreatingReason),
+					applyoperatorv1.OperatorCondition().
+						WithType(c.instanceName+opv1.OperatorStatusTypeProgressing).
+						WithStatus(opv1.ConditionTrue).
+						WithMessage(fmt.Sprintf("Waiting for CSIDriver %s to be deleted before it is re-created", required.Name)).
+						WithReason(RecreatingReason),
+				)
+			if updateErr := c.operatorClient.ApplyOperatorStatus(ctx, c.controllerInstanceName, status); updateErr != nil {
+				klog.Warningf("Updating status of %q failed: %v", c.instanceName, updateErr)
+			}
+		}
+		return err
+	}
+
+	status := applyoperatorv1.OperatorStatus().
+		WithConditions(
+			applyoperatorv1.OperatorCondition().
+				WithType(c.instanceName+opv1.OperatorStatusTypeAvailable).
+				WithStatus(opv1.ConditionTrue).
+				WithMessage("CSIDriver is available").
+				WithReason("AsExpected"),
+			applyoperatorv1.OperatorCondition().
+				WithType(c.instanceName+opv1.OperatorStatusTypeProgressing).
+				WithStatus(opv1.ConditionFalse).
+				WithMessage("CSIDriver is not progressing").
+				WithReason("AsExpected"),
+		)
+	return c.operatorClient.ApplyOperatorStatus(
+		ctx,
+		c.controllerInstanceName,
+		status,
+	)
+}
+
+func (c *CSIDriverObjectController) getCSIDriver(opSpec *opv1.OperatorSpec) (*storagev1.CSIDriver, error) {
+	required := resourceread.ReadCSIDriverV1OrDie(c.manifest)
+
+	for i := range c.optionalCSIDriverHooks {
+		err := c.optionalCSIDriverHooks[i](opSpec, required)
+		if err != nil {
+			return nil, fmt.Errorf("error running hook function (index=%d): %w", i, err)
+		}
+	}
+	return required, nil
+}
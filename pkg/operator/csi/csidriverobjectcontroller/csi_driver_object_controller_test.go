@@ -0,0 +1,421 @@
+package csidriverobjectcontroller
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	opv1 "github.com/openshift/api/operator/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	coreinformers "k8s.io/client-go/informers"
+	fakecore "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+const (
+	controllerName = "TestCSIDriverObjectController"
+	csiDriverName  = "test.csi.openshift.io"
+
+	// From github.com/openshift/library-go/pkg/operator/resource/resourceapply/apps.go
+	specHashAnnotation = "operator.openshift.io/spec-hash"
+)
+
+var (
+	conditionAvailable   = controllerName + opv1.OperatorStatusTypeAvailable
+	conditionProgressing = controllerName + opv1.OperatorStatusTypeProgressing
+)
+
+type testCase struct {
+	name            string
+	manifest        []byte
+	hooks           []CSIDriverHookFunc
+	initialObjects  testObjects
+	expectedObjects testObjects
+	// reactors are prepended to the fake client before the sync
+	reactors map[string]core.ReactionFunc
+	// expectedReasons are the expected reasons of the driver conditions, by condition type
+	expectedReasons map[string]string
+	// expectedActions are the expected verbs of the client actions on CSIDrivers
+	expectedActions []string
+	expectErr       bool
+}
+
+type testObjects struct {
+	csiDriver *storagev1.CSIDriver
+	driver    *fakeDriverInstance
+}
+
+type testContext struct {
+	controller     factory.Controller
+	operatorClient v1helpers.OperatorClient
+	coreClient     *fakecore.Clientset
+}
+
+func newTestContext(test testCase, t *testing.T) *testContext {
+	var initialObjects []runtime.Object
+	if test.initialObjects.csiDriver != nil {
+		resourceapply.SetSpecHashAnnotation(&test.initialObjects.csiDriver.ObjectMeta, test.initialObjects.csiDriver.Spec)
+		initialObjects = append(initialObjects, test.initialObjects.csiDriver)
+	}
+
+	coreClient := fakecore.NewSimpleClientset(initialObjects...)
+	coreInformerFactory := coreinformers.NewSharedInformerFactory(coreClient, 0 /*no resync */)
+	for verb, reactor := range test.reactors {
+		coreClient.PrependReactor(verb, "csidrivers", reactor)
+	}
+
+	fakeOperatorClient := v1helpers.NewFakeOperatorClientWithObjectMeta(
+		&test.initialObjects.driver.ObjectMeta,
+		&test.initialObjects.driver.Spec,
+		&test.initialObjects.driver.Status,
+		nil, /*triggerErr func*/
+	)
+	controller := NewCSIDriverObjectController(
+		controllerName,
+		test.manifest,
+		events.NewInMemoryRecorder(controllerName, clocktesting.NewFakePassiveClock(time.Now())),
+		fakeOperatorClient,
+		coreClient,
+		coreInformerFactory.Storage().V1().CSIDrivers(),
+		test.hooks...,
+	)
+	return &testContext{
+		controller:     controller,
+		operatorClient: fakeOperatorClient,
+		coreClient:     coreClient,
+	}
+}
+
+// fakeDriverInstance is a fake CSI driver instance that also fullfils the OperatorClient interface
+type fakeDriverInstance struct {
+	metav1.ObjectMeta
+	Spec   opv1.OperatorSpec
+	Status opv1.OperatorStatus
+}
+
+type driverModifier func(*fakeDriverInstance) *fakeDriverInstance
+
+func makeFakeDriverInstance(modifiers ...driverModifier) *fakeDriverInstance {
+	instance := &fakeDriverInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "cluster",
+			Generation: 0,
+		},
+		Spec: opv1.OperatorSpec{
+			ManagementState: opv1.Managed,
+		},
+		Status: opv1.OperatorStatus{},
+	}
+	for _, modifier := range modifiers {
+		instance = modifier(instance)
+	}
+	return instance
+}
+
+func withTrueConditions(conditions ...string) driverModifier {
+	return func(i *fakeDriverInstance) *fakeDriverInstance {
+		for _, cond := range conditions {
+			i.Status.Conditions = append(i.Status.Conditions, opv1.OperatorCondition{
+				Type:   cond,
+				Status: opv1.ConditionTrue,
+			})
+		}
+		return i
+	}
+}
+
+func withFalseConditions(conditions ...string) driverModifier {
+	return func(i *fakeDriverInstance) *fakeDriverInstance {
+		for _, cond := range conditions {
+			i.Status.Conditions = append(i.Status.Conditions, opv1.OperatorCondition{
+				Type:   cond,
+				Status: opv1.ConditionFalse,
+			})
+		}
+		return i
+	}
+}
+
+func withManagementState(state opv1.ManagementState) driverModifier {
+	return func(i *fakeDriverInstance) *fakeDriverInstance {
+		i.Spec.ManagementState = state
+		return i
+	}
+}
+
+// CSIDrivers
+
+type csiDriverModifier func(*storagev1.CSIDriver) *storagev1.CSIDriver
+
+func getCSIDriver(attachRequired bool, fsGroupPolicy storagev1.FSGroupPolicy, modifiers ...csiDriverModifier) *storagev1.CSIDriver {
+	csiDriver := &storagev1.CSIDriver{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: csiDriverName,
+		},
+		Spec: storagev1.CSIDriverSpec{
+			AttachRequired: ptr.To(attachRequired),
+			FSGroupPolicy:  ptr.To(fsGroupPolicy),
+		},
+	}
+	for _, modifier := range modifiers {
+		csiDriver = modifier(csiDriver)
+	}
+	return csiDriver
+}
+
+func withLabel(key, value string) csiDriverModifier {
+	return func(csiDriver *storagev1.CSIDriver) *storagev1.CSIDriver {
+		if csiDriver.Labels == nil {
+			csiDriver.Labels = map[string]string{}
+		}
+		csiDriver.Labels[key] = value
+		return csiDriver
+	}
+}
+
+func withDeletionTimestamp() csiDriverModifier {
+	return func(csiDriver *storagev1.CSIDriver) *storagev1.CSIDriver {
+		csiDriver.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		csiDriver.Finalizers = []string{"test.openshift.io/finalizer"}
+		return csiDriver
+	}
+}
+
+func makeFakeManifest(attachRequired bool, fsGroupPolicy storagev1.FSGroupPolicy) []byte {
+	attach := "false"
+	if attachRequired {
+		attach = "true"
+	}
+	return []byte(`
+apiVersion: storage.k8s.io/v1
+kind: CSIDriver
+metadata:
+  name: ` + csiDriverName + `
+spec:
+  attachRequired: ` + attach + `
+  fsGroupPolicy: ` + string(fsGroupPolicy) + `
+`)
+}
+
+func labelHook(opSpec *opv1.OperatorSpec, csiDriver *storagev1.CSIDriver) error {
+	if csiDriver.Labels == nil {
+		csiDriver.Labels = map[string]string{}
+	}
+	csiDriver.Labels["operator.openshift.io/foo"] = "bar"
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	testCases := []testCase{
+		{
+			// Only CR exists, the CSIDriver is created
+			name:     "initial sync",
+			manifest: makeFakeManifest(true, storagev1.FileFSGroupPolicy),
+			initialObjects: testObjects{
+				driver: makeFakeDriverInstance(),
+			},
+			expectedObjects: testObjects{
+				csiDriver: getCSIDriver(true, storagev1.FileFSGroupPolicy),
+				driver: makeFakeDriverInstance(
+					withTrueConditions(conditionAvailable),
+					withFalseConditions(conditionProgressing)),
+			},
+			expectedReasons: map[string]string{
+				conditionAvailable:   "AsExpected",
+				conditionProgressing: "AsExpected",
+			},
+			expectedActions: []string{"get", "create"},
+		},
+		{
+			// The CSIDriver matches the manifest
+			name:     "no change",
+			manifest: makeFakeManifest(true, storagev1.FileFSGroupPolicy),
+			initialObjects: testObjects{
+				csiDriver: getCSIDriver(true, storagev1.FileFSGroupPolicy),
+				driver:    makeFakeDriverInstance(),
+			},
+			expectedObjects: testObjects{
+				csiDriver: getCSIDriver(true, storagev1.FileFSGroupPolicy),
+				driver: makeFakeDriverInstance(
+					withTrueConditions(conditionAvailable),
+					withFalseConditions(conditionProgressing)),
+			},
+			expectedActions: []string{"get"},
+		},
+		{
+			// Immutable fields of the CSIDriver changed, it is re-created
+			name:     "field change",
+			manifest: makeFakeManifest(false, storagev1.NoneFSGroupPolicy),
+			initialObjects: testObjects{
+				csiDriver: getCSIDriver(true, storagev1.FileFSGroupPolicy),
+				driver:    makeFakeDriverInstance(),
+			},
+			expectedObjects: testObjects{
+				csiDriver: getCSIDriver(false, storagev1.NoneFSGroupPolicy),
+				driver: makeFakeDriverInstance(
+					withTrueConditions(conditionAvailable),
+					withFalseConditions(conditionProgressing)),
+			},
+			expectedActions: []string{"get", "delete", "create"},
+		},
+		{
+			// The old CSIDriver is still being deleted when it is re-created
+			name:     "field change while the old object is being deleted",
+			manifest: makeFakeManifest(false, storagev1.NoneFSGroupPolicy),
+			initialObjects: testObjects{
+				csiDriver: getCSIDriver(true, storagev1.FileFSGroupPolicy, withDeletionTimestamp()),
+				driver:    makeFakeDriverInstance(),
+			},
+			reactors: map[string]core.ReactionFunc{
+				// the object has a finalizer, the deletion is not finished
+				"delete": func(action core.Action) (bool, runtime.Object, error) {
+					return true, nil, nil
+				},
+			},
+			expectedObjects: testObjects{
+				csiDriver: getCSIDriver(true, storagev1.FileFSGroupPolicy, withDeletionTimestamp()),
+				driver: makeFakeDriverInstance(
+					withTrueConditions(conditionProgressing),
+					withFalseConditions(conditionAvailable)),
+			},
+			expectedReasons: map[string]string{
+				conditionAvailable:   RecreatingReason,
+				conditionProgressing: RecreatingReason,
+			},
+			expectedActions: []string{"get", "delete", "create", "get"},
+			expectErr:       true,
+		},
+		{
+			// Hooks modify the CSIDriver
+			name:     "hook",
+			manifest: makeFakeManifest(true, storagev1.FileFSGroupPolicy),
+			hooks:    []CSIDriverHookFunc{labelHook},
+			initialObjects: testObjects{
+				driver: makeFakeDriverInstance(),
+			},
+			expectedObjects: testObjects{
+				csiDriver: getCSIDriver(true, storagev1.FileFSGroupPolicy, withLabel("operator.openshift.io/foo", "bar")),
+				driver: makeFakeDriverInstance(
+					withTrueConditions(conditionAvailable),
+					withFalseConditions(conditionProgressing)),
+			},
+			expectedActions: []string{"get", "create"},
+		},
+		{
+			// Nothing is reconciled when the operator is not managed
+			name:     "unmanaged",
+			manifest: makeFakeManifest(true, storagev1.FileFSGroupPolicy),
+			initialObjects: testObjects{
+				driver: makeFakeDriverInstance(withManagementState(opv1.Unmanaged)),
+			},
+			expectedObjects: testObjects{
+				driver: makeFakeDriverInstance(withManagementState(opv1.Unmanaged)),
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			// Initialize
+			ctx := newTestContext(test, t)
+
+			// Act
+			recorder := events.NewInMemoryRecorder("test-csi-driver", clocktesting.NewFakePassiveClock(time.Now()))
+			err := ctx.controller.Sync(context.TODO(), factory.NewSyncContext(controllerName, recorder))
+
+			// Assert
+			// Check error
+			if err != nil && !test.expectErr {
+				t.Errorf("sync() returned unexpected error: %v", err)
+			}
+			if err == nil && test.expectErr {
+				t.Error("sync() unexpectedly succeeded when error was expected")
+			}
+
+			// Check actions
+			var verbs []string
+			for _, action := range ctx.coreClient.Actions() {
+				if action.GetResource().Resource == "csidrivers" && action.GetVerb() != "list" && action.GetVerb() != "watch" {
+					verbs = append(verbs, action.GetVerb())
+				}
+			}
+			if !equality.Semantic.DeepEqual(test.expectedActions, verbs) {
+				t.Errorf("Unexpected CSIDriver actions:\n%s", cmp.Diff(test.expectedActions, verbs))
+			}
+
+			// Check expectedObjects.csiDriver
+			if test.expectedObjects.csiDriver != nil {
+				actualCSIDriver, err := ctx.coreClient.StorageV1().CSIDrivers().Get(context.TODO(), csiDriverName, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("Failed to get CSIDriver %s: %v", csiDriverName, err)
+				}
+				sanitizeCSIDriver(actualCSIDriver)
+				sanitizeCSIDriver(test.expectedObjects.csiDriver)
+				if !equality.Semantic.DeepEqual(test.expectedObjects.csiDriver.Spec, actualCSIDriver.Spec) {
+					t.Errorf("Unexpected CSIDriver %s spec:\n%s", csiDriverName, cmp.Diff(test.expectedObjects.csiDriver.Spec, actualCSIDriver.Spec))
+				}
+				if !equality.Semantic.DeepEqual(test.expectedObjects.csiDriver.Labels, actualCSIDriver.Labels) {
+					t.Errorf("Unexpected CSIDriver %s labels:\n%s", csiDriverName, cmp.Diff(test.expectedObjects.csiDriver.Labels, actualCSIDriver.Labels))
+				}
+			}
+
+			// Check expectedObjects.driver.Status
+			if test.expectedObjects.driver != nil {
+				_, actualStatus, _, err := ctx.operatorClient.GetOperatorState()
+				if err != nil {
+					t.Errorf("Failed to get Driver: %v", err)
+				}
+				for conditionType, expectedReason := range test.expectedReasons {
+					condition := v1helpers.FindOperatorCondition(actualStatus.Conditions, conditionType)
+					if condition == nil {
+						t.Errorf("Condition %s not found", conditionType)
+					} else if condition.Reason != expectedReason {
+						t.Errorf("Expected condition %s to have reason %q, got %q", conditionType, expectedReason, condition.Reason)
+					}
+				}
+				sanitizeInstanceStatus(actualStatus)
+				sanitizeInstanceStatus(&test.expectedObjects.driver.Status)
+				if !equality.Semantic.DeepEqual(test.expectedObjects.driver.Status, *actualStatus) {
+					t.Errorf("Unexpected Driver content:\n%s", cmp.Diff(test.expectedObjects.driver.Status, *actualStatus))
+				}
+			}
+		})
+	}
+}
+
+func sanitizeCSIDriver(csiDriver *storagev1.CSIDriver) {
+	// nil and empty array are the same
+	if len(csiDriver.Labels) == 0 {
+		csiDriver.Labels = nil
+	}
+	// Remove random annotations set by ApplyCSIDriver
+	delete(csiDriver.Annotations, specHashAnnotation)
+}
+
+func sanitizeInstanceStatus(status *opv1.OperatorStatus) {
+	// Remove condition texts
+	for i := range status.Conditions {
+		status.Conditions[i].LastTransitionTime = metav1.Time{}
+		status.Conditions[i].Message = ""
+		status.Conditions[i].Reason = ""
+	}
+	// Sort the conditions by name to have consistent position in the array
+	sort.Slice(status.Conditions, func(i, j int) bool {
+		return status.Conditions[i].Type < status.Conditions[j].Type
+	})
+	if len(status.Conditions) == 0 {
+		status.Conditions = nil
+	}
+}
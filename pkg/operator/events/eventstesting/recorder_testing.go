@@ -44,6 +44,10 @@ func (r *TestingEventRecorder) Eventf(reason, messageFmt string, args ...interfa
 	r.Event(reason, fmt.Sprintf(messageFmt, args...))
 }
 
+func (r *TestingEventRecorder) EventfWithFields(reason string, fields map[string]string, messageFmt string, args ...interface{}) {
+	r.t.Logf("Event: %v: %v %v", reason, fmt.Sprintf(messageFmt, args...), fields)
+}
+
 func (r *TestingEventRecorder) Warning(reason, message string) {
 	r.t.Logf("Warning: %v: %v", reason, message)
 }
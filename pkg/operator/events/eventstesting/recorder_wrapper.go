@@ -35,6 +35,11 @@ func (e *EventRecorder) Eventf(reason, messageFmt string, args ...interface{}) {
 	e.testingEventRecorder.Eventf(reason, messageFmt, args...)
 }
 
+func (e *EventRecorder) EventfWithFields(reason string, fields map[string]string, messageFmt string, args ...interface{}) {
+	events.EventfWithFields(e.realEventRecorder, reason, fields, messageFmt, args...)
+	e.testingEventRecorder.EventfWithFields(reason, fields, messageFmt, args...)
+}
+
 func (e *EventRecorder) Warning(reason, message string) {
 	e.realEventRecorder.Warning(reason, message)
 	e.testingEventRecorder.Warning(reason, message)
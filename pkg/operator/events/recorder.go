@@ -40,6 +40,23 @@ type Recorder interface {
 	Shutdown()
 }
 
+// FieldsRecorder is implemented by recorders able to attach structured key/value fields to the events they emit.
+// The fields are stored as annotations of the Event object, so the keys must be valid annotation keys.
+type FieldsRecorder interface {
+	// EventfWithFields emits the normal type event with the given fields and allows formatting of message.
+	EventfWithFields(reason string, fields map[string]string, messageFmt string, args ...interface{})
+}
+
+// EventfWithFields emits the normal type event with the given fields when the recorder implements FieldsRecorder,
+// otherwise the event is emitted without the fields.
+func EventfWithFields(recorder Recorder, reason string, fields map[string]string, messageFmt string, args ...interface{}) {
+	if fieldsRecorder, ok := recorder.(FieldsRecorder); ok {
+		fieldsRecorder.EventfWithFields(reason, fields, messageFmt, args...)
+		return
+	}
+	recorder.Eventf(reason, messageFmt, args...)
+}
+
 // podNameEnv is a name of environment variable inside container that specifies the name of the current replica set.
 // This replica set name is then used as a source/involved object for operator events.
 const podNameEnv = "POD_NAME"
@@ -196,21 +213,24 @@ func (r *recorder) Warningf(reason, messageFmt string, args ...interface{}) {
 	r.Warning(reason, fmt.Sprintf(messageFmt, args...))
 }
 
+// EventfWithFields emits the normal type event with the fields as annotations and allow formatting of message.
+func (r *recorder) EventfWithFields(reason string, fields map[string]string, messageFmt string, args ...interface{}) {
+	event := makeEvent(r.clock, r.involvedObjectRef, r.sourceComponent, corev1.EventTypeNormal, reason, fmt.Sprintf(messageFmt, args...))
+	setEventFields(event, fields)
+	r.create(event)
+}
+
 // Event emits the normal type event.
 func (r *recorder) Event(reason, message string) {
-	event := makeEvent(r.clock, r.involvedObjectRef, r.sourceComponent, corev1.EventTypeNormal, reason, message)
-	ctx := context.Background()
-	if r.ctx != nil {
-		ctx = r.ctx
-	}
-	if _, err := r.eventClient.Create(ctx, event, metav1.CreateOptions{}); err != nil {
-		klog.Warningf("Error creating event %+v: %v", event, err)
-	}
+	r.create(makeEvent(r.clock, r.involvedObjectRef, r.sourceComponent, corev1.EventTypeNormal, reason, message))
 }
 
 // Warning emits the warning type event.
 func (r *recorder) Warning(reason, message string) {
-	event := makeEvent(r.clock, r.involvedObjectRef, r.sourceComponent, corev1.EventTypeWarning, reason, message)
+	r.create(makeEvent(r.clock, r.involvedObjectRef, r.sourceComponent, corev1.EventTypeWarning, reason, message))
+}
+
+func (r *recorder) create(event *corev1.Event) {
 	ctx := context.Background()
 	if r.ctx != nil {
 		ctx = r.ctx
@@ -240,6 +260,19 @@ func makeEvent(clock clock.PassiveClock, involvedObjRef *corev1.ObjectReference,
 	return event
 }
 
+// setEventFields stores the fields as annotations of the event.
+func setEventFields(event *corev1.Event, fields map[string]string) {
+	if len(fields) == 0 {
+		return
+	}
+	if event.Annotations == nil {
+		event.Annotations = make(map[string]string, len(fields))
+	}
+	for k, v := range fields {
+		event.Annotations[k] = v
+	}
+}
+
 func hashForEventNameSuffix(in ...string) string {
 	data := []byte{}
 	for _, curr := range in {
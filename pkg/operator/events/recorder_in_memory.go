@@ -79,6 +79,15 @@ func (r *inMemoryEventRecorder) Eventf(reason, messageFmt string, args ...interf
 	r.Event(reason, fmt.Sprintf(messageFmt, args...))
 }
 
+// EventfWithFields records the normal type event with the fields stored as annotations.
+func (r *inMemoryEventRecorder) EventfWithFields(reason string, fields map[string]string, messageFmt string, args ...interface{}) {
+	r.Lock()
+	defer r.Unlock()
+	event := makeEvent(r.clock, &inMemoryDummyObjectReference, r.source, corev1.EventTypeNormal, reason, fmt.Sprintf(messageFmt, args...))
+	setEventFields(event, fields)
+	r.events = append(r.events, event)
+}
+
 func (r *inMemoryEventRecorder) Warning(reason, message string) {
 	r.Lock()
 	defer r.Unlock()
@@ -53,6 +53,12 @@ func (r *LoggingEventRecorder) Eventf(reason, messageFmt string, args ...interfa
 	r.Event(reason, fmt.Sprintf(messageFmt, args...))
 }
 
+func (r *LoggingEventRecorder) EventfWithFields(reason string, fields map[string]string, messageFmt string, args ...interface{}) {
+	event := makeEvent(r.clock, &inMemoryDummyObjectReference, "", corev1.EventTypeNormal, reason, fmt.Sprintf(messageFmt, args...))
+	setEventFields(event, fields)
+	klog.Info(event.String())
+}
+
 func (r *LoggingEventRecorder) Warning(reason, message string) {
 	event := makeEvent(r.clock, &inMemoryDummyObjectReference, "", corev1.EventTypeWarning, reason, message)
 	klog.Warning(event.String())
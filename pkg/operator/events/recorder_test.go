@@ -2,6 +2,7 @@ package events
 
 import (
 	"context"
+	"fmt"
 	clocktesting "k8s.io/utils/clock/testing"
 	"testing"
 	"time"
@@ -100,6 +101,66 @@ func TestRecorder(t *testing.T) {
 	}
 }
 
+func TestRecorderEventfWithFields(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	r := NewRecorder(client.CoreV1().Events("test-namespace"), "test-operator", fakeControllerRef(t), clocktesting.NewFakePassiveClock(time.Now()))
+
+	EventfWithFields(r, "TestReason", map[string]string{"operator.openshift.io/resource": "secrets/foo"}, "updated %s", "foo")
+
+	var createdEvent *corev1.Event
+	for _, action := range client.Actions() {
+		if action.Matches("create", "events") {
+			createdEvent = action.(clientgotesting.CreateAction).GetObject().(*corev1.Event)
+			break
+		}
+	}
+	if createdEvent == nil {
+		t.Fatalf("expected event to be created")
+	}
+	if createdEvent.Message != "updated foo" || createdEvent.Type != corev1.EventTypeNormal {
+		t.Errorf("unexpected event: %v", createdEvent)
+	}
+	if createdEvent.Annotations["operator.openshift.io/resource"] != "secrets/foo" {
+		t.Errorf("expected the fields as annotations, got %v", createdEvent.Annotations)
+	}
+}
+
+func TestInMemoryRecorderEventfWithFields(t *testing.T) {
+	r := NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+	EventfWithFields(r, "TestReason", map[string]string{"key": "value"}, "message %d", 1)
+	r.Eventf("OtherReason", "message %d", 2)
+
+	events := r.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Reason != "TestReason" || events[0].Message != "message 1" || events[0].Annotations["key"] != "value" {
+		t.Errorf("unexpected event: %v", events[0])
+	}
+	if len(events[1].Annotations) != 0 {
+		t.Errorf("expected no fields, got %v", events[1].Annotations)
+	}
+}
+
+// eventfRecorder is a recorder not implementing FieldsRecorder.
+type eventfRecorder struct {
+	Recorder
+	messages []string
+}
+
+func (r *eventfRecorder) Eventf(reason, messageFmt string, args ...interface{}) {
+	r.messages = append(r.messages, reason+": "+fmt.Sprintf(messageFmt, args...))
+}
+
+func TestEventfWithFieldsFallback(t *testing.T) {
+	r := &eventfRecorder{}
+	EventfWithFields(r, "TestReason", map[string]string{"key": "value"}, "message %d", 1)
+	if len(r.messages) != 1 || r.messages[0] != "TestReason: message 1" {
+		t.Errorf("expected the event without fields, got %v", r.messages)
+	}
+}
+
 func TestGetControllerReferenceForCurrentPodIsPod(t *testing.T) {
 	pod := fakePod("test", "test")
 	pod.OwnerReferences = []metav1.OwnerReference{}
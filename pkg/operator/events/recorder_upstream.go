@@ -161,6 +161,18 @@ func (r *upstreamRecorder) Event(reason, message string) {
 	r.eventRecorder.Event(r.involvedObjectRef, corev1.EventTypeNormal, reason, message)
 }
 
+// EventfWithFields emits the normal type event with the fields as annotations and allow formatting of message.
+func (r *upstreamRecorder) EventfWithFields(reason string, fields map[string]string, messageFmt string, args ...interface{}) {
+	r.shutdownMutex.RLock()
+	defer r.shutdownMutex.RUnlock()
+	defer r.incrementEventsCounter(corev1.EventTypeNormal)
+	if r.shuttingDown {
+		EventfWithFields(r.fallbackRecorder, reason, fields, messageFmt, args...)
+		return
+	}
+	r.eventRecorder.AnnotatedEventf(r.involvedObjectRef, fields, corev1.EventTypeNormal, reason, messageFmt, args...)
+}
+
 // Warning emits the warning type event.
 func (r *upstreamRecorder) Warning(reason, message string) {
 	r.shutdownMutex.RLock()
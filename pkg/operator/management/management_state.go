@@ -4,9 +4,19 @@ import (
 	v1 "github.com/openshift/api/operator/v1"
 )
 
+const (
+	// defaultRemovableReason is the reason of the removable state until it is set.
+	defaultRemovableReason = "operators are removable by default"
+	// setRemovableReason and setNotRemovableReason are the reasons recorded by SetOperatorRemovable and
+	// SetOperatorNotRemovable.
+	setRemovableReason    = "the operator was set removable"
+	setNotRemovableReason = "the operator was set not removable"
+)
+
 var (
 	allowOperatorUnmanagedState = true
 	allowOperatorRemovedState   = true
+	operatorRemovableReason     = defaultRemovableReason
 )
 
 // SetOperatorAlwaysManaged is one time choice when an operator want to opt-out from supporting the "unmanaged" state.
@@ -26,14 +36,34 @@ func SetOperatorUnmanageable() {
 // removing of his operand. This makes sense for operators like kube-apiserver where removing operand will lead to a
 // bricked, non-automatically recoverable state.
 func SetOperatorNotRemovable() {
+	SetOperatorNotRemovableWithReason(setNotRemovableReason)
+}
+
+// SetOperatorNotRemovableWithReason is SetOperatorNotRemovable recording why the operator does not support removing
+// of his operand. The reason is returned by RemovableState.
+func SetOperatorNotRemovableWithReason(reason string) {
 	allowOperatorRemovedState = false
+	operatorRemovableReason = reason
 }
 
 // SetOperatorRemovable is one time choice the operator author can make to indicate the operator supports
 // removing of his operand.
 // This is the default setting, provided here mostly for unit tests.
 func SetOperatorRemovable() {
+	SetOperatorRemovableWithReason(setRemovableReason)
+}
+
+// SetOperatorRemovableWithReason is SetOperatorRemovable recording why the operator supports removing of his operand.
+// The reason is returned by RemovableState.
+func SetOperatorRemovableWithReason(reason string) {
 	allowOperatorRemovedState = true
+	operatorRemovableReason = reason
+}
+
+// RemovableState returns whether the operator can be set to removed state, and the reason recorded when this was
+// last set. Controllers can use the reason to explain why their removal logic, like finalizers, is or is not active.
+func RemovableState() (removable bool, reason string) {
+	return allowOperatorRemovedState, operatorRemovableReason
 }
 
 // IsOperatorAlwaysManaged means the operator can't be set to unmanaged state.
@@ -0,0 +1,51 @@
+package management
+
+import "testing"
+
+func TestRemovableState(t *testing.T) {
+	defer SetOperatorRemovable()
+
+	tests := []struct {
+		name              string
+		set               func()
+		expectedRemovable bool
+		expectedReason    string
+	}{
+		{
+			name:              "not removable",
+			set:               SetOperatorNotRemovable,
+			expectedRemovable: false,
+			expectedReason:    setNotRemovableReason,
+		},
+		{
+			name:              "removable",
+			set:               SetOperatorRemovable,
+			expectedRemovable: true,
+			expectedReason:    setRemovableReason,
+		},
+		{
+			name:              "not removable with a reason",
+			set:               func() { SetOperatorNotRemovableWithReason("the operand is required by the cluster") },
+			expectedRemovable: false,
+			expectedReason:    "the operand is required by the cluster",
+		},
+		{
+			name:              "removable with a reason",
+			set:               func() { SetOperatorRemovableWithReason("the operand is optional") },
+			expectedRemovable: true,
+			expectedReason:    "the operand is optional",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.set()
+			removable, reason := RemovableState()
+			if removable != tc.expectedRemovable || reason != tc.expectedReason {
+				t.Errorf("expected removable %v with reason %q, got %v with reason %q", tc.expectedRemovable, tc.expectedReason, removable, reason)
+			}
+			if IsOperatorRemovable() != tc.expectedRemovable {
+				t.Errorf("expected IsOperatorRemovable to return %v", tc.expectedRemovable)
+			}
+		})
+	}
+}
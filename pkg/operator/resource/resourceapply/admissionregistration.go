@@ -23,6 +23,10 @@ import (
 // mutatingwebhookconfiguration will be merged with the existing mutatingwebhookconfiguration
 // and an update performed if the mutatingwebhookconfiguration spec and metadata differ from
 // the previously required spec and metadata based on generation change.
+//
+// The clientConfig.caBundle, namespaceSelector and matchConditions of a webhook are owned by the
+// operator only when they are set in the required webhook. When they are unset, the values of the
+// existing webhook are preserved, so that they can be injected and managed by other controllers.
 func ApplyMutatingWebhookConfigurationImproved(ctx context.Context, client admissionregistrationclientv1.MutatingWebhookConfigurationsGetter, recorder events.Recorder,
 	requiredOriginal *admissionregistrationv1.MutatingWebhookConfiguration, cache ResourceCache) (*admissionregistrationv1.MutatingWebhookConfiguration, bool, error) {
 
@@ -56,6 +60,7 @@ func ApplyMutatingWebhookConfigurationImproved(ctx context.Context, client admis
 
 	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
 	copyMutatingWebhookCABundle(existing, required)
+	copyMutatingWebhookSelectors(existing, required)
 	webhooksEquivalent := equality.Semantic.DeepEqual(existingCopy.Webhooks, required.Webhooks)
 	if webhooksEquivalent && !modified {
 		// need to store the original so that the early comparison of hashes is done based on the original, not a mutated copy
@@ -78,6 +83,33 @@ func ApplyMutatingWebhookConfigurationImproved(ctx context.Context, client admis
 	return actual, true, nil
 }
 
+// copyMutatingWebhookSelectors populates webhooks[].namespaceSelector, webhooks[].objectSelector and
+// webhooks[].matchConditions fields from existing resource if they are nil in present. This preserves selectors
+// and match conditions injected by other controllers. A field set in present, even to an empty selector or an
+// empty list, is owned by the caller and replaces the existing value.
+func copyMutatingWebhookSelectors(from, to *admissionregistrationv1.MutatingWebhookConfiguration) {
+	fromMap := make(map[string]admissionregistrationv1.MutatingWebhook, len(from.Webhooks))
+	for _, webhook := range from.Webhooks {
+		fromMap[webhook.Name] = webhook
+	}
+
+	for i, wh := range to.Webhooks {
+		existing, ok := fromMap[wh.Name]
+		if !ok {
+			continue
+		}
+		if wh.NamespaceSelector == nil {
+			to.Webhooks[i].NamespaceSelector = existing.NamespaceSelector
+		}
+		if wh.ObjectSelector == nil {
+			to.Webhooks[i].ObjectSelector = existing.ObjectSelector
+		}
+		if wh.MatchConditions == nil {
+			to.Webhooks[i].MatchConditions = existing.MatchConditions
+		}
+	}
+}
+
 // copyMutatingWebhookCABundle populates webhooks[].clientConfig.caBundle fields from existing resource if it was set before
 // and is not set in present. This provides upgrade compatibility with service-ca-bundle operator.
 func copyMutatingWebhookCABundle(from, to *admissionregistrationv1.MutatingWebhookConfiguration) {
@@ -99,6 +131,10 @@ func copyMutatingWebhookCABundle(from, to *admissionregistrationv1.MutatingWebho
 // validatingwebhookconfiguration will be merged with the existing validatingwebhookconfiguration
 // and an update performed if the validatingwebhookconfiguration spec and metadata differ from
 // the previously required spec and metadata based on generation change.
+//
+// The clientConfig.caBundle, namespaceSelector and matchConditions of a webhook are owned by the
+// operator only when they are set in the required webhook. When they are unset, the values of the
+// existing webhook are preserved, so that they can be injected and managed by other controllers.
 func ApplyValidatingWebhookConfigurationImproved(ctx context.Context, client admissionregistrationclientv1.ValidatingWebhookConfigurationsGetter, recorder events.Recorder,
 	requiredOriginal *admissionregistrationv1.ValidatingWebhookConfiguration, cache ResourceCache) (*admissionregistrationv1.ValidatingWebhookConfiguration, bool, error) {
 	if requiredOriginal == nil {
@@ -131,6 +167,7 @@ func ApplyValidatingWebhookConfigurationImproved(ctx context.Context, client adm
 
 	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
 	copyValidatingWebhookCABundle(existing, required)
+	copyValidatingWebhookSelectors(existing, required)
 	webhooksEquivalent := equality.Semantic.DeepEqual(existingCopy.Webhooks, required.Webhooks)
 	if webhooksEquivalent && !modified {
 		// need to store the original so that the early comparison of hashes is done based on the original, not a mutated copy
@@ -165,6 +202,33 @@ func DeleteValidatingWebhookConfiguration(ctx context.Context, client admissionr
 	return nil, true, nil
 }
 
+// copyValidatingWebhookSelectors populates webhooks[].namespaceSelector, webhooks[].objectSelector and
+// webhooks[].matchConditions fields from existing resource if they are nil in present. This preserves selectors
+// and match conditions injected by other controllers. A field set in present, even to an empty selector or an
+// empty list, is owned by the caller and replaces the existing value.
+func copyValidatingWebhookSelectors(from, to *admissionregistrationv1.ValidatingWebhookConfiguration) {
+	fromMap := make(map[string]admissionregistrationv1.ValidatingWebhook, len(from.Webhooks))
+	for _, webhook := range from.Webhooks {
+		fromMap[webhook.Name] = webhook
+	}
+
+	for i, wh := range to.Webhooks {
+		existing, ok := fromMap[wh.Name]
+		if !ok {
+			continue
+		}
+		if wh.NamespaceSelector == nil {
+			to.Webhooks[i].NamespaceSelector = existing.NamespaceSelector
+		}
+		if wh.ObjectSelector == nil {
+			to.Webhooks[i].ObjectSelector = existing.ObjectSelector
+		}
+		if wh.MatchConditions == nil {
+			to.Webhooks[i].MatchConditions = existing.MatchConditions
+		}
+	}
+}
+
 // copyValidatingWebhookCABundle populates webhooks[].clientConfig.caBundle fields from existing resource if it was set before
 // and is not set in present. This provides upgrade compatibility with service-ca-bundle operator.
 func copyValidatingWebhookCABundle(from, to *admissionregistrationv1.ValidatingWebhookConfiguration) {
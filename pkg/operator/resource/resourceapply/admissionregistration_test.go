@@ -12,6 +12,7 @@ import (
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 	ktesting "k8s.io/client-go/testing"
@@ -158,6 +159,114 @@ func TestApplyMutatingConfiguration(t *testing.T) {
 			},
 			expectedEvents: []string{updateEvent},
 		},
+		{
+			name:           "Should update webhook rules, but preserve selectors and matchConditions if they are not set",
+			expectModified: true,
+			input: func() *admissionregistrationv1.MutatingWebhookConfiguration {
+				hook := defaultHook.DeepCopy()
+				hook.Webhooks = append(hook.Webhooks, admissionregistrationv1.MutatingWebhook{
+					Name:  "test",
+					Rules: []admissionregistrationv1.RuleWithOperations{{Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update}}},
+				})
+				return hook
+			},
+			existing: func() *admissionregistrationv1.MutatingWebhookConfiguration {
+				hook := defaultHook.DeepCopy()
+				hook.Webhooks = append(hook.Webhooks, admissionregistrationv1.MutatingWebhook{
+					Name:              "test",
+					Rules:             []admissionregistrationv1.RuleWithOperations{{Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create}}},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"injected": "true"}},
+					ObjectSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"injected": "true"}},
+					MatchConditions:   []admissionregistrationv1.MatchCondition{{Name: "injected", Expression: "true"}},
+				})
+				return hook
+			},
+			checkUpdated: func(hook *admissionregistrationv1.MutatingWebhookConfiguration) error {
+				if len(hook.Webhooks) != 1 {
+					return fmt.Errorf("Expected to find a single webhook, got: %+v", hook.Webhooks)
+				}
+				webhook := hook.Webhooks[0]
+				if len(webhook.Rules) != 1 || len(webhook.Rules[0].Operations) != 2 {
+					return fmt.Errorf("Expected the rules to be reconciled, got: %+v", webhook.Rules)
+				}
+				if webhook.NamespaceSelector == nil || webhook.NamespaceSelector.MatchLabels["injected"] != "true" {
+					return fmt.Errorf("Expected the injected namespaceSelector to be preserved, got: %+v", webhook.NamespaceSelector)
+				}
+				if webhook.ObjectSelector == nil || webhook.ObjectSelector.MatchLabels["injected"] != "true" {
+					return fmt.Errorf("Expected the injected objectSelector to be preserved, got: %+v", webhook.ObjectSelector)
+				}
+				if len(webhook.MatchConditions) != 1 || webhook.MatchConditions[0].Name != "injected" {
+					return fmt.Errorf("Expected the injected matchConditions to be preserved, got: %+v", webhook.MatchConditions)
+				}
+				return nil
+			},
+			expectedEvents: []string{updateEvent},
+		},
+		{
+			name:           "Should update webhook, and force namespaceSelector field if is set",
+			expectModified: true,
+			input: func() *admissionregistrationv1.MutatingWebhookConfiguration {
+				hook := defaultHook.DeepCopy()
+				hook.Webhooks = append(hook.Webhooks, admissionregistrationv1.MutatingWebhook{
+					Name:              "test",
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"owned": "true"}},
+				})
+				return hook
+			},
+			existing: func() *admissionregistrationv1.MutatingWebhookConfiguration {
+				hook := defaultHook.DeepCopy()
+				hook.Webhooks = append(hook.Webhooks, admissionregistrationv1.MutatingWebhook{
+					Name:              "test",
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"injected": "true"}},
+				})
+				return hook
+			},
+			checkUpdated: func(hook *admissionregistrationv1.MutatingWebhookConfiguration) error {
+				if selector := hook.Webhooks[0].NamespaceSelector; selector == nil || selector.MatchLabels["owned"] != "true" || len(selector.MatchLabels) != 1 {
+					return fmt.Errorf("Expected the required namespaceSelector to be set, got: %+v", selector)
+				}
+				return nil
+			},
+			expectedEvents: []string{updateEvent},
+		},
+		{
+			name:           "Should update webhook, and remove injected selectors if they are set empty",
+			expectModified: true,
+			input: func() *admissionregistrationv1.MutatingWebhookConfiguration {
+				hook := defaultHook.DeepCopy()
+				hook.Webhooks = append(hook.Webhooks, admissionregistrationv1.MutatingWebhook{
+					Name:              "test",
+					NamespaceSelector: &metav1.LabelSelector{},
+					ObjectSelector:    &metav1.LabelSelector{},
+					MatchConditions:   []admissionregistrationv1.MatchCondition{},
+				})
+				return hook
+			},
+			existing: func() *admissionregistrationv1.MutatingWebhookConfiguration {
+				hook := defaultHook.DeepCopy()
+				hook.Webhooks = append(hook.Webhooks, admissionregistrationv1.MutatingWebhook{
+					Name:              "test",
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"injected": "true"}},
+					ObjectSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"injected": "true"}},
+					MatchConditions:   []admissionregistrationv1.MatchCondition{{Name: "injected", Expression: "true"}},
+				})
+				return hook
+			},
+			checkUpdated: func(hook *admissionregistrationv1.MutatingWebhookConfiguration) error {
+				webhook := hook.Webhooks[0]
+				if webhook.NamespaceSelector == nil || len(webhook.NamespaceSelector.MatchLabels) != 0 {
+					return fmt.Errorf("Expected the namespaceSelector to be empty, got: %+v", webhook.NamespaceSelector)
+				}
+				if webhook.ObjectSelector == nil || len(webhook.ObjectSelector.MatchLabels) != 0 {
+					return fmt.Errorf("Expected the objectSelector to be empty, got: %+v", webhook.ObjectSelector)
+				}
+				if len(webhook.MatchConditions) != 0 {
+					return fmt.Errorf("Expected the matchConditions to be removed, got: %+v", webhook.MatchConditions)
+				}
+				return nil
+			},
+			expectedEvents: []string{updateEvent},
+		},
 	}
 
 	for _, test := range tests {
@@ -342,6 +451,114 @@ func TestApplyValidatingConfiguration(t *testing.T) {
 			},
 			expectedEvents: []string{updateEvent},
 		},
+		{
+			name:           "Should update webhook rules, but preserve selectors and matchConditions if they are not set",
+			expectModified: true,
+			input: func() *admissionregistrationv1.ValidatingWebhookConfiguration {
+				hook := defaultHook.DeepCopy()
+				hook.Webhooks = append(hook.Webhooks, admissionregistrationv1.ValidatingWebhook{
+					Name:  "test",
+					Rules: []admissionregistrationv1.RuleWithOperations{{Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update}}},
+				})
+				return hook
+			},
+			existing: func() *admissionregistrationv1.ValidatingWebhookConfiguration {
+				hook := defaultHook.DeepCopy()
+				hook.Webhooks = append(hook.Webhooks, admissionregistrationv1.ValidatingWebhook{
+					Name:              "test",
+					Rules:             []admissionregistrationv1.RuleWithOperations{{Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create}}},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"injected": "true"}},
+					ObjectSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"injected": "true"}},
+					MatchConditions:   []admissionregistrationv1.MatchCondition{{Name: "injected", Expression: "true"}},
+				})
+				return hook
+			},
+			checkUpdated: func(hook *admissionregistrationv1.ValidatingWebhookConfiguration) error {
+				if len(hook.Webhooks) != 1 {
+					return fmt.Errorf("Expected to find a single webhook, got: %+v", hook.Webhooks)
+				}
+				webhook := hook.Webhooks[0]
+				if len(webhook.Rules) != 1 || len(webhook.Rules[0].Operations) != 2 {
+					return fmt.Errorf("Expected the rules to be reconciled, got: %+v", webhook.Rules)
+				}
+				if webhook.NamespaceSelector == nil || webhook.NamespaceSelector.MatchLabels["injected"] != "true" {
+					return fmt.Errorf("Expected the injected namespaceSelector to be preserved, got: %+v", webhook.NamespaceSelector)
+				}
+				if webhook.ObjectSelector == nil || webhook.ObjectSelector.MatchLabels["injected"] != "true" {
+					return fmt.Errorf("Expected the injected objectSelector to be preserved, got: %+v", webhook.ObjectSelector)
+				}
+				if len(webhook.MatchConditions) != 1 || webhook.MatchConditions[0].Name != "injected" {
+					return fmt.Errorf("Expected the injected matchConditions to be preserved, got: %+v", webhook.MatchConditions)
+				}
+				return nil
+			},
+			expectedEvents: []string{updateEvent},
+		},
+		{
+			name:           "Should update webhook, and force namespaceSelector field if is set",
+			expectModified: true,
+			input: func() *admissionregistrationv1.ValidatingWebhookConfiguration {
+				hook := defaultHook.DeepCopy()
+				hook.Webhooks = append(hook.Webhooks, admissionregistrationv1.ValidatingWebhook{
+					Name:              "test",
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"owned": "true"}},
+				})
+				return hook
+			},
+			existing: func() *admissionregistrationv1.ValidatingWebhookConfiguration {
+				hook := defaultHook.DeepCopy()
+				hook.Webhooks = append(hook.Webhooks, admissionregistrationv1.ValidatingWebhook{
+					Name:              "test",
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"injected": "true"}},
+				})
+				return hook
+			},
+			checkUpdated: func(hook *admissionregistrationv1.ValidatingWebhookConfiguration) error {
+				if selector := hook.Webhooks[0].NamespaceSelector; selector == nil || selector.MatchLabels["owned"] != "true" || len(selector.MatchLabels) != 1 {
+					return fmt.Errorf("Expected the required namespaceSelector to be set, got: %+v", selector)
+				}
+				return nil
+			},
+			expectedEvents: []string{updateEvent},
+		},
+		{
+			name:           "Should update webhook, and remove injected selectors if they are set empty",
+			expectModified: true,
+			input: func() *admissionregistrationv1.ValidatingWebhookConfiguration {
+				hook := defaultHook.DeepCopy()
+				hook.Webhooks = append(hook.Webhooks, admissionregistrationv1.ValidatingWebhook{
+					Name:              "test",
+					NamespaceSelector: &metav1.LabelSelector{},
+					ObjectSelector:    &metav1.LabelSelector{},
+					MatchConditions:   []admissionregistrationv1.MatchCondition{},
+				})
+				return hook
+			},
+			existing: func() *admissionregistrationv1.ValidatingWebhookConfiguration {
+				hook := defaultHook.DeepCopy()
+				hook.Webhooks = append(hook.Webhooks, admissionregistrationv1.ValidatingWebhook{
+					Name:              "test",
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"injected": "true"}},
+					ObjectSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"injected": "true"}},
+					MatchConditions:   []admissionregistrationv1.MatchCondition{{Name: "injected", Expression: "true"}},
+				})
+				return hook
+			},
+			checkUpdated: func(hook *admissionregistrationv1.ValidatingWebhookConfiguration) error {
+				webhook := hook.Webhooks[0]
+				if webhook.NamespaceSelector == nil || len(webhook.NamespaceSelector.MatchLabels) != 0 {
+					return fmt.Errorf("Expected the namespaceSelector to be empty, got: %+v", webhook.NamespaceSelector)
+				}
+				if webhook.ObjectSelector == nil || len(webhook.ObjectSelector.MatchLabels) != 0 {
+					return fmt.Errorf("Expected the objectSelector to be empty, got: %+v", webhook.ObjectSelector)
+				}
+				if len(webhook.MatchConditions) != 0 {
+					return fmt.Errorf("Expected the matchConditions to be removed, got: %+v", webhook.MatchConditions)
+				}
+				return nil
+			},
+			expectedEvents: []string{updateEvent},
+		},
 	}
 
 	for _, test := range tests {
@@ -562,6 +779,187 @@ func TestApplyValidatingAdmissionPolicyConfiguration(t *testing.T) {
 	}
 }
 
+func TestApplyValidatingAdmissionPolicyV1(t *testing.T) {
+	defaultPolicy := &admissionregistrationv1.ValidatingAdmissionPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "test",
+			Labels: map[string]string{"app": "test"},
+		},
+		Spec: admissionregistrationv1.ValidatingAdmissionPolicySpec{
+			Validations: []admissionregistrationv1.Validation{{Expression: "object.spec.replicas <= 5"}},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		expectModified bool
+		existing       func() *admissionregistrationv1.ValidatingAdmissionPolicy
+		input          func() *admissionregistrationv1.ValidatingAdmissionPolicy
+		checkUpdated   func(*admissionregistrationv1.ValidatingAdmissionPolicy) error
+		expectedEvents []string
+	}{
+		{
+			name:           "Should successfully create policy",
+			expectModified: true,
+			input: func() *admissionregistrationv1.ValidatingAdmissionPolicy {
+				return defaultPolicy.DeepCopy()
+			},
+			expectedEvents: []string{"ValidatingAdmissionPolicyCreated"},
+		},
+		{
+			name:           "Should not update policy when is unchanged",
+			expectModified: false,
+			input: func() *admissionregistrationv1.ValidatingAdmissionPolicy {
+				return defaultPolicy.DeepCopy()
+			},
+			existing: func() *admissionregistrationv1.ValidatingAdmissionPolicy {
+				policy := defaultPolicy.DeepCopy()
+				policy.Labels["extra"] = "label"
+				return policy
+			},
+		},
+		{
+			name:           "Should update policy when spec changed and keep extra labels",
+			expectModified: true,
+			input: func() *admissionregistrationv1.ValidatingAdmissionPolicy {
+				return defaultPolicy.DeepCopy()
+			},
+			existing: func() *admissionregistrationv1.ValidatingAdmissionPolicy {
+				policy := defaultPolicy.DeepCopy()
+				policy.Labels["extra"] = "label"
+				policy.Spec.Validations[0].Expression = "object.spec.replicas <= 10"
+				return policy
+			},
+			checkUpdated: func(policy *admissionregistrationv1.ValidatingAdmissionPolicy) error {
+				if policy.Spec.Validations[0].Expression != "object.spec.replicas <= 5" {
+					return fmt.Errorf("expected the validation to be updated, got %q", policy.Spec.Validations[0].Expression)
+				}
+				if policy.Labels["extra"] != "label" || policy.Labels["app"] != "test" {
+					return fmt.Errorf("expected the labels to be merged, got %v", policy.Labels)
+				}
+				return nil
+			},
+			expectedEvents: []string{"ValidatingAdmissionPolicyUpdated"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			existing := []runtime.Object{}
+			if test.existing != nil {
+				existing = append(existing, test.existing())
+			}
+			client := fake.NewSimpleClientset(existing...)
+			recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+			updatedPolicy, modified, err := ApplyValidatingAdmissionPolicyV1(context.TODO(), client.AdmissionregistrationV1(), recorder, test.input(), noCache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectModified != modified {
+				t.Errorf("expected modified to be equal %v, got %v: %#v", test.expectModified, modified, updatedPolicy)
+			}
+			if test.checkUpdated != nil {
+				if err = test.checkUpdated(updatedPolicy); err != nil {
+					t.Errorf("Expected modification: %v", err)
+				}
+			}
+			assertEvents(t, test.name, test.expectedEvents, recorder.Events())
+		})
+	}
+}
+
+func TestApplyValidatingAdmissionPolicyBindingV1(t *testing.T) {
+	defaultBinding := &admissionregistrationv1.ValidatingAdmissionPolicyBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "test",
+			Labels: map[string]string{"app": "test"},
+		},
+		Spec: admissionregistrationv1.ValidatingAdmissionPolicyBindingSpec{
+			PolicyName:        "test",
+			ValidationActions: []admissionregistrationv1.ValidationAction{admissionregistrationv1.Deny},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		expectModified bool
+		existing       func() *admissionregistrationv1.ValidatingAdmissionPolicyBinding
+		input          func() *admissionregistrationv1.ValidatingAdmissionPolicyBinding
+		checkUpdated   func(*admissionregistrationv1.ValidatingAdmissionPolicyBinding) error
+		expectedEvents []string
+	}{
+		{
+			name:           "Should successfully create binding",
+			expectModified: true,
+			input: func() *admissionregistrationv1.ValidatingAdmissionPolicyBinding {
+				return defaultBinding.DeepCopy()
+			},
+			expectedEvents: []string{"ValidatingAdmissionPolicyBindingCreated"},
+		},
+		{
+			name:           "Should not update binding when is unchanged",
+			expectModified: false,
+			input: func() *admissionregistrationv1.ValidatingAdmissionPolicyBinding {
+				return defaultBinding.DeepCopy()
+			},
+			existing: func() *admissionregistrationv1.ValidatingAdmissionPolicyBinding {
+				binding := defaultBinding.DeepCopy()
+				binding.Labels["extra"] = "label"
+				return binding
+			},
+		},
+		{
+			name:           "Should update binding when spec changed and keep extra labels",
+			expectModified: true,
+			input: func() *admissionregistrationv1.ValidatingAdmissionPolicyBinding {
+				return defaultBinding.DeepCopy()
+			},
+			existing: func() *admissionregistrationv1.ValidatingAdmissionPolicyBinding {
+				binding := defaultBinding.DeepCopy()
+				binding.Labels["extra"] = "label"
+				binding.Spec.ValidationActions = []admissionregistrationv1.ValidationAction{admissionregistrationv1.Warn}
+				return binding
+			},
+			checkUpdated: func(binding *admissionregistrationv1.ValidatingAdmissionPolicyBinding) error {
+				if len(binding.Spec.ValidationActions) != 1 || binding.Spec.ValidationActions[0] != admissionregistrationv1.Deny {
+					return fmt.Errorf("expected the validation actions to be updated, got %v", binding.Spec.ValidationActions)
+				}
+				if binding.Labels["extra"] != "label" || binding.Labels["app"] != "test" {
+					return fmt.Errorf("expected the labels to be merged, got %v", binding.Labels)
+				}
+				return nil
+			},
+			expectedEvents: []string{"ValidatingAdmissionPolicyBindingUpdated"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			existing := []runtime.Object{}
+			if test.existing != nil {
+				existing = append(existing, test.existing())
+			}
+			client := fake.NewSimpleClientset(existing...)
+			recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+			updatedBinding, modified, err := ApplyValidatingAdmissionPolicyBindingV1(context.TODO(), client.AdmissionregistrationV1(), recorder, test.input(), noCache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectModified != modified {
+				t.Errorf("expected modified to be equal %v, got %v: %#v", test.expectModified, modified, updatedBinding)
+			}
+			if test.checkUpdated != nil {
+				if err = test.checkUpdated(updatedBinding); err != nil {
+					t.Errorf("Expected modification: %v", err)
+				}
+			}
+			assertEvents(t, test.name, test.expectedEvents, recorder.Events())
+		})
+	}
+}
+
 func assertEvents(t *testing.T, testCase string, expectedReasons []string, events []*corev1.Event) {
 	if len(expectedReasons) != len(events) {
 		t.Errorf(
@@ -194,6 +194,30 @@ func ApplyDaemonSet(ctx context.Context, client appsclientv1.DaemonSetsGetter, r
 // ApplyDaemonSetWithForce merges objectmeta and requires matching generation. It returns the final Object, whether any change as made, and an error
 // DEPRECATED - This method will be removed in 4.6 and callers will need to migrate to ApplyDaemonSet before then.
 func ApplyDaemonSetWithForce(ctx context.Context, client appsclientv1.DaemonSetsGetter, recorder events.Recorder, requiredOriginal *appsv1.DaemonSet, expectedGeneration int64, forceRollout bool) (*appsv1.DaemonSet, bool, error) {
+	return applyDaemonSet(ctx, client, recorder, requiredOriginal, expectedGeneration, forceRollout, false)
+}
+
+// ApplyDaemonSetDryRun computes what ApplyDaemonSet would do without persisting any change. The daemonset is
+// read and merged like ApplyDaemonSet does, and the create or update is issued with metav1.DryRunAll. It returns
+// the object the server would store and whether ApplyDaemonSet would modify the daemonset. No events are recorded.
+func ApplyDaemonSetDryRun(ctx context.Context, client appsclientv1.DaemonSetsGetter, requiredOriginal *appsv1.DaemonSet, expectedGeneration int64) (*appsv1.DaemonSet, bool, error) {
+	required := requiredOriginal.DeepCopy()
+	err := SetSpecHashAnnotation(&required.ObjectMeta, required.Spec)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return applyDaemonSet(ctx, client, nil, required, expectedGeneration, false, true)
+}
+
+// applyDaemonSet implements ApplyDaemonSetWithForce. With dryRun, writes are issued with metav1.DryRunAll and no
+// events are recorded.
+func applyDaemonSet(ctx context.Context, client appsclientv1.DaemonSetsGetter, recorder events.Recorder, requiredOriginal *appsv1.DaemonSet, expectedGeneration int64, forceRollout, dryRun bool) (*appsv1.DaemonSet, bool, error) {
+	var dryRunOpts []string
+	if dryRun {
+		dryRunOpts = []string{metav1.DryRunAll}
+	}
+
 	required := requiredOriginal.DeepCopy()
 	if required.Annotations == nil {
 		required.Annotations = map[string]string{}
@@ -205,8 +229,10 @@ func ApplyDaemonSetWithForce(ctx context.Context, client appsclientv1.DaemonSets
 	}
 	existing, err := client.DaemonSets(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
-		actual, err := client.DaemonSets(required.Namespace).Create(ctx, required, metav1.CreateOptions{})
-		resourcehelper.ReportCreateEvent(recorder, required, err)
+		actual, err := client.DaemonSets(required.Namespace).Create(ctx, required, metav1.CreateOptions{DryRun: dryRunOpts})
+		if !dryRun {
+			resourcehelper.ReportCreateEvent(recorder, required, err)
+		}
 		return actual, true, err
 	}
 	if err != nil {
@@ -241,8 +267,10 @@ func ApplyDaemonSetWithForce(ctx context.Context, client appsclientv1.DaemonSets
 	if klog.V(2).Enabled() {
 		klog.Infof("DaemonSet %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, toWrite))
 	}
-	actual, err := client.DaemonSets(required.Namespace).Update(ctx, toWrite, metav1.UpdateOptions{})
-	resourcehelper.ReportUpdateEvent(recorder, required, err)
+	actual, err := client.DaemonSets(required.Namespace).Update(ctx, toWrite, metav1.UpdateOptions{DryRun: dryRunOpts})
+	if !dryRun {
+		resourcehelper.ReportUpdateEvent(recorder, required, err)
+	}
 	return actual, true, err
 }
 
@@ -10,8 +10,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/utils/ptr"
 
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -234,6 +236,96 @@ func TestDeleteDaemonSet(t *testing.T) {
 	}
 }
 
+func TestApplyDaemonSetDryRun(t *testing.T) {
+	ctx := context.TODO()
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	client := fake.NewSimpleClientset()
+	// the fake tracker persists dry-run writes, return the written object like the server does instead
+	var dryRunActions []clienttesting.Action
+	client.PrependReactor("*", "daemonsets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		switch a := action.(type) {
+		case clienttesting.CreateActionImpl:
+			if equality.Semantic.DeepEqual(a.CreateOptions.DryRun, []string{metav1.DryRunAll}) {
+				dryRunActions = append(dryRunActions, action)
+				return true, a.GetObject(), nil
+			}
+		case clienttesting.UpdateActionImpl:
+			if equality.Semantic.DeepEqual(a.UpdateOptions.DryRun, []string{metav1.DryRunAll}) {
+				dryRunActions = append(dryRunActions, action)
+				return true, a.GetObject(), nil
+			}
+		}
+		return false, nil, nil
+	})
+
+	// a missing daemonset would be created
+	actual, modified, err := resourceapply.ApplyDaemonSetDryRun(ctx, client.AppsV1(), daemonSet(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified || len(dryRunActions) != 1 || !dryRunActions[0].Matches("create", "daemonsets") {
+		t.Fatalf("expected a dry-run create, got modified=%v actions=%v", modified, dryRunActions)
+	}
+	if _, err := client.AppsV1().DaemonSets("openshift-apiserver").Get(ctx, "apiserver", metav1.GetOptions{}); err == nil {
+		t.Fatal("expected the dry-run create not to persist the daemonset")
+	}
+	if len(actual.Annotations["operator.openshift.io/spec-hash"]) == 0 {
+		t.Errorf("expected the spec hash annotation to be set")
+	}
+
+	if _, _, err := resourceapply.ApplyDaemonSet(ctx, client.AppsV1(), recorder, daemonSet(), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// an unchanged daemonset would not be modified
+	dryRunActions = nil
+	_, modified, err = resourceapply.ApplyDaemonSetDryRun(ctx, client.AppsV1(), daemonSet(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modified || len(dryRunActions) != 0 {
+		t.Errorf("expected no modification, got modified=%v actions=%v", modified, dryRunActions)
+	}
+
+	// a changed daemonset would be updated, but is not persisted
+	required := daemonSet()
+	required.Spec.Template.Spec.Containers[0].Image = "docker-registry/img:new"
+	actual, modified, err = resourceapply.ApplyDaemonSetDryRun(ctx, client.AppsV1(), required, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified || len(dryRunActions) != 1 || !dryRunActions[0].Matches("update", "daemonsets") {
+		t.Fatalf("expected a dry-run update, got modified=%v actions=%v", modified, dryRunActions)
+	}
+	if actual.Spec.Template.Spec.Containers[0].Image != "docker-registry/img:new" {
+		t.Errorf("expected the updated image, got %q", actual.Spec.Template.Spec.Containers[0].Image)
+	}
+	persisted, err := client.AppsV1().DaemonSets("openshift-apiserver").Get(ctx, "apiserver", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if persisted.Spec.Template.Spec.Containers[0].Image != "docker-registry/img" {
+		t.Errorf("expected the dry-run update not to persist, got image %q", persisted.Spec.Template.Spec.Containers[0].Image)
+	}
+
+	// the live apply computes the same object
+	live, modified, err := resourceapply.ApplyDaemonSet(ctx, client.AppsV1(), recorder, required, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified {
+		t.Errorf("expected the live apply to modify the daemonset")
+	}
+	if live.Annotations["operator.openshift.io/spec-hash"] != actual.Annotations["operator.openshift.io/spec-hash"] {
+		t.Errorf("expected the dry-run spec hash %q to match the live one %q", actual.Annotations["operator.openshift.io/spec-hash"], live.Annotations["operator.openshift.io/spec-hash"])
+	}
+
+	// only the live applies recorded events
+	if events := recorder.Events(); len(events) != 2 {
+		t.Errorf("expected 2 events, got %v", events)
+	}
+}
+
 func TestApplyDeploymentWithForce(t *testing.T) {
 	tests := []struct {
 		name               string
@@ -0,0 +1,87 @@
+package resourceapply
+
+import (
+	"context"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	batchclientv1 "k8s.io/client-go/kubernetes/typed/batch/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehelper"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+)
+
+// ApplyJob ensures the form of the specified job is present in the API. If it does not exist, it will be
+// created. The spec of a job is immutable, so a change of the required spec is detected by the spec hash
+// annotation and the job is deleted and created again, but only once the existing job completed or failed.
+// A running job is left alone until it finishes. If the spec did not change, the metadata of the required
+// job is merged with the existing job and an update is performed if it differs.
+func ApplyJob(ctx context.Context, client batchclientv1.JobsGetter, recorder events.Recorder, requiredOriginal *batchv1.Job) (*batchv1.Job, bool, error) {
+	required := requiredOriginal.DeepCopy()
+	if err := SetSpecHashAnnotation(&required.ObjectMeta, required.Spec); err != nil {
+		return nil, false, err
+	}
+
+	existing, err := client.Jobs(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		actual, err := client.Jobs(required.Namespace).Create(
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*batchv1.Job), metav1.CreateOptions{})
+		resourcehelper.ReportCreateEvent(recorder, required, err)
+		return actual, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if existing.Annotations[specHashAnnotation] != required.Annotations[specHashAnnotation] {
+		if !jobFinished(existing) {
+			klog.V(2).Infof("Job %q spec changed, waiting for the running job to finish before it is recreated", required.Namespace+"/"+required.Name)
+			return existing, false, nil
+		}
+
+		// the pods of the finished job are garbage collected in the background
+		propagation := metav1.DeletePropagationBackground
+		err := client.Jobs(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{
+			PropagationPolicy: &propagation,
+			Preconditions:     &metav1.Preconditions{UID: &existing.UID},
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, false, err
+		}
+		resourcehelper.ReportDeleteEvent(recorder, existing, err)
+
+		actual, err := client.Jobs(required.Namespace).Create(
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required).(*batchv1.Job), metav1.CreateOptions{})
+		resourcehelper.ReportCreateEvent(recorder, required, err)
+		return actual, true, err
+	}
+
+	modified := false
+	existingCopy := existing.DeepCopy()
+	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
+	if !modified {
+		return existingCopy, false, nil
+	}
+
+	if klog.V(2).Enabled() {
+		klog.Infof("Job %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, existingCopy))
+	}
+
+	actual, err := client.Jobs(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	resourcehelper.ReportUpdateEvent(recorder, required, err)
+	return actual, true, err
+}
+
+// jobFinished returns true if the job completed or failed.
+func jobFinished(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
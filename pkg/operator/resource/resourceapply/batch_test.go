@@ -0,0 +1,174 @@
+package resourceapply
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestApplyJob(t *testing.T) {
+	newJob := func(image string, conditions ...batchv1.JobConditionType) *batchv1.Job {
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "migration", UID: "uid"},
+			Spec: batchv1.JobSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						RestartPolicy: corev1.RestartPolicyNever,
+						Containers:    []corev1.Container{{Name: "migrate", Image: image}},
+					},
+				},
+			},
+		}
+		for _, condition := range conditions {
+			job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{Type: condition, Status: corev1.ConditionTrue})
+		}
+		return job
+	}
+	withSpecHash := func(job *batchv1.Job) *batchv1.Job {
+		if err := SetSpecHashAnnotation(&job.ObjectMeta, job.Spec); err != nil {
+			t.Fatal(err)
+		}
+		return job
+	}
+
+	tests := []struct {
+		name             string
+		existing         []runtime.Object
+		required         *batchv1.Job
+		expectedModified bool
+		expectedImage    string
+		expectedLabels   map[string]string
+		expectedActions  []string
+	}{
+		{
+			name:             "create",
+			required:         newJob("migrate:v1"),
+			expectedModified: true,
+			expectedImage:    "migrate:v1",
+			expectedActions:  []string{"get", "create"},
+		},
+		{
+			name: "create removes labels marked for removal",
+			required: func() *batchv1.Job {
+				job := newJob("migrate:v1")
+				job.Labels = map[string]string{"app": "new", "foo-": ""}
+				return job
+			}(),
+			expectedModified: true,
+			expectedImage:    "migrate:v1",
+			expectedLabels:   map[string]string{"app": "new"},
+			expectedActions:  []string{"get", "create"},
+		},
+		{
+			name:            "unchanged",
+			existing:        []runtime.Object{withSpecHash(newJob("migrate:v1", batchv1.JobComplete))},
+			required:        newJob("migrate:v1"),
+			expectedImage:   "migrate:v1",
+			expectedActions: []string{"get"},
+		},
+		{
+			name:            "changed spec of a running job is left alone",
+			existing:        []runtime.Object{withSpecHash(newJob("migrate:v1"))},
+			required:        newJob("migrate:v2"),
+			expectedImage:   "migrate:v1",
+			expectedActions: []string{"get"},
+		},
+		{
+			name:             "changed spec of a completed job recreates the job",
+			existing:         []runtime.Object{withSpecHash(newJob("migrate:v1", batchv1.JobComplete))},
+			required:         newJob("migrate:v2"),
+			expectedModified: true,
+			expectedImage:    "migrate:v2",
+			expectedActions:  []string{"get", "delete", "create"},
+		},
+		{
+			name:     "recreate removes labels marked for removal",
+			existing: []runtime.Object{withSpecHash(newJob("migrate:v1", batchv1.JobComplete))},
+			required: func() *batchv1.Job {
+				job := newJob("migrate:v2")
+				job.Labels = map[string]string{"app": "new", "foo-": ""}
+				return job
+			}(),
+			expectedModified: true,
+			expectedImage:    "migrate:v2",
+			expectedLabels:   map[string]string{"app": "new"},
+			expectedActions:  []string{"get", "delete", "create"},
+		},
+		{
+			name:             "changed spec of a failed job recreates the job",
+			existing:         []runtime.Object{withSpecHash(newJob("migrate:v1", batchv1.JobFailed))},
+			required:         newJob("migrate:v2"),
+			expectedModified: true,
+			expectedImage:    "migrate:v2",
+			expectedActions:  []string{"get", "delete", "create"},
+		},
+		{
+			name: "changed metadata is updated",
+			existing: []runtime.Object{func() *batchv1.Job {
+				job := withSpecHash(newJob("migrate:v1"))
+				job.Labels = map[string]string{"app": "old"}
+				return job
+			}()},
+			required: func() *batchv1.Job {
+				job := newJob("migrate:v1")
+				job.Labels = map[string]string{"app": "new"}
+				return job
+			}(),
+			expectedModified: true,
+			expectedImage:    "migrate:v1",
+			expectedActions:  []string{"get", "update"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+			actual, modified, err := ApplyJob(context.TODO(), client.BatchV1(), recorder, test.required)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if modified != test.expectedModified {
+				t.Errorf("expected modified %v, got %v", test.expectedModified, modified)
+			}
+			if image := actual.Spec.Template.Spec.Containers[0].Image; image != test.expectedImage {
+				t.Errorf("expected image %q, got %q", test.expectedImage, image)
+			}
+			if test.expectedLabels != nil && !equality.Semantic.DeepEqual(actual.Labels, test.expectedLabels) {
+				t.Errorf("expected labels %v, got %v", test.expectedLabels, actual.Labels)
+			}
+
+			var verbs []string
+			for _, action := range client.Actions() {
+				verbs = append(verbs, action.GetVerb())
+			}
+			if len(verbs) != len(test.expectedActions) {
+				t.Fatalf("expected actions %v, got %v", test.expectedActions, verbs)
+			}
+			for i := range verbs {
+				if verbs[i] != test.expectedActions[i] {
+					t.Fatalf("expected actions %v, got %v", test.expectedActions, verbs)
+				}
+			}
+			for _, action := range client.Actions() {
+				if deleteAction, ok := action.(clienttesting.DeleteAction); ok {
+					if policy := deleteAction.GetDeleteOptions().PropagationPolicy; policy == nil || *policy != metav1.DeletePropagationBackground {
+						t.Errorf("expected the job to be deleted with background propagation")
+					}
+				}
+			}
+		})
+	}
+}
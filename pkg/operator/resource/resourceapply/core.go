@@ -3,8 +3,8 @@ package resourceapply
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -20,6 +20,7 @@ import (
 	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
 )
 
 // TODO find  way to create a registry of these based on struct mapping or some such that forces users to get this right
@@ -81,7 +82,74 @@ func ApplyServiceAccount(ctx context.Context, client coreclientv1.ServiceAccount
 
 // ApplyConfigMap merges objectmeta, requires data
 func ApplyConfigMap(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap) (*corev1.ConfigMap, bool, error) {
-	return ApplyConfigMapImproved(ctx, client, recorder, required, noCache)
+	return ApplyConfigMapWithForce(ctx, client, recorder, required, false)
+}
+
+// ApplyConfigMapWithForce applies the required ConfigMap like ApplyConfigMap. When force is true, a CA bundle injected
+// into the existing ConfigMap because of the config.openshift.io/inject-trusted-cabundle label or the
+// service.beta.openshift.io/inject-cabundle annotation is not preserved, and the required data is applied verbatim.
+// This is meant for recovering ConfigMaps with broken content, the injecting operator will inject the CA bundle again.
+func ApplyConfigMapWithForce(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap, force bool) (*corev1.ConfigMap, bool, error) {
+	return applyConfigMap(ctx, client, recorder, required, noCache, force)
+}
+
+// ApplyConfigMapTakeOwnership applies the required ConfigMap like ApplyConfigMap, but additionally takes over a ConfigMap
+// that is currently labelled for trusted CA bundle injection. The injection label is removed from the existing ConfigMap
+// and the required data, including ca-bundle.crt, is applied verbatim so that the operator manages the whole content.
+// This is meant for migrating a ConfigMap from being injected to being fully managed by the operator.
+func ApplyConfigMapTakeOwnership(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap) (*corev1.ConfigMap, bool, error) {
+	requiredCopy := required.DeepCopy()
+	if requiredCopy.Labels == nil {
+		requiredCopy.Labels = map[string]string{}
+	}
+	delete(requiredCopy.Labels, "config.openshift.io/inject-trusted-cabundle")
+	// a trailing dash removes the label from the existing object
+	requiredCopy.Labels["config.openshift.io/inject-trusted-cabundle-"] = ""
+	return ApplyConfigMapImproved(ctx, client, recorder, requiredCopy, noCache)
+}
+
+// ApplyConfigMapFromObject applies a ConfigMap with the given metadata holding obj serialized into the data key.
+// obj is serialized as YAML when the key ends with .yaml or .yml, as JSON otherwise. When the existing ConfigMap
+// holds a value that parses to the same content, for instance with keys in a different order, the existing value
+// is kept so that the ConfigMap is not updated.
+func ApplyConfigMapFromObject(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, meta metav1.ObjectMeta, key string, obj interface{}) (*corev1.ConfigMap, bool, error) {
+	var value []byte
+	var err error
+	if strings.HasSuffix(key, ".yaml") || strings.HasSuffix(key, ".yml") {
+		value, err = yaml.Marshal(obj)
+	} else {
+		value, err = json.Marshal(obj)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to serialize %s for configmap %s/%s: %w", key, meta.Namespace, meta.Name, err)
+	}
+
+	required := &corev1.ConfigMap{
+		ObjectMeta: *meta.DeepCopy(),
+		Data:       map[string]string{key: string(value)},
+	}
+	existing, err := client.ConfigMaps(meta.Namespace).Get(ctx, meta.Name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, false, err
+	}
+	if err == nil {
+		if existingValue, ok := existing.Data[key]; ok && equivalentSerializedContent(existingValue, string(value)) {
+			required.Data[key] = existingValue
+		}
+	}
+	return ApplyConfigMap(ctx, client, recorder, required)
+}
+
+// equivalentSerializedContent returns true if both JSON or YAML documents parse to the same content.
+func equivalentSerializedContent(a, b string) bool {
+	var aContent, bContent interface{}
+	if err := yaml.Unmarshal([]byte(a), &aContent); err != nil {
+		return false
+	}
+	if err := yaml.Unmarshal([]byte(b), &bContent); err != nil {
+		return false
+	}
+	return equality.Semantic.DeepEqual(aContent, bContent)
 }
 
 // ApplySecret merges objectmeta, requires data
@@ -159,7 +227,8 @@ func ApplyServiceImproved(ctx context.Context, client coreclientv1.ServicesGette
 	existingCopy := existing.DeepCopy()
 
 	// This will catch also changes between old `required.spec` and current `required.spec`, because
-	// the annotation from SetSpecHashAnnotation will be different.
+	// the annotation from SetSpecHashAnnotation will be different. A spec hash annotation removed by a
+	// user is detected the same way, the annotation is restored and the spec reconciled below.
 	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
 	selectorSame := equality.Semantic.DeepEqual(existingCopy.Spec.Selector, required.Spec.Selector)
 
@@ -264,6 +333,10 @@ func ApplyServiceAccountImproved(ctx context.Context, client coreclientv1.Servic
 
 // ApplyConfigMap merges objectmeta, requires data
 func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap, cache ResourceCache) (*corev1.ConfigMap, bool, error) {
+	return applyConfigMap(ctx, client, recorder, required, cache, false)
+}
+
+func applyConfigMap(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap, cache ResourceCache, force bool) (*corev1.ConfigMap, bool, error) {
 	existing, err := client.ConfigMaps(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
@@ -287,11 +360,11 @@ func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsG
 	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
 
 	// injected by cluster-network-operator: https://github.com/openshift/cluster-network-operator/blob/acc819ee0f3424a341b9ad4e1e83ca0a742c230a/docs/architecture.md?L192#configmap-ca-injector
-	caBundleInjected := required.Labels["config.openshift.io/inject-trusted-cabundle"] == "true"
+	caBundleInjected := !force && required.Labels["config.openshift.io/inject-trusted-cabundle"] == "true"
 	_, newCABundleRequired := required.Data["ca-bundle.crt"]
 
 	// injected by service-ca-operator: https://github.com/openshift/service-ca-operator/blob/f409fb9e308ace1e5f8596add187d2239b073e23/README.md#openshift-service-ca-operator
-	serviceCAInjected := required.Annotations["service.beta.openshift.io/inject-cabundle"] == "true"
+	serviceCAInjected := !force && required.Annotations["service.beta.openshift.io/inject-cabundle"] == "true"
 	_, newServiceCARequired := required.Data["service-ca.crt"]
 
 	var modifiedKeys []string
@@ -343,15 +416,17 @@ func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsG
 
 	actual, err := client.ConfigMaps(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
 
-	var details string
-	if !dataSame {
-		sort.Sort(sort.StringSlice(modifiedKeys))
-		details = fmt.Sprintf("cause by changes in %v", strings.Join(modifiedKeys, ","))
+	var details []string
+	if delta := formatDataDelta(
+		dataDelta("data", existing.Data, existingCopy.Data, stringsEqual, eventDiffValue),
+		dataDelta("binaryData", existing.BinaryData, existingCopy.BinaryData, bytes.Equal, nil),
+	); len(delta) > 0 {
+		details = append(details, delta)
 	}
 	if klog.V(2).Enabled() {
 		klog.Infof("ConfigMap %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, required))
 	}
-	resourcehelper.ReportUpdateEvent(recorder, required, err, details)
+	resourcehelper.ReportUpdateEvent(recorder, required, err, details...)
 	cache.UpdateCachedResourceMetadata(required, actual)
 	return actual, true, err
 }
@@ -439,7 +514,12 @@ func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter,
 	 */
 	if existingCopy.Type == existing.Type {
 		actual, err = client.Secrets(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
-		resourcehelper.ReportUpdateEvent(recorder, existingCopy, err)
+		// secret values must never be logged, only the names of the changed keys are reported
+		var details []string
+		if delta := formatDataDelta(dataDelta("data", existing.Data, existingCopy.Data, bytes.Equal, nil)); len(delta) > 0 {
+			details = append(details, delta)
+		}
+		resourcehelper.ReportUpdateEvent(recorder, existingCopy, err, details...)
 
 		if err == nil {
 			return actual, true, err
@@ -461,7 +541,53 @@ func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter,
 	return actual, true, err
 }
 
+// ApplyResourceQuota ensures the form of the specified resource quota is present in the API. If it does not
+// exist, it will be created. If it does exist, the metadata of the required resource quota will be merged
+// with the existing one and an update performed if the metadata differ or if the hard limits and scopes
+// changed. Changes of the spec are detected by the spec hash annotation, so the hard limits are compared
+// as they were required, not as they were normalized by the API server. The status of the existing resource
+// quota, which is maintained by the quota controller, is preserved.
+func ApplyResourceQuota(ctx context.Context, client coreclientv1.ResourceQuotasGetter, recorder events.Recorder, requiredOriginal *corev1.ResourceQuota) (*corev1.ResourceQuota, bool, error) {
+	required := requiredOriginal.DeepCopy()
+	if err := SetSpecHashAnnotation(&required.ObjectMeta, required.Spec); err != nil {
+		return nil, false, err
+	}
+
+	existing, err := client.ResourceQuotas(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		requiredCopy := required.DeepCopy()
+		actual, err := client.ResourceQuotas(requiredCopy.Namespace).Create(
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.ResourceQuota), metav1.CreateOptions{})
+		resourcehelper.ReportCreateEvent(recorder, required, err)
+		return actual, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	modified := false
+	existingCopy := existing.DeepCopy()
+	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
+
+	sameSpec := existing.Annotations[specHashAnnotation] == required.Annotations[specHashAnnotation]
+	if sameSpec && !modified {
+		return existingCopy, false, nil
+	}
+	// the spec hash annotation was merged with the metadata above
+	existingCopy.Spec = required.Spec
+
+	if klog.V(2).Enabled() {
+		klog.Infof("ResourceQuota %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, existingCopy))
+	}
+
+	actual, err := client.ResourceQuotas(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	resourcehelper.ReportUpdateEvent(recorder, required, err)
+	return actual, true, err
+}
+
 // SyncConfigMap applies a ConfigMap from a location `sourceNamespace/sourceName` to `targetNamespace/targetName`
+// If the source does not exist, the target is deleted. CA bundles injected into the target are preserved as long as
+// the source carries the injection label or annotation, just like ApplyConfigMap does.
 func SyncConfigMap(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, sourceNamespace, sourceName, targetNamespace, targetName string, ownerRefs []metav1.OwnerReference) (*corev1.ConfigMap, bool, error) {
 	return syncPartialConfigMap(ctx, client, recorder, sourceNamespace, sourceName, targetNamespace, targetName, nil, ownerRefs, nil)
 }
@@ -550,10 +676,33 @@ func SyncSecretWithLabels(ctx context.Context, client coreclientv1.SecretsGetter
 // SyncPartialSecret does what SyncSecret does but it only synchronizes a subset of keys given by `syncedKeys`.
 // SyncPartialSecret will delete the target if `syncedKeys` are set but the source does not contain any of these keys.
 func SyncPartialSecret(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, sourceNamespace, sourceName, targetNamespace, targetName string, syncedKeys sets.Set[string], ownerRefs []metav1.OwnerReference) (*corev1.Secret, bool, error) {
-	return syncPartialSecret(ctx, client, recorder, sourceNamespace, sourceName, targetNamespace, targetName, syncedKeys, ownerRefs, nil)
+	var keyMapping map[string]string
+	if len(syncedKeys) > 0 {
+		keyMapping = make(map[string]string, len(syncedKeys))
+		for key := range syncedKeys {
+			keyMapping[key] = key
+		}
+	}
+	return syncPartialSecret(ctx, client, recorder, sourceNamespace, sourceName, targetNamespace, targetName, keyMapping, ownerRefs, nil)
 }
 
-func syncPartialSecret(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, sourceNamespace, sourceName, targetNamespace, targetName string, syncedKeys sets.Set[string], ownerRefs []metav1.OwnerReference, labels map[string]string) (*corev1.Secret, bool, error) {
+// SyncPartialSecretWithKeyMapping does what SyncPartialSecret does, but renames the synchronized keys: `keyMapping` maps
+// the keys of the source to the keys of the target. Source keys that are not mapped are not synchronized and mapped keys
+// missing in the source are skipped. The target is deleted if the source contains none of the mapped keys. An error is
+// returned if several source keys are mapped to the same target key.
+func SyncPartialSecretWithKeyMapping(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, sourceNamespace, sourceName, targetNamespace, targetName string, keyMapping map[string]string, ownerRefs []metav1.OwnerReference) (*corev1.Secret, bool, error) {
+	sourceKeys := make(map[string]string, len(keyMapping))
+	for _, sourceKey := range sets.List(sets.KeySet(keyMapping)) {
+		targetKey := keyMapping[sourceKey]
+		if otherKey, ok := sourceKeys[targetKey]; ok {
+			return nil, false, fmt.Errorf("source keys %q and %q are both mapped to target key %q", otherKey, sourceKey, targetKey)
+		}
+		sourceKeys[targetKey] = sourceKey
+	}
+	return syncPartialSecret(ctx, client, recorder, sourceNamespace, sourceName, targetNamespace, targetName, keyMapping, ownerRefs, nil)
+}
+
+func syncPartialSecret(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, sourceNamespace, sourceName, targetNamespace, targetName string, keyMapping map[string]string, ownerRefs []metav1.OwnerReference, labels map[string]string) (*corev1.Secret, bool, error) {
 	source, err := client.Secrets(sourceNamespace).Get(ctx, sourceName, metav1.GetOptions{})
 	switch {
 	case apierrors.IsNotFound(err):
@@ -580,17 +729,19 @@ func syncPartialSecret(ctx context.Context, client coreclientv1.SecretsGetter, r
 			source.Type = corev1.SecretTypeOpaque
 		}
 
-		if len(syncedKeys) > 0 {
-			for sourceKey := range source.Data {
-				if !syncedKeys.Has(sourceKey) {
-					delete(source.Data, sourceKey)
+		if len(keyMapping) > 0 {
+			data := map[string][]byte{}
+			stringData := map[string]string{}
+			for sourceKey, targetKey := range keyMapping {
+				if value, ok := source.Data[sourceKey]; ok {
+					data[targetKey] = value
 				}
-			}
-			for sourceKey := range source.StringData {
-				if !syncedKeys.Has(sourceKey) {
-					delete(source.StringData, sourceKey)
+				if value, ok := source.StringData[sourceKey]; ok {
+					stringData[targetKey] = value
 				}
 			}
+			source.Data = data
+			source.StringData = stringData
 
 			// remove the synced secret if the requested fields are not present in source
 			if len(source.Data)+len(source.StringData) == 0 {
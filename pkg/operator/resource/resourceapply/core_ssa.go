@@ -0,0 +1,219 @@
+package resourceapply
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehelper"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+)
+
+// ApplyConfigMapSSA applies the required ConfigMap using server-side apply with the given field manager.
+// Unlike ApplyConfigMap, only the metadata and data keys set in required are reconciled, data keys written by
+// other field managers are left untouched. The patch is only sent when the existing ConfigMap differs from
+// required in any of these fields, or still contains labels, annotations or data keys previously applied by
+// the field manager that required no longer sets, and the same events as ApplyConfigMap are recorded.
+func ApplyConfigMapSSA(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap, fieldManager string) (*corev1.ConfigMap, bool, error) {
+	existing, err := client.ConfigMaps(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, false, err
+	}
+	exists := err == nil
+
+	var modifiedKeys []string
+	if exists {
+		modified := false
+		existingCopy := existing.DeepCopy()
+		resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
+		for key, value := range required.Data {
+			if existingValue, ok := existing.Data[key]; !ok || existingValue != value {
+				modifiedKeys = append(modifiedKeys, "data."+key)
+			}
+		}
+		for key, value := range required.BinaryData {
+			if existingValue, ok := existing.BinaryData[key]; !ok || !bytes.Equal(existingValue, value) {
+				modifiedKeys = append(modifiedKeys, "binaryData."+key)
+			}
+		}
+		// keys applied before and dropped from required are removed by the apply
+		for _, key := range appliedKeys(existing.ManagedFields, fieldManager, "data") {
+			if _, ok := required.Data[key]; !ok {
+				modifiedKeys = append(modifiedKeys, "data."+key)
+			}
+		}
+		for _, key := range appliedKeys(existing.ManagedFields, fieldManager, "binaryData") {
+			if _, ok := required.BinaryData[key]; !ok {
+				modifiedKeys = append(modifiedKeys, "binaryData."+key)
+			}
+		}
+		if appliedMetadataRemoved(existing.ManagedFields, fieldManager, required.ObjectMeta) {
+			modified = true
+		}
+		if !modified && len(modifiedKeys) == 0 {
+			return existing, false, nil
+		}
+	}
+
+	applyConfig := resourcemerge.WithCleanLabelsAndAnnotations(required.DeepCopy()).(*corev1.ConfigMap)
+	applyConfig.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+	patch, err := serverSideApplyPatch(&applyConfig.ObjectMeta, applyConfig)
+	if err != nil {
+		return nil, false, err
+	}
+	actual, err := client.ConfigMaps(required.Namespace).Patch(ctx, required.Name, types.ApplyPatchType, patch, metav1.PatchOptions{FieldManager: fieldManager, Force: ptr.To(true)})
+	if !exists {
+		resourcehelper.ReportCreateEvent(recorder, required, err)
+		return actual, true, err
+	}
+
+	var details string
+	if len(modifiedKeys) > 0 {
+		sort.Strings(modifiedKeys)
+		details = fmt.Sprintf("cause by changes in %v", strings.Join(modifiedKeys, ","))
+	}
+	if klog.V(2).Enabled() {
+		klog.Infof("ConfigMap %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, required))
+	}
+	resourcehelper.ReportUpdateEvent(recorder, required, err, details)
+	return actual, true, err
+}
+
+// ApplySecretSSA applies the required Secret using server-side apply with the given field manager.
+// Unlike ApplySecret, only the metadata, type and data keys set in required are reconciled, data keys written by
+// other field managers are left untouched. The patch is only sent when the existing Secret differs from
+// required in any of these fields, or still contains labels, annotations or data keys previously applied by
+// the field manager that required no longer sets, and the same events as ApplySecret are recorded.
+func ApplySecretSSA(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, requiredInput *corev1.Secret, fieldManager string) (*corev1.Secret, bool, error) {
+	// copy the stringData to data.  Error on a data content conflict inside required.  This is usually a bug.
+	required := requiredInput.DeepCopy()
+	if required.Data == nil {
+		required.Data = map[string][]byte{}
+	}
+	for k, v := range required.StringData {
+		if dataV, ok := required.Data[k]; ok {
+			if string(dataV) != v {
+				return nil, false, fmt.Errorf("Secret.stringData[%q] conflicts with Secret.data[%q]", k, k)
+			}
+		}
+		required.Data[k] = []byte(v)
+	}
+	required.StringData = nil
+
+	existing, err := client.Secrets(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, false, err
+	}
+	exists := err == nil
+
+	if exists {
+		modified := false
+		existingCopy := existing.DeepCopy()
+		resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
+		for key, value := range required.Data {
+			if existingValue, ok := existing.Data[key]; !ok || !bytes.Equal(existingValue, value) {
+				modified = true
+			}
+		}
+		if len(required.Type) > 0 && required.Type != existing.Type {
+			modified = true
+		}
+		// keys applied before and dropped from required are removed by the apply
+		for _, key := range appliedKeys(existing.ManagedFields, fieldManager, "data") {
+			if _, ok := required.Data[key]; !ok {
+				modified = true
+			}
+		}
+		if appliedMetadataRemoved(existing.ManagedFields, fieldManager, required.ObjectMeta) {
+			modified = true
+		}
+		if !modified {
+			return existing, false, nil
+		}
+	}
+
+	applyConfig := resourcemerge.WithCleanLabelsAndAnnotations(required.DeepCopy()).(*corev1.Secret)
+	applyConfig.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}
+	patch, err := serverSideApplyPatch(&applyConfig.ObjectMeta, applyConfig)
+	if err != nil {
+		return nil, false, err
+	}
+	actual, err := client.Secrets(required.Namespace).Patch(ctx, required.Name, types.ApplyPatchType, patch, metav1.PatchOptions{FieldManager: fieldManager, Force: ptr.To(true)})
+	if !exists {
+		resourcehelper.ReportCreateEvent(recorder, required, err)
+		return actual, true, err
+	}
+
+	if klog.V(4).Enabled() {
+		klog.Infof("Secret %s/%s changes: %v", required.Namespace, required.Name, JSONPatchSecretNoError(existing, required))
+	}
+	resourcehelper.ReportUpdateEvent(recorder, required, err)
+	return actual, true, err
+}
+
+// serverSideApplyPatch serializes obj as an apply patch. Metadata populated by the server is dropped, it would
+// either be rejected or make the field manager own it.
+func serverSideApplyPatch(objMeta *metav1.ObjectMeta, obj interface{}) ([]byte, error) {
+	objMeta.ResourceVersion = ""
+	objMeta.UID = ""
+	objMeta.Generation = 0
+	objMeta.CreationTimestamp = metav1.Time{}
+	objMeta.ManagedFields = nil
+	patch, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize apply patch: %w", err)
+	}
+	return patch, nil
+}
+
+// appliedMetadataRemoved returns true if labels or annotations applied by fieldManager are not set in required.
+func appliedMetadataRemoved(managedFields []metav1.ManagedFieldsEntry, fieldManager string, required metav1.ObjectMeta) bool {
+	for _, key := range appliedKeys(managedFields, fieldManager, "metadata", "labels") {
+		if _, ok := required.Labels[key]; !ok {
+			return true
+		}
+	}
+	for _, key := range appliedKeys(managedFields, fieldManager, "metadata", "annotations") {
+		if _, ok := required.Annotations[key]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// appliedKeys returns the keys of the map at fieldPath that fieldManager owns through server-side apply, as
+// recorded in managedFields.
+func appliedKeys(managedFields []metav1.ManagedFieldsEntry, fieldManager string, fieldPath ...string) []string {
+	var keys []string
+	for _, entry := range managedFields {
+		if entry.Manager != fieldManager || entry.Operation != metav1.ManagedFieldsOperationApply || entry.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			klog.V(4).Infof("unable to parse the managed fields of %q: %v", fieldManager, err)
+			continue
+		}
+		for _, name := range fieldPath {
+			fields, _ = fields["f:"+name].(map[string]interface{})
+		}
+		for field := range fields {
+			if key, ok := strings.CutPrefix(field, "f:"); ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,190 @@
+package resourceapply
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestApplyConfigMapSSA(t *testing.T) {
+	ctx := context.TODO()
+	client := fake.NewClientset()
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	required := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"app": "operand"}},
+		Data:       map[string]string{"owned": "value"},
+	}
+
+	// create
+	actual, modified, err := ApplyConfigMapSSA(ctx, client.CoreV1(), recorder, required, "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified {
+		t.Errorf("expected the configmap to be created")
+	}
+	if actual.Data["owned"] != "value" {
+		t.Errorf("unexpected data: %v", actual.Data)
+	}
+
+	// another manager writes a disjoint key
+	_, _, err = ApplyConfigMapSSA(ctx, client.CoreV1(), recorder, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+		Data:       map[string]string{"other": "value"},
+	}, "other-manager")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// applying the unchanged configmap is a no-op that keeps the key of the other manager
+	actual, modified, err = ApplyConfigMapSSA(ctx, client.CoreV1(), recorder, required, "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modified {
+		t.Errorf("expected no modification")
+	}
+
+	// changing an owned key does not conflict with the other manager
+	required.Data["owned"] = "changed"
+	actual, modified, err = ApplyConfigMapSSA(ctx, client.CoreV1(), recorder, required, "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified {
+		t.Errorf("expected the configmap to be updated")
+	}
+	if actual.Data["owned"] != "changed" || actual.Data["other"] != "value" {
+		t.Errorf("unexpected data: %v", actual.Data)
+	}
+	if actual.Labels["app"] != "operand" {
+		t.Errorf("unexpected labels: %v", actual.Labels)
+	}
+
+	var reasons []string
+	for _, e := range recorder.Events() {
+		reasons = append(reasons, e.Reason)
+	}
+	expectedReasons := []string{"ConfigMapCreated", "ConfigMapUpdated", "ConfigMapUpdated"}
+	if len(reasons) != len(expectedReasons) {
+		t.Fatalf("expected events %v, got %v", expectedReasons, reasons)
+	}
+	for i := range reasons {
+		if reasons[i] != expectedReasons[i] {
+			t.Fatalf("expected events %v, got %v", expectedReasons, reasons)
+		}
+	}
+}
+
+func TestApplySecretSSA(t *testing.T) {
+	ctx := context.TODO()
+	client := fake.NewClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"other": []byte("value")},
+	})
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	required := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: map[string]string{"owned": "value"},
+	}
+
+	actual, modified, err := ApplySecretSSA(ctx, client.CoreV1(), recorder, required, "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified {
+		t.Errorf("expected the secret to be updated")
+	}
+	if string(actual.Data["owned"]) != "value" || string(actual.Data["other"]) != "value" {
+		t.Errorf("unexpected data: %v", actual.Data)
+	}
+
+	_, modified, err = ApplySecretSSA(ctx, client.CoreV1(), recorder, required, "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modified {
+		t.Errorf("expected no modification")
+	}
+	if events := recorder.Events(); len(events) != 1 || events[0].Reason != "SecretUpdated" {
+		t.Errorf("expected a single SecretUpdated event, got %v", events)
+	}
+
+	// dropping an owned key removes it and keeps the key of the other manager
+	required.StringData = map[string]string{"kept": "value"}
+	if _, _, err := ApplySecretSSA(ctx, client.CoreV1(), recorder, required, "operator"); err != nil {
+		t.Fatal(err)
+	}
+	actual, modified, err = ApplySecretSSA(ctx, client.CoreV1(), recorder, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+		Type:       corev1.SecretTypeOpaque,
+	}, "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified {
+		t.Errorf("expected the dropped key to be removed")
+	}
+	if _, ok := actual.Data["kept"]; ok || string(actual.Data["other"]) != "value" {
+		t.Errorf("unexpected data: %v", actual.Data)
+	}
+}
+
+func TestApplyConfigMapSSARemovedKey(t *testing.T) {
+	ctx := context.TODO()
+	client := fake.NewClientset()
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	required := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"app": "operand"}},
+		Data:       map[string]string{"owned": "value", "dropped": "value"},
+	}
+	if _, _, err := ApplyConfigMapSSA(ctx, client.CoreV1(), recorder, required, "operator"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ApplyConfigMapSSA(ctx, client.CoreV1(), recorder, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+		Data:       map[string]string{"other": "value"},
+	}, "other-manager"); err != nil {
+		t.Fatal(err)
+	}
+
+	// dropping an owned key removes it, without any other change
+	delete(required.Data, "dropped")
+	actual, modified, err := ApplyConfigMapSSA(ctx, client.CoreV1(), recorder, required, "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified {
+		t.Errorf("expected the dropped key to be removed")
+	}
+	if _, ok := actual.Data["dropped"]; ok || actual.Data["owned"] != "value" || actual.Data["other"] != "value" {
+		t.Errorf("unexpected data: %v", actual.Data)
+	}
+
+	// dropping an owned label removes it as well
+	required.Labels = nil
+	actual, modified, err = ApplyConfigMapSSA(ctx, client.CoreV1(), recorder, required, "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified || len(actual.Labels) != 0 {
+		t.Errorf("expected the dropped label to be removed, got modified %v and labels %v", modified, actual.Labels)
+	}
+
+	// once removed, applying is a no-op again
+	if _, modified, err = ApplyConfigMapSSA(ctx, client.CoreV1(), recorder, required, "operator"); err != nil {
+		t.Fatal(err)
+	}
+	if modified {
+		t.Errorf("expected no modification")
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -336,6 +337,354 @@ func TestApplyConfigMap(t *testing.T) {
 	}
 }
 
+func TestApplyConfigMapTakeOwnership(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		input    *corev1.ConfigMap
+
+		expectedModified bool
+		expected         *corev1.ConfigMap
+	}{
+		{
+			name: "take over injected CA bundle",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"config.openshift.io/inject-trusted-cabundle": "true", "extra": "leave-alone"}},
+					Data: map[string]string{
+						"ca-bundle.crt": "injected",
+					},
+				},
+			},
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"}},
+				Data: map[string]string{
+					"ca-bundle.crt": "managed",
+				},
+			},
+
+			expectedModified: true,
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"extra": "leave-alone"}},
+				Data: map[string]string{
+					"ca-bundle.crt": "managed",
+				},
+			},
+		},
+		{
+			name: "drop injected CA bundle not required anymore",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"}},
+					Data: map[string]string{
+						"ca-bundle.crt": "injected",
+					},
+				},
+			},
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Data: map[string]string{
+					"other": "managed",
+				},
+			},
+
+			expectedModified: true,
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{}},
+				Data: map[string]string{
+					"other": "managed",
+				},
+			},
+		},
+		{
+			name: "already managed",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Data: map[string]string{
+						"ca-bundle.crt": "managed",
+					},
+				},
+			},
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Data: map[string]string{
+					"ca-bundle.crt": "managed",
+				},
+			},
+
+			expectedModified: false,
+		},
+		{
+			name: "create without the injection label",
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"}},
+				Data: map[string]string{
+					"ca-bundle.crt": "managed",
+				},
+			},
+
+			expectedModified: true,
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{}},
+				Data: map[string]string{
+					"ca-bundle.crt": "managed",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			input := test.input.DeepCopy()
+			actual, actualModified, err := ApplyConfigMapTakeOwnership(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			if !equality.Semantic.DeepEqual(input, test.input) {
+				t.Errorf("input was mutated: %v", JSONPatchNoError(input, test.input))
+			}
+			if test.expected == nil {
+				return
+			}
+			if !equality.Semantic.DeepEqual(test.expected, actual) {
+				t.Error(JSONPatchNoError(test.expected, actual))
+			}
+		})
+	}
+}
+
+func TestApplyConfigMapFromObject(t *testing.T) {
+	type config struct {
+		Name    string            `json:"name"`
+		Port    int               `json:"port"`
+		Options map[string]string `json:"options,omitempty"`
+	}
+	meta := metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"}
+	configMap := func(key, value string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: meta, Data: map[string]string{key: value}}
+	}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		key      string
+		obj      interface{}
+
+		expectedModified bool
+		expectedValue    string
+		expectedVerbs    []string
+	}{
+		{
+			name:             "create json",
+			key:              "config.json",
+			obj:              config{Name: "operand", Port: 8443},
+			expectedModified: true,
+			expectedValue:    `{"name":"operand","port":8443}`,
+			expectedVerbs:    []string{"get", "get", "create"},
+		},
+		{
+			name:             "create yaml",
+			key:              "config.yaml",
+			obj:              config{Name: "operand", Port: 8443},
+			expectedModified: true,
+			expectedValue:    "name: operand\nport: 8443\n",
+			expectedVerbs:    []string{"get", "get", "create"},
+		},
+		{
+			name:          "reordered json is not updated",
+			existing:      []runtime.Object{configMap("config.json", `{"port": 8443, "options": {"b": "2", "a": "1"}, "name": "operand"}`)},
+			key:           "config.json",
+			obj:           config{Name: "operand", Port: 8443, Options: map[string]string{"a": "1", "b": "2"}},
+			expectedValue: `{"port": 8443, "options": {"b": "2", "a": "1"}, "name": "operand"}`,
+			expectedVerbs: []string{"get", "get"},
+		},
+		{
+			name:          "reordered yaml is not updated",
+			existing:      []runtime.Object{configMap("config.yaml", "port: 8443\nname: operand\n")},
+			key:           "config.yaml",
+			obj:           config{Name: "operand", Port: 8443},
+			expectedValue: "port: 8443\nname: operand\n",
+			expectedVerbs: []string{"get", "get"},
+		},
+		{
+			name:             "changed content is updated",
+			existing:         []runtime.Object{configMap("config.json", `{"port": 8080, "name": "operand"}`)},
+			key:              "config.json",
+			obj:              config{Name: "operand", Port: 8443},
+			expectedModified: true,
+			expectedValue:    `{"name":"operand","port":8443}`,
+			expectedVerbs:    []string{"get", "get", "update"},
+		},
+		{
+			name:             "unparsable content is updated",
+			existing:         []runtime.Object{configMap("config.json", `{"port": `)},
+			key:              "config.json",
+			obj:              config{Name: "operand", Port: 8443},
+			expectedModified: true,
+			expectedValue:    `{"name":"operand","port":8443}`,
+			expectedVerbs:    []string{"get", "get", "update"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+			actual, modified, err := ApplyConfigMapFromObject(context.TODO(), client.CoreV1(), recorder, meta, test.key, test.obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if modified != test.expectedModified {
+				t.Errorf("expected modified %v, got %v", test.expectedModified, modified)
+			}
+			if actual.Data[test.key] != test.expectedValue {
+				t.Errorf("expected value %q, got %q", test.expectedValue, actual.Data[test.key])
+			}
+			var verbs []string
+			for _, action := range client.Actions() {
+				verbs = append(verbs, action.GetVerb())
+			}
+			if !reflect.DeepEqual(verbs, test.expectedVerbs) {
+				t.Errorf("expected actions %v, got %v", test.expectedVerbs, verbs)
+			}
+		})
+	}
+}
+
+func TestApplyConfigMapWithForce(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		input    *corev1.ConfigMap
+		force    bool
+
+		expectedModified bool
+		expected         *corev1.ConfigMap
+	}{
+		{
+			name: "injected CA bundle is preserved without force",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"}},
+					Data: map[string]string{
+						"ca-bundle.crt": "injected",
+					},
+				},
+			},
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"}},
+			},
+
+			expectedModified: false,
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"}},
+				Data: map[string]string{
+					"ca-bundle.crt": "injected",
+				},
+			},
+		},
+		{
+			name: "injected CA bundle is overwritten with force",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"}},
+					Data: map[string]string{
+						"ca-bundle.crt": "injected",
+					},
+				},
+			},
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"}},
+				Data: map[string]string{
+					"other": "required",
+				},
+			},
+			force: true,
+
+			expectedModified: true,
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"}},
+				Data: map[string]string{
+					"other": "required",
+				},
+			},
+		},
+		{
+			name: "injected service CA is overwritten with force",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Annotations: map[string]string{"service.beta.openshift.io/inject-cabundle": "true"}},
+					Data: map[string]string{
+						"service-ca.crt": "injected",
+					},
+				},
+			},
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Annotations: map[string]string{"service.beta.openshift.io/inject-cabundle": "true"}},
+			},
+			force: true,
+
+			expectedModified: true,
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Annotations: map[string]string{"service.beta.openshift.io/inject-cabundle": "true"}},
+			},
+		},
+		{
+			name: "no changes with force",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"}},
+					Data: map[string]string{
+						"ca-bundle.crt": "required",
+					},
+				},
+			},
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"}},
+				Data: map[string]string{
+					"ca-bundle.crt": "required",
+				},
+			},
+			force: true,
+
+			expectedModified: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+			actual, actualModified, err := ApplyConfigMapWithForce(context.TODO(), client.CoreV1(), recorder, test.input, test.force)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			expectedEvents := 0
+			if test.expectedModified {
+				expectedEvents = 1
+			}
+			if len(recorder.Events()) != expectedEvents {
+				t.Errorf("expected %d event(s), got %v", expectedEvents, recorder.Events())
+			}
+			if test.expected == nil {
+				return
+			}
+			if !equality.Semantic.DeepEqual(test.expected, actual) {
+				t.Error(JSONPatchNoError(test.expected, actual))
+			}
+		})
+	}
+}
+
 func TestApplySecret(t *testing.T) {
 	m := metav1.ObjectMeta{
 		Name:        "test",
@@ -698,6 +1047,130 @@ func TestApplyNamespace(t *testing.T) {
 	}
 }
 
+func TestApplyResourceQuota(t *testing.T) {
+	quota := func(pods string, scopes ...corev1.ResourceQuotaScope) *corev1.ResourceQuota {
+		return &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "quota", Labels: map[string]string{"app": "operand"}},
+			Spec: corev1.ResourceQuotaSpec{
+				Hard:   corev1.ResourceList{corev1.ResourcePods: resource.MustParse(pods)},
+				Scopes: scopes,
+			},
+		}
+	}
+	// existingQuota returns a quota applied with the given spec, with an extra label and a status
+	existingQuota := func(pods string, scopes ...corev1.ResourceQuotaScope) *corev1.ResourceQuota {
+		q := quota(pods, scopes...)
+		if err := SetSpecHashAnnotation(&q.ObjectMeta, q.Spec); err != nil {
+			t.Fatal(err)
+		}
+		q.Labels["extra"] = "label"
+		q.Status = corev1.ResourceQuotaStatus{
+			Hard: q.Spec.Hard,
+			Used: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("3")},
+		}
+		return q
+	}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		input    *corev1.ResourceQuota
+
+		expectedModified bool
+		verifyActions    func(actions []clienttesting.Action, t *testing.T)
+	}{
+		{
+			name:  "create",
+			input: quota("10"),
+
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "resourcequotas") || actions[0].(clienttesting.GetAction).GetName() != "quota" {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("create", "resourcequotas") {
+					t.Error(spew.Sdump(actions))
+				}
+				actual := actions[1].(clienttesting.CreateAction).GetObject().(*corev1.ResourceQuota)
+				if len(actual.Annotations[specHashAnnotation]) == 0 {
+					t.Errorf("expected the spec hash annotation to be set, got %v", actual.Annotations)
+				}
+			},
+		},
+		{
+			name:     "no-op",
+			existing: []runtime.Object{existingQuota("10", corev1.ResourceQuotaScopeNotTerminating)},
+			input:    quota("10", corev1.ResourceQuotaScopeNotTerminating),
+
+			expectedModified: false,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "resourcequotas") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name:     "hard limit change",
+			existing: []runtime.Object{existingQuota("10")},
+			input:    quota("20"),
+
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "resourcequotas") {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := existingQuota("20")
+				expected.Status = existingQuota("10").Status
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.ResourceQuota)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+		{
+			name:     "scopes change",
+			existing: []runtime.Object{existingQuota("10")},
+			input:    quota("10", corev1.ResourceQuotaScopeBestEffort),
+
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "resourcequotas") {
+					t.Error(spew.Sdump(actions))
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.ResourceQuota)
+				if !reflect.DeepEqual(actual.Spec.Scopes, []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}) {
+					t.Errorf("expected the scopes to be updated, got %v", actual.Spec.Scopes)
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			_, actualModified, err := ApplyResourceQuota(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			test.verifyActions(client.Actions(), t)
+		})
+	}
+}
+
 func TestDeepCopyAvoidance(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -813,217 +1286,411 @@ func TestDeepCopyAvoidance(t *testing.T) {
 			},
 		},
 	}
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			client := fake.NewSimpleClientset(test.existing...)
-			cache := NewResourceCache()
-			_, actualModified, err := ApplyNamespaceImproved(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input, cache)
-			if err != nil {
-				t.Fatal(err)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			cache := NewResourceCache()
+			_, actualModified, err := ApplyNamespaceImproved(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input, cache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			test.verifyActions(client.Actions(), t)
+		})
+	}
+}
+
+func TestSyncSecret(t *testing.T) {
+	tt := []struct {
+		name                        string
+		sourceNamespace, sourceName string
+		targetNamespace, targetName string
+		ownerRefs                   []metav1.OwnerReference
+		existingObjects             []runtime.Object
+		expectedSecret              *corev1.Secret
+		expectedChanged             bool
+		expectedErr                 error
+	}{
+		{
+			name:            "syncing existing secret succeeds when the target is missing",
+			sourceNamespace: "sourceNamespace",
+			sourceName:      "sourceName",
+			targetNamespace: "targetNamespace",
+			targetName:      "targetName",
+			ownerRefs:       nil,
+			existingObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "sourceNamespace",
+						Name:      "sourceName",
+					},
+					Type: corev1.SecretTypeOpaque,
+					Data: map[string][]byte{"foo": []byte("bar")},
+				},
+			},
+			expectedSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "targetNamespace",
+					Name:      "targetName",
+				},
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{"foo": []byte("bar")},
+			},
+			expectedChanged: true,
+			expectedErr:     nil,
+		},
+		{
+			name:            "syncing existing secret succeeds when the target is present and up to date",
+			sourceNamespace: "sourceNamespace",
+			sourceName:      "sourceName",
+			targetNamespace: "targetNamespace",
+			targetName:      "targetName",
+			ownerRefs:       nil,
+			existingObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "sourceNamespace",
+						Name:      "sourceName",
+					},
+					Type: corev1.SecretTypeOpaque,
+					Data: map[string][]byte{"foo": []byte("bar")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "targetNamespace",
+						Name:      "targetName",
+					},
+					Type: corev1.SecretTypeOpaque,
+					Data: map[string][]byte{"foo": []byte("bar")},
+				},
+			},
+			expectedSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "targetNamespace",
+					Name:      "targetName",
+				},
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{"foo": []byte("bar")},
+			},
+			expectedChanged: false,
+			expectedErr:     nil,
+		},
+		{
+			name:            "syncing existing secret succeeds when the target is present and needs update",
+			sourceNamespace: "sourceNamespace",
+			sourceName:      "sourceName",
+			targetNamespace: "targetNamespace",
+			targetName:      "targetName",
+			ownerRefs:       nil,
+			existingObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "sourceNamespace",
+						Name:      "sourceName",
+					},
+					Type: corev1.SecretTypeOpaque,
+					Data: map[string][]byte{"foo": []byte("bar2")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "targetNamespace",
+						Name:      "targetName",
+					},
+					Type: corev1.SecretTypeOpaque,
+					Data: map[string][]byte{"foo": []byte("bar1")},
+				},
+			},
+			expectedSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "targetNamespace",
+					Name:      "targetName",
+				},
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{"foo": []byte("bar2")},
+			},
+			expectedChanged: true,
+			expectedErr:     nil,
+		},
+		{
+			name:            "syncing missing source secret doesn't fail",
+			sourceNamespace: "sourceNamespace",
+			sourceName:      "sourceName",
+			targetNamespace: "targetNamespace",
+			targetName:      "targetName",
+			ownerRefs:       nil,
+			existingObjects: []runtime.Object{},
+			expectedSecret:  nil,
+			expectedChanged: false,
+			expectedErr:     nil,
+		},
+		{
+			name:            "syncing missing source secret removes pre-existing target",
+			sourceNamespace: "sourceNamespace",
+			sourceName:      "sourceName",
+			targetNamespace: "targetNamespace",
+			targetName:      "targetName",
+			ownerRefs:       nil,
+			existingObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "targetNamespace",
+						Name:      "targetName",
+					},
+					Type: corev1.SecretTypeOpaque,
+					Data: map[string][]byte{"foo": []byte("bar1")},
+				},
+			},
+			expectedSecret:  nil,
+			expectedChanged: true,
+			expectedErr:     nil,
+		},
+		{
+			name:            "syncing service account token doesn't sync without the token being present",
+			sourceNamespace: "sourceNamespace",
+			sourceName:      "sourceName",
+			targetNamespace: "targetNamespace",
+			targetName:      "targetName",
+			ownerRefs:       nil,
+			existingObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "sourceNamespace",
+						Name:      "sourceName",
+					},
+					Type: corev1.SecretTypeServiceAccountToken,
+					Data: map[string][]byte{"foo": []byte("bar")},
+				},
+			},
+			expectedSecret:  nil,
+			expectedChanged: false,
+			expectedErr:     fmt.Errorf("secret sourceNamespace/sourceName doesn't have a token yet"),
+		},
+		{
+			name:            "syncing service account token strips \"managed\" annotations",
+			sourceNamespace: "sourceNamespace",
+			sourceName:      "sourceName",
+			targetNamespace: "targetNamespace",
+			targetName:      "targetName",
+			ownerRefs:       nil,
+			existingObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "sourceNamespace",
+						Name:      "sourceName",
+						Annotations: map[string]string{
+							corev1.ServiceAccountNameKey: "foo",
+							corev1.ServiceAccountUIDKey:  "bar",
+						},
+					},
+					Type: corev1.SecretTypeServiceAccountToken,
+					Data: map[string][]byte{"token": []byte("top-secret")},
+				},
+			},
+			expectedSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "targetNamespace",
+					Name:      "targetName",
+				},
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{"token": []byte("top-secret")},
+			},
+			expectedChanged: true,
+			expectedErr:     nil,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(tc.existingObjects...)
+			secret, changed, err := SyncSecret(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), tc.sourceNamespace, tc.sourceName, tc.targetNamespace, tc.targetName, tc.ownerRefs)
+
+			if !reflect.DeepEqual(err, tc.expectedErr) {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+				return
 			}
-			if test.expectedModified != actualModified {
-				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+
+			if !equality.Semantic.DeepEqual(secret, tc.expectedSecret) {
+				t.Errorf("secrets differ: %s", cmp.Diff(tc.expectedSecret, secret))
+			}
+
+			if changed != tc.expectedChanged {
+				t.Errorf("expected changed %t, got %t", tc.expectedChanged, changed)
 			}
-			test.verifyActions(client.Actions(), t)
 		})
 	}
 }
 
-func TestSyncSecret(t *testing.T) {
+func TestSyncConfigMap(t *testing.T) {
 	tt := []struct {
 		name                        string
 		sourceNamespace, sourceName string
 		targetNamespace, targetName string
 		ownerRefs                   []metav1.OwnerReference
 		existingObjects             []runtime.Object
-		expectedSecret              *corev1.Secret
+		expectedConfigMap           *corev1.ConfigMap
 		expectedChanged             bool
 		expectedErr                 error
 	}{
 		{
-			name:            "syncing existing secret succeeds when the target is missing",
+			name:            "syncing existing configmap succeeds when the target is missing",
 			sourceNamespace: "sourceNamespace",
 			sourceName:      "sourceName",
 			targetNamespace: "targetNamespace",
 			targetName:      "targetName",
 			ownerRefs:       nil,
 			existingObjects: []runtime.Object{
-				&corev1.Secret{
+				&corev1.ConfigMap{
 					ObjectMeta: metav1.ObjectMeta{
 						Namespace: "sourceNamespace",
 						Name:      "sourceName",
 					},
-					Type: corev1.SecretTypeOpaque,
-					Data: map[string][]byte{"foo": []byte("bar")},
+					Data: map[string]string{"foo": "bar"},
 				},
 			},
-			expectedSecret: &corev1.Secret{
+			expectedConfigMap: &corev1.ConfigMap{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "targetNamespace",
 					Name:      "targetName",
 				},
-				Type: corev1.SecretTypeOpaque,
-				Data: map[string][]byte{"foo": []byte("bar")},
+				Data: map[string]string{"foo": "bar"},
 			},
 			expectedChanged: true,
 			expectedErr:     nil,
 		},
 		{
-			name:            "syncing existing secret succeeds when the target is present and up to date",
+			name:            "syncing existing configmap succeeds when the target is present and up to date",
 			sourceNamespace: "sourceNamespace",
 			sourceName:      "sourceName",
 			targetNamespace: "targetNamespace",
 			targetName:      "targetName",
 			ownerRefs:       nil,
 			existingObjects: []runtime.Object{
-				&corev1.Secret{
+				&corev1.ConfigMap{
 					ObjectMeta: metav1.ObjectMeta{
 						Namespace: "sourceNamespace",
 						Name:      "sourceName",
 					},
-					Type: corev1.SecretTypeOpaque,
-					Data: map[string][]byte{"foo": []byte("bar")},
+					Data: map[string]string{"foo": "bar"},
 				},
-				&corev1.Secret{
+				&corev1.ConfigMap{
 					ObjectMeta: metav1.ObjectMeta{
 						Namespace: "targetNamespace",
 						Name:      "targetName",
 					},
-					Type: corev1.SecretTypeOpaque,
-					Data: map[string][]byte{"foo": []byte("bar")},
+					Data: map[string]string{"foo": "bar"},
 				},
 			},
-			expectedSecret: &corev1.Secret{
+			expectedConfigMap: &corev1.ConfigMap{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "targetNamespace",
 					Name:      "targetName",
 				},
-				Type: corev1.SecretTypeOpaque,
-				Data: map[string][]byte{"foo": []byte("bar")},
+				Data: map[string]string{"foo": "bar"},
 			},
 			expectedChanged: false,
 			expectedErr:     nil,
 		},
 		{
-			name:            "syncing existing secret succeeds when the target is present and needs update",
+			name:            "syncing existing configmap succeeds when the target is present and needs update",
 			sourceNamespace: "sourceNamespace",
 			sourceName:      "sourceName",
 			targetNamespace: "targetNamespace",
 			targetName:      "targetName",
 			ownerRefs:       nil,
 			existingObjects: []runtime.Object{
-				&corev1.Secret{
+				&corev1.ConfigMap{
 					ObjectMeta: metav1.ObjectMeta{
 						Namespace: "sourceNamespace",
 						Name:      "sourceName",
 					},
-					Type: corev1.SecretTypeOpaque,
-					Data: map[string][]byte{"foo": []byte("bar2")},
+					Data: map[string]string{"foo": "bar2"},
 				},
-				&corev1.Secret{
+				&corev1.ConfigMap{
 					ObjectMeta: metav1.ObjectMeta{
 						Namespace: "targetNamespace",
 						Name:      "targetName",
 					},
-					Type: corev1.SecretTypeOpaque,
-					Data: map[string][]byte{"foo": []byte("bar1")},
+					Data: map[string]string{"foo": "bar1"},
 				},
 			},
-			expectedSecret: &corev1.Secret{
+			expectedConfigMap: &corev1.ConfigMap{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "targetNamespace",
 					Name:      "targetName",
 				},
-				Type: corev1.SecretTypeOpaque,
-				Data: map[string][]byte{"foo": []byte("bar2")},
+				Data: map[string]string{"foo": "bar2"},
 			},
 			expectedChanged: true,
 			expectedErr:     nil,
 		},
 		{
-			name:            "syncing missing source secret doesn't fail",
-			sourceNamespace: "sourceNamespace",
-			sourceName:      "sourceName",
-			targetNamespace: "targetNamespace",
-			targetName:      "targetName",
-			ownerRefs:       nil,
-			existingObjects: []runtime.Object{},
-			expectedSecret:  nil,
-			expectedChanged: false,
-			expectedErr:     nil,
+			name:              "syncing missing source configmap doesn't fail",
+			sourceNamespace:   "sourceNamespace",
+			sourceName:        "sourceName",
+			targetNamespace:   "targetNamespace",
+			targetName:        "targetName",
+			ownerRefs:         nil,
+			existingObjects:   []runtime.Object{},
+			expectedConfigMap: nil,
+			expectedChanged:   false,
+			expectedErr:       nil,
 		},
 		{
-			name:            "syncing missing source secret removes pre-existing target",
+			name:            "syncing missing source configmap removes pre-existing target",
 			sourceNamespace: "sourceNamespace",
 			sourceName:      "sourceName",
 			targetNamespace: "targetNamespace",
 			targetName:      "targetName",
 			ownerRefs:       nil,
 			existingObjects: []runtime.Object{
-				&corev1.Secret{
+				&corev1.ConfigMap{
 					ObjectMeta: metav1.ObjectMeta{
 						Namespace: "targetNamespace",
 						Name:      "targetName",
 					},
-					Type: corev1.SecretTypeOpaque,
-					Data: map[string][]byte{"foo": []byte("bar1")},
+					Data: map[string]string{"foo": "bar1"},
 				},
 			},
-			expectedSecret:  nil,
-			expectedChanged: true,
-			expectedErr:     nil,
+			expectedConfigMap: nil,
+			expectedChanged:   true,
+			expectedErr:       nil,
 		},
 		{
-			name:            "syncing service account token doesn't sync without the token being present",
+			name:            "syncing configmap keeps the CA bundle injected into the target",
 			sourceNamespace: "sourceNamespace",
 			sourceName:      "sourceName",
 			targetNamespace: "targetNamespace",
 			targetName:      "targetName",
 			ownerRefs:       nil,
 			existingObjects: []runtime.Object{
-				&corev1.Secret{
+				&corev1.ConfigMap{
 					ObjectMeta: metav1.ObjectMeta{
 						Namespace: "sourceNamespace",
 						Name:      "sourceName",
+						Labels:    map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"},
 					},
-					Type: corev1.SecretTypeServiceAccountToken,
-					Data: map[string][]byte{"foo": []byte("bar")},
+					Data: map[string]string{"foo": "bar"},
 				},
-			},
-			expectedSecret:  nil,
-			expectedChanged: false,
-			expectedErr:     fmt.Errorf("secret sourceNamespace/sourceName doesn't have a token yet"),
-		},
-		{
-			name:            "syncing service account token strips \"managed\" annotations",
-			sourceNamespace: "sourceNamespace",
-			sourceName:      "sourceName",
-			targetNamespace: "targetNamespace",
-			targetName:      "targetName",
-			ownerRefs:       nil,
-			existingObjects: []runtime.Object{
-				&corev1.Secret{
+				&corev1.ConfigMap{
 					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "sourceNamespace",
-						Name:      "sourceName",
-						Annotations: map[string]string{
-							corev1.ServiceAccountNameKey: "foo",
-							corev1.ServiceAccountUIDKey:  "bar",
-						},
+						Namespace: "targetNamespace",
+						Name:      "targetName",
+						Labels:    map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"},
 					},
-					Type: corev1.SecretTypeServiceAccountToken,
-					Data: map[string][]byte{"token": []byte("top-secret")},
+					Data: map[string]string{"foo": "bar", "ca-bundle.crt": "injected"},
 				},
 			},
-			expectedSecret: &corev1.Secret{
+			expectedConfigMap: &corev1.ConfigMap{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "targetNamespace",
 					Name:      "targetName",
+					Labels:    map[string]string{"config.openshift.io/inject-trusted-cabundle": "true"},
 				},
-				Type: corev1.SecretTypeOpaque,
-				Data: map[string][]byte{"token": []byte("top-secret")},
+				Data: map[string]string{"foo": "bar", "ca-bundle.crt": "injected"},
 			},
-			expectedChanged: true,
+			expectedChanged: false,
 			expectedErr:     nil,
 		},
 	}
@@ -1031,15 +1698,15 @@ func TestSyncSecret(t *testing.T) {
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			client := fake.NewSimpleClientset(tc.existingObjects...)
-			secret, changed, err := SyncSecret(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), tc.sourceNamespace, tc.sourceName, tc.targetNamespace, tc.targetName, tc.ownerRefs)
+			configMap, changed, err := SyncConfigMap(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), tc.sourceNamespace, tc.sourceName, tc.targetNamespace, tc.targetName, tc.ownerRefs)
 
 			if !reflect.DeepEqual(err, tc.expectedErr) {
 				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
 				return
 			}
 
-			if !equality.Semantic.DeepEqual(secret, tc.expectedSecret) {
-				t.Errorf("secrets differ: %s", cmp.Diff(tc.expectedSecret, secret))
+			if !equality.Semantic.DeepEqual(configMap, tc.expectedConfigMap) {
+				t.Errorf("configmaps differ: %s", cmp.Diff(tc.expectedConfigMap, configMap))
 			}
 
 			if changed != tc.expectedChanged {
@@ -1310,6 +1977,125 @@ func TestSyncPartialSync(t *testing.T) {
 	}
 }
 
+func TestSyncPartialSecretWithKeyMapping(t *testing.T) {
+	tt := []struct {
+		name            string
+		keyMapping      map[string]string
+		existingObjects []runtime.Object
+		expectedSecret  *corev1.Secret
+		expectedChanged bool
+		expectedErr     error
+	}{
+		{
+			name:       "syncing existing secret renames the mapped keys",
+			keyMapping: map[string]string{"tls.crt": "ca.crt", "missing": "other"},
+			existingObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "sourceNamespace", Name: "sourceName"},
+					Type:       corev1.SecretTypeOpaque,
+					Data: map[string][]byte{
+						"tls.crt": []byte("cert"),
+						"tls.key": []byte("key"),
+					},
+				},
+			},
+			expectedSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "targetNamespace", Name: "targetName"},
+				Type:       corev1.SecretTypeOpaque,
+				Data:       map[string][]byte{"ca.crt": []byte("cert")},
+			},
+			expectedChanged: true,
+		},
+		{
+			name:       "syncing existing secret drops the keys of the target that are not mapped",
+			keyMapping: map[string]string{"tls.crt": "ca.crt"},
+			existingObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "sourceNamespace", Name: "sourceName"},
+					Type:       corev1.SecretTypeOpaque,
+					Data:       map[string][]byte{"tls.crt": []byte("cert2")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "targetNamespace", Name: "targetName"},
+					Type:       corev1.SecretTypeOpaque,
+					Data: map[string][]byte{
+						"ca.crt":  []byte("cert1"),
+						"tls.crt": []byte("cert1"),
+					},
+				},
+			},
+			expectedSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "targetNamespace", Name: "targetName"},
+				Type:       corev1.SecretTypeOpaque,
+				Data:       map[string][]byte{"ca.crt": []byte("cert2")},
+			},
+			expectedChanged: true,
+		},
+		{
+			name:       "syncing existing secret deletes the target when the source secret does not contain any mapped keys",
+			keyMapping: map[string]string{"tls.crt": "ca.crt"},
+			existingObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "sourceNamespace", Name: "sourceName"},
+					Type:       corev1.SecretTypeOpaque,
+					Data:       map[string][]byte{"tls.key": []byte("key")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "targetNamespace", Name: "targetName"},
+					Type:       corev1.SecretTypeOpaque,
+					Data:       map[string][]byte{"ca.crt": []byte("cert")},
+				},
+			},
+			expectedSecret:  nil,
+			expectedChanged: true,
+		},
+		{
+			name:       "syncing service account token doesn't sync without the token being present",
+			keyMapping: map[string]string{"ca.crt": "service-ca.crt"},
+			existingObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "sourceNamespace", Name: "sourceName"},
+					Type:       corev1.SecretTypeServiceAccountToken,
+					Data:       map[string][]byte{"ca.crt": []byte("cert")},
+				},
+			},
+			expectedErr: fmt.Errorf("secret sourceNamespace/sourceName doesn't have a token yet"),
+		},
+		{
+			name:       "syncing existing secret fails when several source keys are mapped to the same target key",
+			keyMapping: map[string]string{"tls.crt": "ca.crt", "ca-bundle.crt": "ca.crt", "tls.key": "tls.key"},
+			existingObjects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "sourceNamespace", Name: "sourceName"},
+					Type:       corev1.SecretTypeTLS,
+					Data:       map[string][]byte{"tls.crt": []byte("cert"), "ca-bundle.crt": []byte("bundle"), "tls.key": []byte("key")},
+				},
+			},
+			expectedErr: fmt.Errorf(`source keys "ca-bundle.crt" and "tls.crt" are both mapped to target key "ca.crt"`),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(tc.existingObjects...)
+			secret, changed, err := SyncPartialSecretWithKeyMapping(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), "sourceNamespace", "sourceName", "targetNamespace", "targetName", tc.keyMapping, nil)
+
+			if !reflect.DeepEqual(err, tc.expectedErr) {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+				return
+			}
+
+			if !equality.Semantic.DeepEqual(secret, tc.expectedSecret) {
+				t.Errorf("secrets differ: %s", cmp.Diff(tc.expectedSecret, secret))
+			}
+
+			if changed != tc.expectedChanged {
+				t.Errorf("expected changed %t, got %t", tc.expectedChanged, changed)
+			}
+		})
+	}
+}
+
 func TestSyncSecretWithLabels(t *testing.T) {
 	tt := []struct {
 		name                        string
@@ -1587,6 +2373,11 @@ func TestApplyService(t *testing.T) {
 	// srv1Port where user changed an untracked field without changing spec hash
 	userChangedSrv1Untracked := withSpecHash(srv1Port)
 	userChangedSrv1Untracked.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeCluster
+	// srv1Port where user changed type and removed the spec hash
+	userChangedSrv1TypeRemovedHash := srv1Port.DeepCopy()
+	userChangedSrv1TypeRemovedHash.Spec.Type = corev1.ServiceTypeClusterIP
+	// srv1Port where user removed the spec hash
+	userRemovedSrv1Hash := srv1Port.DeepCopy()
 
 	srv2Ports := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1698,6 +2489,49 @@ func TestApplyService(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:             "overwrite when user changes type and removes the spec hash",
+			existingObjects:  []runtime.Object{userChangedSrv1TypeRemovedHash},
+			input:            srv1Port,
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "services") || actions[0].(clienttesting.GetAction).GetName() != "srv" {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "services") {
+					t.Error(spew.Sdump(actions))
+				}
+
+				expected := withSpecHash(srv1Port)
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.Service)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+		{
+			name:             "restore spec hash when user removes it",
+			existingObjects:  []runtime.Object{userRemovedSrv1Hash},
+			input:            srv1Port,
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "services") {
+					t.Error(spew.Sdump(actions))
+				}
+
+				expected := withSpecHash(srv1Port)
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.Service)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
 		{
 			name:             "no overwrite when user changes an untracked field",
 			existingObjects:  []runtime.Object{userChangedSrv1Untracked},
@@ -0,0 +1,78 @@
+package resourceapply
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxEventDiffKeys is the maximum number of changed keys listed in an event message.
+	maxEventDiffKeys = 10
+	// maxEventDiffValueLength is the maximum length of a value shown in an event message.
+	maxEventDiffValueLength = 40
+)
+
+// dataDelta returns one line per key added to, removed from or changed between the existing and the updated data
+// of a resource, sorted by key and prefixed by field. The values are described by valueFn. When valueFn is nil,
+// only the key names are listed, which must be used for values that must not be logged, like secret data.
+func dataDelta[V any](field string, existing, updated map[string]V, equal func(a, b V) bool, valueFn func(V) string) []string {
+	keys := make(map[string]struct{}, len(existing)+len(updated))
+	for key := range existing {
+		keys[key] = struct{}{}
+	}
+	for key := range updated {
+		keys[key] = struct{}{}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var lines []string
+	for _, key := range sortedKeys {
+		existingValue, existed := existing[key]
+		updatedValue, exists := updated[key]
+		switch {
+		case !existed && valueFn != nil:
+			lines = append(lines, fmt.Sprintf("%s.%s: added %s", field, key, valueFn(updatedValue)))
+		case !existed:
+			lines = append(lines, fmt.Sprintf("%s.%s: added", field, key))
+		case !exists:
+			lines = append(lines, fmt.Sprintf("%s.%s: removed", field, key))
+		case equal(existingValue, updatedValue):
+		case valueFn != nil:
+			lines = append(lines, fmt.Sprintf("%s.%s: changed %s -> %s", field, key, valueFn(existingValue), valueFn(updatedValue)))
+		default:
+			lines = append(lines, fmt.Sprintf("%s.%s: changed", field, key))
+		}
+	}
+	return lines
+}
+
+// formatDataDelta joins the lines of the given deltas for an event message. At most maxEventDiffKeys lines are
+// kept, the number of the dropped ones is appended instead.
+func formatDataDelta(deltas ...[]string) string {
+	var lines []string
+	for _, delta := range deltas {
+		lines = append(lines, delta...)
+	}
+	if len(lines) > maxEventDiffKeys {
+		lines = append(lines[:maxEventDiffKeys], fmt.Sprintf("and %d more keys", len(lines)-maxEventDiffKeys))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// eventDiffValue quotes value for an event message, truncated to maxEventDiffValueLength.
+func eventDiffValue(value string) string {
+	if len(value) > maxEventDiffValueLength {
+		return strconv.Quote(value[:maxEventDiffValueLength]) + "..."
+	}
+	return strconv.Quote(value)
+}
+
+func stringsEqual(a, b string) bool {
+	return a == b
+}
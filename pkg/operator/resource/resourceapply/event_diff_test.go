@@ -0,0 +1,142 @@
+package resourceapply
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestDataDelta(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  map[string]string
+		updated   map[string]string
+		keysOnly  bool
+		expected  []string
+		formatted string
+	}{
+		{
+			name:     "unchanged",
+			existing: map[string]string{"a": "1"},
+			updated:  map[string]string{"a": "1"},
+		},
+		{
+			name:      "added, removed and changed keys with values",
+			existing:  map[string]string{"b": "old", "c": "gone", "d": "same"},
+			updated:   map[string]string{"a": "new", "b": "changed", "d": "same"},
+			expected:  []string{`data.a: added "new"`, `data.b: changed "old" -> "changed"`, "data.c: removed"},
+			formatted: "data.a: added \"new\"\ndata.b: changed \"old\" -> \"changed\"\ndata.c: removed",
+		},
+		{
+			name:      "key names only",
+			existing:  map[string]string{"b": "old", "c": "gone"},
+			updated:   map[string]string{"a": "new", "b": "changed"},
+			keysOnly:  true,
+			expected:  []string{"data.a: added", "data.b: changed", "data.c: removed"},
+			formatted: "data.a: added\ndata.b: changed\ndata.c: removed",
+		},
+		{
+			name:      "long values are truncated",
+			existing:  map[string]string{},
+			updated:   map[string]string{"a": strings.Repeat("x", 100)},
+			expected:  []string{fmt.Sprintf("data.a: added %q...", strings.Repeat("x", maxEventDiffValueLength))},
+			formatted: fmt.Sprintf("data.a: added %q...", strings.Repeat("x", maxEventDiffValueLength)),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			valueFn := eventDiffValue
+			if tc.keysOnly {
+				valueFn = nil
+			}
+			delta := dataDelta("data", tc.existing, tc.updated, stringsEqual, valueFn)
+			if strings.Join(delta, "|") != strings.Join(tc.expected, "|") {
+				t.Errorf("expected delta %q, got %q", tc.expected, delta)
+			}
+			if formatted := formatDataDelta(delta); formatted != tc.formatted {
+				t.Errorf("expected %q, got %q", tc.formatted, formatted)
+			}
+		})
+	}
+}
+
+func TestFormatDataDeltaTruncates(t *testing.T) {
+	updated := map[string]string{}
+	for i := 0; i < maxEventDiffKeys+5; i++ {
+		updated[fmt.Sprintf("key-%02d", i)] = "value"
+	}
+	lines := strings.Split(formatDataDelta(dataDelta("data", nil, updated, stringsEqual, nil)), "\n")
+	if len(lines) != maxEventDiffKeys+1 {
+		t.Fatalf("expected %d lines, got %q", maxEventDiffKeys+1, lines)
+	}
+	if last := lines[len(lines)-1]; last != "and 5 more keys" {
+		t.Errorf("unexpected last line %q", last)
+	}
+}
+
+func TestApplyEventsIncludeDataDelta(t *testing.T) {
+	ctx := context.TODO()
+	client := fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+			Data:       map[string]string{"kept": "value", "changed": "old", "removed": "value"},
+			BinaryData: map[string][]byte{"binary": []byte("old")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"password": []byte("old-secret-value")},
+		},
+	)
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+	_, _, err := ApplyConfigMap(ctx, client.CoreV1(), recorder, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+		Data:       map[string]string{"kept": "value", "changed": "new", "added": "value"},
+		BinaryData: map[string][]byte{"binary": []byte("new")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = ApplySecret(ctx, client.CoreV1(), recorder, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"password": []byte("new-secret-value"), "token": []byte("token-value")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorded := recorder.Events()
+	if len(recorded) != 2 {
+		t.Fatalf("expected 2 events, got %v", recorded)
+	}
+	expectedConfigMapMessage := "Updated ConfigMap/foo -n one-ns:\n" +
+		"data.added: added \"value\"\n" +
+		"data.changed: changed \"old\" -> \"new\"\n" +
+		"data.removed: removed\n" +
+		"binaryData.binary: changed"
+	if recorded[0].Reason != "ConfigMapUpdated" || recorded[0].Message != expectedConfigMapMessage {
+		t.Errorf("unexpected configmap event %s: %q", recorded[0].Reason, recorded[0].Message)
+	}
+	expectedSecretMessage := "Updated Secret/foo -n one-ns:\n" +
+		"data.password: changed\n" +
+		"data.token: added"
+	if recorded[1].Reason != "SecretUpdated" || recorded[1].Message != expectedSecretMessage {
+		t.Errorf("unexpected secret event %s: %q", recorded[1].Reason, recorded[1].Message)
+	}
+	for _, value := range []string{"old-secret-value", "new-secret-value", "token-value"} {
+		if strings.Contains(recorded[1].Message, value) {
+			t.Errorf("secret event message contains the secret value %q", value)
+		}
+	}
+}
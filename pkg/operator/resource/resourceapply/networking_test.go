@@ -0,0 +1,112 @@
+package resourceapply
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestApplyNetworkPolicy(t *testing.T) {
+	networkPolicy := func(app string, ports ...networkingv1.NetworkPolicyPort) *networkingv1.NetworkPolicy {
+		return &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "allow-operand", Labels: map[string]string{"app": "operand"}},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+				Ingress:     []networkingv1.NetworkPolicyIngressRule{{Ports: ports}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			},
+		}
+	}
+	withSpecHash := func(policy *networkingv1.NetworkPolicy) *networkingv1.NetworkPolicy {
+		policy = policy.DeepCopy()
+		if err := SetSpecHashAnnotation(&policy.ObjectMeta, policy.Spec); err != nil {
+			t.Fatal(err)
+		}
+		return policy
+	}
+	port := func(port int32) networkingv1.NetworkPolicyPort {
+		return networkingv1.NetworkPolicyPort{Port: ptr.To(intstr.FromInt32(port))}
+	}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		required *networkingv1.NetworkPolicy
+
+		expectedModified bool
+		expectedVerbs    []string
+	}{
+		{
+			name:             "create",
+			required:         networkPolicy("operand", port(8443)),
+			expectedModified: true,
+			expectedVerbs:    []string{"get", "create"},
+		},
+		{
+			name:          "no-op",
+			existing:      []runtime.Object{withSpecHash(networkPolicy("operand", port(8443)))},
+			required:      networkPolicy("operand", port(8443)),
+			expectedVerbs: []string{"get"},
+		},
+		{
+			name:             "update ingress rules",
+			existing:         []runtime.Object{withSpecHash(networkPolicy("operand", port(8443)))},
+			required:         networkPolicy("operand", port(8443), port(9443)),
+			expectedModified: true,
+			expectedVerbs:    []string{"get", "update"},
+		},
+		{
+			name:             "update pod selector",
+			existing:         []runtime.Object{withSpecHash(networkPolicy("operand", port(8443)))},
+			required:         networkPolicy("other", port(8443)),
+			expectedModified: true,
+			expectedVerbs:    []string{"get", "update"},
+		},
+		{
+			name:             "update without spec hash",
+			existing:         []runtime.Object{networkPolicy("operand", port(8443))},
+			required:         networkPolicy("operand", port(8443)),
+			expectedModified: true,
+			expectedVerbs:    []string{"get", "update"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+			actual, modified, err := ApplyNetworkPolicy(context.TODO(), client.NetworkingV1(), recorder, test.required)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if modified != test.expectedModified {
+				t.Errorf("expected modified %v, got %v", test.expectedModified, modified)
+			}
+			if !equality.Semantic.DeepEqual(actual.Spec, test.required.Spec) {
+				t.Errorf("expected spec %v, got %v", test.required.Spec, actual.Spec)
+			}
+			if len(actual.Annotations[specHashAnnotation]) == 0 {
+				t.Errorf("expected the spec hash annotation to be set")
+			}
+			var verbs []string
+			for _, action := range client.Actions() {
+				verbs = append(verbs, action.GetVerb())
+			}
+			if !equality.Semantic.DeepEqual(verbs, test.expectedVerbs) {
+				t.Errorf("expected actions %v, got %v", test.expectedVerbs, verbs)
+			}
+		})
+	}
+}
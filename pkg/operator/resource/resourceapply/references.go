@@ -0,0 +1,186 @@
+package resourceapply
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// MissingReference is a ConfigMap or Secret referenced by an object that exists neither in the cluster
+// nor in the batch of objects that is being applied.
+type MissingReference struct {
+	// Object is the object holding the reference.
+	Object runtime.Object
+	// Resource is either "configmaps" or "secrets".
+	Resource  string
+	Namespace string
+	Name      string
+}
+
+func (r MissingReference) String() string {
+	return fmt.Sprintf("%s %s/%s", r.Resource, r.Namespace, r.Name)
+}
+
+type objectReference struct {
+	resource  string
+	namespace string
+	name      string
+}
+
+// DryRunReferenceCheck reports the ConfigMaps and Secrets referenced by the pod specs of objs that are
+// neither part of objs nor exist in the cluster. It is meant to be called before applying objs, so that
+// objects depending on something that will never show up can be reported instead of crashlooping.
+// Pods, Deployments, DaemonSets, StatefulSets, ReplicaSets, Jobs and CronJobs are checked, optional references
+// are ignored.
+func DryRunReferenceCheck(ctx context.Context, client coreclientv1.CoreV1Interface, objs []runtime.Object) ([]MissingReference, error) {
+	inBatch := map[objectReference]bool{}
+	for _, obj := range objs {
+		switch t := obj.(type) {
+		case *corev1.ConfigMap:
+			inBatch[objectReference{resource: "configmaps", namespace: t.Namespace, name: t.Name}] = true
+		case *corev1.Secret:
+			inBatch[objectReference{resource: "secrets", namespace: t.Namespace, name: t.Name}] = true
+		}
+	}
+
+	exists := map[objectReference]bool{}
+	var missing []MissingReference
+	for _, obj := range objs {
+		podSpec := podSpecFor(obj)
+		if podSpec == nil {
+			continue
+		}
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range podSpecReferences(objMeta.GetNamespace(), podSpec) {
+			if inBatch[ref] {
+				continue
+			}
+			found, checked := exists[ref]
+			if !checked {
+				found, err = referenceExists(ctx, client, ref)
+				if err != nil {
+					return nil, err
+				}
+				exists[ref] = found
+			}
+			if !found {
+				missing = append(missing, MissingReference{Object: obj, Resource: ref.resource, Namespace: ref.namespace, Name: ref.name})
+			}
+		}
+	}
+	return missing, nil
+}
+
+func podSpecFor(obj runtime.Object) *corev1.PodSpec {
+	switch t := obj.(type) {
+	case *corev1.Pod:
+		return &t.Spec
+	case *appsv1.Deployment:
+		return &t.Spec.Template.Spec
+	case *appsv1.DaemonSet:
+		return &t.Spec.Template.Spec
+	case *appsv1.StatefulSet:
+		return &t.Spec.Template.Spec
+	case *appsv1.ReplicaSet:
+		return &t.Spec.Template.Spec
+	case *batchv1.Job:
+		return &t.Spec.Template.Spec
+	case *batchv1.CronJob:
+		return &t.Spec.JobTemplate.Spec.Template.Spec
+	}
+	return nil
+}
+
+// podSpecReferences returns the required ConfigMaps and Secrets referenced by volumes, environment variables
+// and image pull secrets of podSpec, without duplicates.
+func podSpecReferences(namespace string, podSpec *corev1.PodSpec) []objectReference {
+	var refs []objectReference
+	seen := map[objectReference]bool{}
+	add := func(resource, name string, optional *bool) {
+		if len(name) == 0 || (optional != nil && *optional) {
+			return
+		}
+		ref := objectReference{resource: resource, namespace: namespace, name: name}
+		if seen[ref] {
+			return
+		}
+		seen[ref] = true
+		refs = append(refs, ref)
+	}
+
+	for _, volume := range podSpec.Volumes {
+		if volume.ConfigMap != nil {
+			add("configmaps", volume.ConfigMap.Name, volume.ConfigMap.Optional)
+		}
+		if volume.Secret != nil {
+			add("secrets", volume.Secret.SecretName, volume.Secret.Optional)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					add("configmaps", source.ConfigMap.Name, source.ConfigMap.Optional)
+				}
+				if source.Secret != nil {
+					add("secrets", source.Secret.Name, source.Secret.Optional)
+				}
+			}
+		}
+	}
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for _, container := range containers {
+			for _, env := range container.Env {
+				if env.ValueFrom == nil {
+					continue
+				}
+				if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+					add("configmaps", ref.Name, ref.Optional)
+				}
+				if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+					add("secrets", ref.Name, ref.Optional)
+				}
+			}
+			for _, envFrom := range container.EnvFrom {
+				if ref := envFrom.ConfigMapRef; ref != nil {
+					add("configmaps", ref.Name, ref.Optional)
+				}
+				if ref := envFrom.SecretRef; ref != nil {
+					add("secrets", ref.Name, ref.Optional)
+				}
+			}
+		}
+	}
+	for _, pullSecret := range podSpec.ImagePullSecrets {
+		add("secrets", pullSecret.Name, nil)
+	}
+	return refs
+}
+
+func referenceExists(ctx context.Context, client coreclientv1.CoreV1Interface, ref objectReference) (bool, error) {
+	var err error
+	switch ref.resource {
+	case "configmaps":
+		_, err = client.ConfigMaps(ref.namespace).Get(ctx, ref.name, metav1.GetOptions{})
+	case "secrets":
+		_, err = client.Secrets(ref.namespace).Get(ctx, ref.name, metav1.GetOptions{})
+	default:
+		return false, fmt.Errorf("unsupported reference to %s", ref.resource)
+	}
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
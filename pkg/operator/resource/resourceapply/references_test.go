@@ -0,0 +1,110 @@
+package resourceapply
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func TestDryRunReferenceCheck(t *testing.T) {
+	deployment := func(volumes []corev1.Volume, envFrom []corev1.EnvFromSource) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "operand"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Volumes: volumes,
+						Containers: []corev1.Container{
+							{Name: "operand", EnvFrom: envFrom},
+						},
+					},
+				},
+			},
+		}
+	}
+	configMapVolume := func(name string, optional *bool) []corev1.Volume {
+		return []corev1.Volume{{
+			Name: "config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: name},
+					Optional:             optional,
+				},
+			},
+		}}
+	}
+	secretEnv := []corev1.EnvFromSource{{
+		SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "credentials"}},
+	}}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		batch    []runtime.Object
+		expected []string
+	}{
+		{
+			name:     "deployment referencing a missing configmap",
+			batch:    []runtime.Object{deployment(configMapVolume("config", nil), nil)},
+			expected: []string{"configmaps ns/config"},
+		},
+		{
+			name:     "deployment referencing an existing configmap",
+			existing: []runtime.Object{&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "config"}}},
+			batch:    []runtime.Object{deployment(configMapVolume("config", nil), nil)},
+		},
+		{
+			name: "deployment referencing a configmap in the batch",
+			batch: []runtime.Object{
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "config"}},
+				deployment(configMapVolume("config", nil), nil),
+			},
+		},
+		{
+			name:     "configmap in another namespace",
+			existing: []runtime.Object{&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "config"}}},
+			batch:    []runtime.Object{deployment(configMapVolume("config", nil), nil)},
+			expected: []string{"configmaps ns/config"},
+		},
+		{
+			name:  "optional configmap",
+			batch: []runtime.Object{deployment(configMapVolume("config", ptr.To(true)), nil)},
+		},
+		{
+			name:     "missing configmap and secret",
+			batch:    []runtime.Object{deployment(configMapVolume("config", nil), secretEnv)},
+			expected: []string{"configmaps ns/config", "secrets ns/credentials"},
+		},
+		{
+			name:  "objects without pod spec",
+			batch: []runtime.Object{&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "svc"}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			missing, err := DryRunReferenceCheck(context.TODO(), client.CoreV1(), test.batch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actual []string
+			for _, ref := range missing {
+				if ref.Object == nil {
+					t.Errorf("missing referencing object for %s", ref)
+				}
+				actual = append(actual, ref.String())
+			}
+			if !reflect.DeepEqual(test.expected, actual) {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
@@ -6,9 +6,13 @@ import (
 	"io"
 	"reflect"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 )
 
@@ -42,6 +46,158 @@ func NewResourceCache() *resourceCache {
 
 var noCache *resourceCache
 
+// NewPrewarmedResourceCache returns a resource cache pre-populated with the given required objects, so that
+// the first ApplyFoo after start can return early instead of comparing and possibly updating the resource.
+// A required object is only cached when one of the informers holds an existing object that the apply would not
+// change: its labels and annotations contain the required ones and its content equals the required content.
+// All other objects are applied as usual.
+// The informers must be synced before calling this.
+func NewPrewarmedResourceCache(required []runtime.Object, informers ...cache.SharedIndexInformer) *resourceCache {
+	c := NewResourceCache()
+	for _, obj := range required {
+		kind, name, namespace, resourceHash, err := getResourceMetadata(obj)
+		if err != nil {
+			klog.V(4).Infof("unable to pre-warm the resource cache with %T: %v", obj, err)
+			continue
+		}
+		existing := findExistingForPrewarm(kind, name, namespace, obj, informers)
+		if existing == nil {
+			continue
+		}
+		resourceVersion, err := getResourceVersion(existing)
+		if err != nil {
+			continue
+		}
+		c.cache[cachedVersionKey{name: name, namespace: namespace, kind: kind}] = cachedResource{resourceHash, resourceVersion}
+		klog.V(7).Infof("pre-warmed resourceVersion of %s:%s:%s %s", name, kind, namespace, resourceVersion)
+	}
+	return c
+}
+
+// findExistingForPrewarm returns the object of the informers matching the required object, if the apply of the
+// required object would not change it.
+func findExistingForPrewarm(kind schema.GroupKind, name, namespace string, required runtime.Object, informers []cache.SharedIndexInformer) runtime.Object {
+	key := name
+	if len(namespace) > 0 {
+		key = namespace + "/" + name
+	}
+	requiredContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(required)
+	if err != nil {
+		return nil
+	}
+	for _, field := range []string{"resourceVersion", "uid", "creationTimestamp", "generation", "managedFields"} {
+		unstructured.RemoveNestedField(requiredContent, "metadata", field)
+	}
+	delete(requiredContent, "apiVersion")
+	delete(requiredContent, "kind")
+
+	for _, informer := range informers {
+		item, exists, err := informer.GetStore().GetByKey(key)
+		if err != nil || !exists {
+			continue
+		}
+		existing, ok := item.(runtime.Object)
+		if !ok {
+			continue
+		}
+		existingKind, _, _, _, err := getResourceMetadata(existing)
+		if err != nil || existingKind != kind {
+			continue
+		}
+		existingContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(existing)
+		if err != nil {
+			continue
+		}
+		if isUnstructuredApplied(requiredContent, existingContent) {
+			return existing
+		}
+	}
+	return nil
+}
+
+// replacedFields are the fields the apply functions replace as a whole, so that keys missing in the required
+// object are removed from the existing object.
+var replacedFields = []string{"data", "binaryData", "stringData", "spec"}
+
+// isUnstructuredApplied returns true if applying required would not change existing. The metadata is merged by
+// the apply functions, so it only has to be a subset of the existing metadata. Any other field set in required,
+// and the replaced fields even if unset in required, must equal the existing field. Fields only set in existing,
+// like the status or defaulted fields, are ignored.
+func isUnstructuredApplied(required, existing map[string]interface{}) bool {
+	if !isUnstructuredSubset(required["metadata"], existing["metadata"]) {
+		return false
+	}
+	fields := sets.New[string](replacedFields...)
+	for field := range required {
+		fields.Insert(field)
+	}
+	fields.Delete("metadata", "status")
+	for field := range fields {
+		if !isUnstructuredEqual(required[field], existing[field]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isUnstructuredEqual returns true if required and existing are equal, treating unset and empty maps and lists
+// as equal.
+func isUnstructuredEqual(required, existing interface{}) bool {
+	if isUnstructuredEmpty(required) && isUnstructuredEmpty(existing) {
+		return true
+	}
+	return equality.Semantic.DeepEqual(required, existing)
+}
+
+func isUnstructuredEmpty(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(value) == 0
+	case []interface{}:
+		return len(value) == 0
+	default:
+		return false
+	}
+}
+
+// isUnstructuredSubset returns true if every field set in required is set to the same value in existing.
+// Lists must have the same length, and their items are compared in order.
+func isUnstructuredSubset(required, existing interface{}) bool {
+	switch required := required.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		if len(required) == 0 && existing == nil {
+			return true
+		}
+		existingMap, ok := existing.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range required {
+			if !isUnstructuredSubset(v, existingMap[k]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		existingList, ok := existing.([]interface{})
+		if !ok || len(required) != len(existingList) {
+			return false
+		}
+		for i := range required {
+			if !isUnstructuredSubset(required[i], existingList[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return equality.Semantic.DeepEqual(required, existing)
+	}
+}
+
 func getResourceMetadata(obj runtime.Object) (schema.GroupKind, string, string, string, error) {
 	if obj == nil {
 		return schema.GroupKind{}, "", "", "", fmt.Errorf("nil object has no metadata")
@@ -1,8 +1,19 @@
 package resourceapply
 
 import (
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"context"
 	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
 )
 
 func TestHashOfResourceStructUnstructured(t *testing.T) {
@@ -34,3 +45,76 @@ func TestHashOfResourceStructUnstructured(t *testing.T) {
 		t.Errorf("expected a different hash after modifying the object")
 	}
 }
+
+func TestNewPrewarmedResourceCache(t *testing.T) {
+	existing := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "operand", ResourceVersion: "42", Labels: map[string]string{"app": "operand", "extra": "value"}},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+	}
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &corev1.Namespace{}, 0, cache.Indexers{})
+	if err := informer.GetStore().Add(existing); err != nil {
+		t.Fatal(err)
+	}
+
+	required := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "operand", Labels: map[string]string{"app": "operand"}}}
+	changed := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "operand", Labels: map[string]string{"app": "changed"}}}
+
+	resourceCache := NewPrewarmedResourceCache([]runtime.Object{required}, informer)
+	if !resourceCache.SafeToSkipApply(required, existing) {
+		t.Errorf("expected the unchanged namespace to be pre-warmed")
+	}
+	if resourceCache.SafeToSkipApply(changed, existing) {
+		t.Errorf("expected a changed namespace not to be skipped")
+	}
+	if NewPrewarmedResourceCache([]runtime.Object{changed}, informer).SafeToSkipApply(changed, existing) {
+		t.Errorf("expected a namespace differing from the informer not to be pre-warmed")
+	}
+
+	client := fake.NewSimpleClientset(existing)
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	_, modified, err := ApplyNamespaceImproved(context.TODO(), client.CoreV1(), recorder, required, resourceCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modified {
+		t.Errorf("expected no modification")
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() != "get" {
+			t.Errorf("expected only a get, got %s", action.GetVerb())
+		}
+	}
+}
+
+func TestNewPrewarmedResourceCacheStaleData(t *testing.T) {
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "operand", Name: "config", ResourceVersion: "42"},
+		Data:       map[string]string{"config.yaml": "foo", "stale.yaml": "bar"},
+	}
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &corev1.ConfigMap{}, 0, cache.Indexers{})
+	if err := informer.GetStore().Add(existing); err != nil {
+		t.Fatal(err)
+	}
+
+	required := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "operand", Name: "config"},
+		Data:       map[string]string{"config.yaml": "foo"},
+	}
+	resourceCache := NewPrewarmedResourceCache([]runtime.Object{required}, informer)
+	if resourceCache.SafeToSkipApply(required, existing) {
+		t.Errorf("expected a configmap with stale data not to be pre-warmed")
+	}
+
+	client := fake.NewSimpleClientset(existing)
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	actual, modified, err := ApplyConfigMapImproved(context.TODO(), client.CoreV1(), recorder, required, resourceCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified {
+		t.Errorf("expected the stale data to be removed")
+	}
+	if _, ok := actual.Data["stale.yaml"]; ok {
+		t.Errorf("expected the stale key to be removed, got %v", actual.Data)
+	}
+}
@@ -0,0 +1,101 @@
+package resourceapply
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	schedulingclientv1 "k8s.io/client-go/kubernetes/typed/scheduling/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehelper"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+)
+
+// systemPriorityClassPrefix is the prefix of the priority classes reserved for the system, which can not be deleted.
+const systemPriorityClassPrefix = "system-"
+
+// ApplyPriorityClass merges objectmeta, requires value, globalDefault, description and preemptionPolicy.
+// The value and the preemption policy of a PriorityClass are immutable, the PriorityClass is deleted and
+// re-created when they change. System priority classes are never re-created.
+func ApplyPriorityClass(ctx context.Context, client schedulingclientv1.PriorityClassesGetter, recorder events.Recorder, required *schedulingv1.PriorityClass) (*schedulingv1.PriorityClass, bool, error) {
+	existing, err := client.PriorityClasses().Get(ctx, required.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		requiredCopy := required.DeepCopy()
+		actual, err := client.PriorityClasses().Create(
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*schedulingv1.PriorityClass), metav1.CreateOptions{})
+		resourcehelper.ReportCreateEvent(recorder, required, err)
+		return actual, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	modified := false
+	existingCopy := existing.DeepCopy()
+
+	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
+	sameImmutableFields := existingCopy.Value == required.Value &&
+		(required.PreemptionPolicy == nil || equality.Semantic.DeepEqual(existingCopy.PreemptionPolicy, required.PreemptionPolicy))
+	contentSame := sameImmutableFields &&
+		existingCopy.GlobalDefault == required.GlobalDefault &&
+		existingCopy.Description == required.Description
+	if contentSame && !modified {
+		return existingCopy, false, nil
+	}
+
+	existingCopy.Value = required.Value
+	existingCopy.GlobalDefault = required.GlobalDefault
+	existingCopy.Description = required.Description
+	if required.PreemptionPolicy != nil {
+		existingCopy.PreemptionPolicy = required.PreemptionPolicy
+	}
+
+	if klog.V(2).Enabled() {
+		klog.Infof("PriorityClass %q changes: %v", required.Name, JSONPatchNoError(existing, existingCopy))
+	}
+
+	if sameImmutableFields {
+		actual, err := client.PriorityClasses().Update(ctx, existingCopy, metav1.UpdateOptions{})
+		resourcehelper.ReportUpdateEvent(recorder, required, err)
+		return actual, true, err
+	}
+
+	if strings.HasPrefix(existing.Name, systemPriorityClassPrefix) {
+		return existing, false, fmt.Errorf("unable to change the value of the system PriorityClass %s from %d to %d", existing.Name, existing.Value, required.Value)
+	}
+
+	existingCopy.ObjectMeta.ResourceVersion = ""
+	// Value is read-only after creation. Delete and re-create the object
+	err = client.PriorityClasses().Delete(ctx, existingCopy.Name, metav1.DeleteOptions{})
+	resourcehelper.ReportDeleteEvent(recorder, existingCopy, err, "Deleting PriorityClass to re-create it with an updated value")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return existing, false, err
+	}
+	actual, err := client.PriorityClasses().Create(ctx, existingCopy, metav1.CreateOptions{})
+	if err != nil && apierrors.IsAlreadyExists(err) {
+		// the API server did not delete the object yet
+		err = fmt.Errorf("failed to re-create PriorityClass %s, waiting for the original object to be deleted", existingCopy.Name)
+	} else if err != nil {
+		err = fmt.Errorf("failed to re-create PriorityClass %s: %w", existingCopy.Name, err)
+	}
+	resourcehelper.ReportCreateEvent(recorder, existingCopy, err)
+	return actual, true, err
+}
+
+func DeletePriorityClass(ctx context.Context, client schedulingclientv1.PriorityClassesGetter, recorder events.Recorder, required *schedulingv1.PriorityClass) (*schedulingv1.PriorityClass, bool, error) {
+	err := client.PriorityClasses().Delete(ctx, required.Name, metav1.DeleteOptions{})
+	if err != nil && apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	resourcehelper.ReportDeleteEvent(recorder, required, err)
+	return nil, true, nil
+}
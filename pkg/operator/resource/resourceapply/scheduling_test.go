@@ -0,0 +1,104 @@
+package resourceapply
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestApplyPriorityClass(t *testing.T) {
+	priorityClass := func(name string, value int32, description string) *schedulingv1.PriorityClass {
+		return &schedulingv1.PriorityClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: name, Labels: map[string]string{"app": "operand"}},
+			Value:       value,
+			Description: description,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		required *schedulingv1.PriorityClass
+
+		expectedModified bool
+		expectedErr      bool
+		expectedValue    int32
+		expectedVerbs    []string
+	}{
+		{
+			name:             "create",
+			required:         priorityClass("operand-critical", 1000, "operands"),
+			expectedModified: true,
+			expectedValue:    1000,
+			expectedVerbs:    []string{"get", "create"},
+		},
+		{
+			name:          "no-op",
+			existing:      []runtime.Object{priorityClass("operand-critical", 1000, "operands")},
+			required:      priorityClass("operand-critical", 1000, "operands"),
+			expectedValue: 1000,
+			expectedVerbs: []string{"get"},
+		},
+		{
+			name:             "update description",
+			existing:         []runtime.Object{priorityClass("operand-critical", 1000, "operands")},
+			required:         priorityClass("operand-critical", 1000, "critical operands"),
+			expectedModified: true,
+			expectedValue:    1000,
+			expectedVerbs:    []string{"get", "update"},
+		},
+		{
+			name:             "value change recreates",
+			existing:         []runtime.Object{priorityClass("operand-critical", 1000, "operands")},
+			required:         priorityClass("operand-critical", 2000, "operands"),
+			expectedModified: true,
+			expectedValue:    2000,
+			expectedVerbs:    []string{"get", "delete", "create"},
+		},
+		{
+			name:          "system priority class is not recreated",
+			existing:      []runtime.Object{priorityClass("system-cluster-critical", 2000000000, "system")},
+			required:      priorityClass("system-cluster-critical", 1000, "system"),
+			expectedErr:   true,
+			expectedValue: 2000000000,
+			expectedVerbs: []string{"get"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+			actual, modified, err := ApplyPriorityClass(context.TODO(), client.SchedulingV1(), recorder, test.required)
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+			if modified != test.expectedModified {
+				t.Errorf("expected modified %v, got %v", test.expectedModified, modified)
+			}
+			if actual.Value != test.expectedValue {
+				t.Errorf("expected value %d, got %d", test.expectedValue, actual.Value)
+			}
+			if !test.expectedErr && actual.Description != test.required.Description {
+				t.Errorf("expected description %q, got %q", test.required.Description, actual.Description)
+			}
+			var verbs []string
+			for _, action := range client.Actions() {
+				verbs = append(verbs, action.GetVerb())
+			}
+			if !equality.Semantic.DeepEqual(verbs, test.expectedVerbs) {
+				t.Errorf("expected actions %v, got %v", test.expectedVerbs, verbs)
+			}
+		})
+	}
+}
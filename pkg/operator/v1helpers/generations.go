@@ -0,0 +1,115 @@
+package v1helpers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/utils/ptr"
+)
+
+// generationsStablePollInterval is how often WaitForAllGenerationsStable checks the workloads.
+var generationsStablePollInterval = time.Second
+
+// WorkloadGetters are the listers used to look up the workloads recorded in the operator status generations.
+// Generations of workloads without a lister are ignored.
+type WorkloadGetters struct {
+	Deployments appsv1listers.DeploymentLister
+	DaemonSets  appsv1listers.DaemonSetLister
+}
+
+// WaitForAllGenerationsStable blocks until every deployment and daemonset recorded in the generations of the
+// operator status observed its recorded generation and all of its replicas are updated and available.
+// The operator status and the workloads are read from informers, which must be started by the caller.
+func WaitForAllGenerationsStable(ctx context.Context, client OperatorClient, workloads WorkloadGetters) error {
+	return wait.PollUntilContextCancel(ctx, generationsStablePollInterval, true, func(ctx context.Context) (bool, error) {
+		_, status, _, err := client.GetOperatorState()
+		if err != nil {
+			return false, err
+		}
+		for _, generation := range status.Generations {
+			stable, err := generationStable(generation, workloads)
+			if err != nil {
+				return false, err
+			}
+			if !stable {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// UpdateGenerationFn returns a func to set a single generation in the operator status. The generation
+// replaces the one recorded for the same resource, or is appended if there is none.
+func UpdateGenerationFn(generation operatorv1.GenerationStatus) UpdateStatusFunc {
+	return func(status *operatorv1.OperatorStatus) error {
+		for i := range status.Generations {
+			curr := &status.Generations[i]
+			if curr.Group == generation.Group &&
+				curr.Resource == generation.Resource &&
+				curr.Namespace == generation.Namespace &&
+				curr.Name == generation.Name {
+				curr.LastGeneration = generation.LastGeneration
+				curr.Hash = generation.Hash
+				return nil
+			}
+		}
+		status.Generations = append(status.Generations, generation)
+		return nil
+	}
+}
+
+// UpdateGeneration sets a single generation in the operator status, leaving the generations of other resources
+// untouched. The status is read and updated again on conflicts, so concurrent updates of other generations are kept.
+func UpdateGeneration(ctx context.Context, client OperatorClient, generation operatorv1.GenerationStatus) (*operatorv1.OperatorStatus, bool, error) {
+	return UpdateStatus(ctx, client, UpdateGenerationFn(generation))
+}
+
+func generationStable(generation operatorv1.GenerationStatus, workloads WorkloadGetters) (bool, error) {
+	if generation.Group != appsv1.GroupName {
+		return true, nil
+	}
+	switch generation.Resource {
+	case "deployments":
+		if workloads.Deployments == nil {
+			return true, nil
+		}
+		deployment, err := workloads.Deployments.Deployments(generation.Namespace).Get(generation.Name)
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("unable to get deployment %s/%s: %w", generation.Namespace, generation.Name, err)
+		}
+		replicas := ptr.Deref(deployment.Spec.Replicas, 1)
+		return deployment.Status.ObservedGeneration >= generation.LastGeneration &&
+			deployment.Status.ObservedGeneration >= deployment.Generation &&
+			deployment.Status.Replicas == replicas &&
+			deployment.Status.UpdatedReplicas == replicas &&
+			deployment.Status.AvailableReplicas == replicas, nil
+	case "daemonsets":
+		if workloads.DaemonSets == nil {
+			return true, nil
+		}
+		daemonSet, err := workloads.DaemonSets.DaemonSets(generation.Namespace).Get(generation.Name)
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("unable to get daemonset %s/%s: %w", generation.Namespace, generation.Name, err)
+		}
+		desired := daemonSet.Status.DesiredNumberScheduled
+		return daemonSet.Status.ObservedGeneration >= generation.LastGeneration &&
+			daemonSet.Status.ObservedGeneration >= daemonSet.Generation &&
+			daemonSet.Status.UpdatedNumberScheduled == desired &&
+			daemonSet.Status.NumberAvailable == desired, nil
+	default:
+		return true, nil
+	}
+}
@@ -0,0 +1,141 @@
+package v1helpers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+)
+
+func TestWaitForAllGenerationsStable(t *testing.T) {
+	generationsStablePollInterval = 10 * time.Millisecond
+	defer func() { generationsStablePollInterval = time.Second }()
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "operand", Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](2)},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 2},
+	}
+	daemonSet := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "agent", Generation: 3},
+		Status:     appsv1.DaemonSetStatus{ObservedGeneration: 3, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 2},
+	}
+	deployments := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	daemonSets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := deployments.Add(deployment); err != nil {
+		t.Fatal(err)
+	}
+	if err := daemonSets.Add(daemonSet); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{
+		Generations: []operatorv1.GenerationStatus{
+			{Group: "apps", Resource: "deployments", Namespace: "ns", Name: "operand", LastGeneration: 2},
+			{Group: "apps", Resource: "daemonsets", Namespace: "ns", Name: "agent", LastGeneration: 3},
+			{Group: "", Resource: "configmaps", Namespace: "ns", Name: "ignored", LastGeneration: 1},
+		},
+	}, nil)
+	workloads := WorkloadGetters{
+		Deployments: appsv1listers.NewDeploymentLister(deployments),
+		DaemonSets:  appsv1listers.NewDaemonSetLister(daemonSets),
+	}
+
+	// the workloads are not stable, the wait times out
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := WaitForAllGenerationsStable(ctx, client, workloads); err == nil {
+		t.Fatal("expected the wait to time out while the workloads roll out")
+	}
+
+	// the workloads reach stability while waiting
+	done := make(chan error)
+	go func() {
+		done <- WaitForAllGenerationsStable(context.Background(), client, workloads)
+	}()
+
+	deployment = deployment.DeepCopy()
+	deployment.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}
+	if err := deployments.Update(deployment); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+		t.Fatal("expected the wait to block until the daemonset is available")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	daemonSet = daemonSet.DeepCopy()
+	daemonSet.Status.NumberAvailable = 3
+	if err := daemonSets.Update(daemonSet); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("expected the wait to finish once all workloads are stable")
+	}
+}
+
+func TestUpdateGeneration(t *testing.T) {
+	deployment := operatorv1.GenerationStatus{Group: "apps", Resource: "deployments", Namespace: "ns", Name: "operand", LastGeneration: 1}
+	daemonSet := operatorv1.GenerationStatus{Group: "apps", Resource: "daemonsets", Namespace: "ns", Name: "agent", LastGeneration: 4}
+
+	var client *fakeOperatorClient
+	conflicted := false
+	client = NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{
+		Generations: []operatorv1.GenerationStatus{deployment},
+	}, func(rv string, status *operatorv1.OperatorStatus) error {
+		if conflicted {
+			return nil
+		}
+		conflicted = true
+		// another controller records its generation before this update lands
+		client.fakeOperatorStatus = &operatorv1.OperatorStatus{
+			Generations: []operatorv1.GenerationStatus{deployment, daemonSet},
+		}
+		return apierrors.NewConflict(schema.GroupResource{Group: operatorv1.GroupName, Resource: "TestOperatorConfig"}, "instance", fmt.Errorf("invalid resourceVersion"))
+	})
+
+	updatedDeployment := deployment
+	updatedDeployment.LastGeneration = 2
+	status, updated, err := UpdateGeneration(context.TODO(), client, updatedDeployment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated {
+		t.Errorf("expected the status to be updated")
+	}
+	if !conflicted {
+		t.Errorf("expected the update to conflict once")
+	}
+	expected := []operatorv1.GenerationStatus{updatedDeployment, daemonSet}
+	if !equality.Semantic.DeepEqual(status.Generations, expected) {
+		t.Errorf("expected generations %v, got %v", expected, status.Generations)
+	}
+
+	// an unknown resource is appended
+	service := operatorv1.GenerationStatus{Group: "", Resource: "services", Namespace: "ns", Name: "operand", LastGeneration: 1}
+	status, _, err = UpdateGeneration(context.TODO(), client, service)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = append(expected, service)
+	if !equality.Semantic.DeepEqual(status.Generations, expected) {
+		t.Errorf("expected generations %v, got %v", expected, status.Generations)
+	}
+}
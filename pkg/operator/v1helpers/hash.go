@@ -0,0 +1,19 @@
+package v1helpers
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// InputsHash returns a stable hash of the given named inputs, like operand images, log level or observed config.
+// Controllers can store the hash and compare it on the next sync to decide whether manifests must be re-rendered,
+// the same way SetSpecHashAnnotation does for object specs.
+// Inputs are serialized to JSON, so map keys, including the input names, are sorted and do not affect the result.
+func InputsHash(inputs map[string]interface{}) (string, error) {
+	jsonBytes, err := json.Marshal(inputs)
+	if err != nil {
+		return "", fmt.Errorf("unable to serialize inputs: %w", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(jsonBytes)), nil
+}
@@ -0,0 +1,99 @@
+package v1helpers
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+func TestInputsHash(t *testing.T) {
+	base := func() map[string]interface{} {
+		return map[string]interface{}{
+			"operandImage":   "quay.io/openshift/operand:v1",
+			"operatorImage":  "quay.io/openshift/operator:v1",
+			"logLevel":       operatorv1.Normal,
+			"observedConfig": runtime.RawExtension{Raw: []byte(`{"servingInfo":{"bindAddress":"0.0.0.0:8443","minTLSVersion":"VersionTLS12"}}`)},
+			"replicas":       3,
+		}
+	}
+
+	baseHash, err := InputsHash(base())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("stable", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			hash, err := InputsHash(base())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hash != baseHash {
+				t.Fatalf("expected hash %q, got %q", baseHash, hash)
+			}
+		}
+	})
+
+	for _, tc := range []struct {
+		name   string
+		mutate func(map[string]interface{})
+	}{
+		{
+			name:   "operand image",
+			mutate: func(in map[string]interface{}) { in["operandImage"] = "quay.io/openshift/operand:v2" },
+		},
+		{
+			name:   "operator image",
+			mutate: func(in map[string]interface{}) { in["operatorImage"] = "quay.io/openshift/operator:v2" },
+		},
+		{
+			name:   "log level",
+			mutate: func(in map[string]interface{}) { in["logLevel"] = operatorv1.Debug },
+		},
+		{
+			name: "observed config",
+			mutate: func(in map[string]interface{}) {
+				in["observedConfig"] = runtime.RawExtension{Raw: []byte(`{"servingInfo":{"bindAddress":"0.0.0.0:8443","minTLSVersion":"VersionTLS13"}}`)}
+			},
+		},
+		{
+			name:   "replicas",
+			mutate: func(in map[string]interface{}) { in["replicas"] = 2 },
+		},
+		{
+			name:   "added input",
+			mutate: func(in map[string]interface{}) { in["proxy"] = "http://proxy.example.com" },
+		},
+		{
+			name:   "removed input",
+			mutate: func(in map[string]interface{}) { delete(in, "replicas") },
+		},
+		{
+			name: "renamed input",
+			mutate: func(in map[string]interface{}) {
+				in["image"] = in["operandImage"]
+				delete(in, "operandImage")
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			inputs := base()
+			tc.mutate(inputs)
+			hash, err := InputsHash(inputs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hash == baseHash {
+				t.Errorf("expected hash to change when %s changes", tc.name)
+			}
+		})
+	}
+
+	t.Run("unserializable input", func(t *testing.T) {
+		if _, err := InputsHash(map[string]interface{}{"func": func() {}}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package v1helpers
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -103,6 +104,117 @@ func TestSetOperatorCondition(t *testing.T) {
 	}
 }
 
+func TestSetOperatorConditionIfChanged(t *testing.T) {
+	beforeish := metav1.Time{Time: metav1.Now().Add(-10 * time.Minute)}
+
+	tests := []struct {
+		name                   string
+		starting               []operatorsv1.OperatorCondition
+		newCondition           operatorsv1.OperatorCondition
+		expectedChanged        bool
+		expected               operatorsv1.OperatorCondition
+		expectedTransitionTime bool
+	}{
+		{
+			name:            "add to empty",
+			newCondition:    newOperatorCondition("one", "True", "my-reason", "my-message", nil),
+			expectedChanged: true,
+			expected:        newOperatorCondition("one", "True", "my-reason", "my-message", nil),
+		},
+		{
+			name: "cosmetic re-set keeps the transition time",
+			starting: []operatorsv1.OperatorCondition{
+				newOperatorCondition("one", "True", "my-reason", "my-message", &beforeish),
+			},
+			newCondition:           newOperatorCondition("one", "True", "my-reason", "my-message", nil),
+			expected:               newOperatorCondition("one", "True", "my-reason", "my-message", &beforeish),
+			expectedTransitionTime: true,
+		},
+		{
+			name: "changed status",
+			starting: []operatorsv1.OperatorCondition{
+				newOperatorCondition("one", "True", "my-reason", "my-message", &beforeish),
+			},
+			newCondition:    newOperatorCondition("one", "False", "my-reason", "my-message", nil),
+			expectedChanged: true,
+			expected:        newOperatorCondition("one", "False", "my-reason", "my-message", nil),
+		},
+		{
+			name: "changed reason",
+			starting: []operatorsv1.OperatorCondition{
+				newOperatorCondition("one", "True", "my-reason", "my-message", &beforeish),
+			},
+			newCondition:    newOperatorCondition("one", "True", "my-other-reason", "my-message", nil),
+			expectedChanged: true,
+			expected:        newOperatorCondition("one", "True", "my-other-reason", "my-message", nil),
+		},
+		{
+			name: "changed message",
+			starting: []operatorsv1.OperatorCondition{
+				newOperatorCondition("one", "True", "my-reason", "my-message", &beforeish),
+			},
+			newCondition:    newOperatorCondition("one", "True", "my-reason", "my-other-message", nil),
+			expectedChanged: true,
+			expected:        newOperatorCondition("one", "True", "my-reason", "my-other-message", nil),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conditions := append([]operatorsv1.OperatorCondition{}, test.starting...)
+			if changed := SetOperatorConditionIfChanged(&conditions, test.newCondition); changed != test.expectedChanged {
+				t.Errorf("expected changed %v, got %v", test.expectedChanged, changed)
+			}
+			if len(conditions) != 1 {
+				t.Fatal(spew.Sdump(conditions))
+			}
+			actual := conditions[0]
+			if test.expectedTransitionTime {
+				if !actual.LastTransitionTime.Equal(&beforeish) {
+					t.Errorf("expected the transition time to be preserved, got %v", actual.LastTransitionTime)
+				}
+			} else if !actual.LastTransitionTime.After(beforeish.Time) {
+				t.Errorf("expected the transition time to be updated, got %v", actual.LastTransitionTime)
+			}
+			actual.LastTransitionTime = test.expected.LastTransitionTime
+			if !equality.Semantic.DeepEqual(test.expected, actual) {
+				t.Errorf(diff.ObjectDiff(test.expected, actual))
+			}
+		})
+	}
+}
+
+func TestUpdateConditionIfChangedFn(t *testing.T) {
+	beforeish := metav1.Time{Time: metav1.Now().Add(-10 * time.Minute)}
+	condition := newOperatorCondition("OperatorAvailable", "True", "AsExpected", "", &beforeish)
+	client := NewFakeOperatorClient(&operatorsv1.OperatorSpec{}, &operatorsv1.OperatorStatus{
+		Conditions: []operatorsv1.OperatorCondition{condition},
+	}, nil)
+
+	// rebuilding the same condition does not update the status
+	status, updated, err := UpdateStatus(context.TODO(), client, UpdateConditionIfChangedFn(newOperatorCondition("OperatorAvailable", "True", "AsExpected", "", nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated {
+		t.Errorf("expected no status update")
+	}
+	if !status.Conditions[0].LastTransitionTime.Equal(&beforeish) {
+		t.Errorf("expected the transition time to be preserved, got %v", status.Conditions[0].LastTransitionTime)
+	}
+
+	status, updated, err = UpdateStatus(context.TODO(), client, UpdateConditionIfChangedFn(newOperatorCondition("OperatorAvailable", "True", "AsExpected", "all replicas are available", nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated {
+		t.Errorf("expected the status to be updated")
+	}
+	if status.Conditions[0].Message != "all replicas are available" {
+		t.Errorf("unexpected condition %v", status.Conditions[0])
+	}
+}
+
 func TestRemoveOperatorCondition(t *testing.T) {
 	tests := []struct {
 		name            string
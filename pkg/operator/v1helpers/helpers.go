@@ -75,6 +75,29 @@ func SetOperatorCondition(conditions *[]operatorv1.OperatorCondition, newConditi
 	existingCondition.Message = newCondition.Message
 }
 
+// SetOperatorConditionIfChanged sets newCondition in conditions only when its status, reason or message differ from
+// the existing condition of the same type, and returns true if the conditions changed. Unlike SetOperatorCondition,
+// the last transition time is updated whenever the condition changes, and conditions that are re-set with the same
+// status, reason and message are left untouched, so rebuilding the conditions on every sync does not cause status
+// updates.
+func SetOperatorConditionIfChanged(conditions *[]operatorv1.OperatorCondition, newCondition operatorv1.OperatorCondition) bool {
+	existingCondition := FindOperatorCondition(*conditions, newCondition.Type)
+	if existingCondition != nil &&
+		existingCondition.Status == newCondition.Status &&
+		existingCondition.Reason == newCondition.Reason &&
+		existingCondition.Message == newCondition.Message {
+		return false
+	}
+
+	newCondition.LastTransitionTime = metav1.NewTime(time.Now())
+	if existingCondition == nil {
+		*conditions = append(*conditions, newCondition)
+		return true
+	}
+	*existingCondition = newCondition
+	return true
+}
+
 func RemoveOperatorCondition(conditions *[]operatorv1.OperatorCondition, conditionType string) {
 	if conditions == nil {
 		conditions = &[]operatorv1.OperatorCondition{}
@@ -232,6 +255,14 @@ func UpdateConditionFn(cond operatorv1.OperatorCondition) UpdateStatusFunc {
 	}
 }
 
+// UpdateConditionIfChangedFn returns a func to update a condition only when its status, reason or message changed.
+func UpdateConditionIfChangedFn(cond operatorv1.OperatorCondition) UpdateStatusFunc {
+	return func(oldStatus *operatorv1.OperatorStatus) error {
+		SetOperatorConditionIfChanged(&oldStatus.Conditions, cond)
+		return nil
+	}
+}
+
 // UpdateStaticPodStatusFunc is a func that mutates an operator status.
 type UpdateStaticPodStatusFunc func(status *operatorv1.StaticPodOperatorStatus) error
 
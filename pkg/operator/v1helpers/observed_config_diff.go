@@ -0,0 +1,158 @@
+package v1helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// redactedValue replaces the value of a redacted path.
+	redactedValue = "<redacted>"
+	// redactedChangedValue replaces the new value of a redacted path when it differs from the old value, so
+	// that the change is still visible in the diff.
+	redactedChangedValue = "<redacted, changed>"
+)
+
+// ObservedConfigDiff returns a readable diff of two observed configs, meant for events and logs. Both configs
+// are pretty-printed with sorted keys and compared line by line. All lines are returned, unchanged lines are
+// indented with two spaces, removed lines are prefixed with "- " and added lines with "+ ". An empty string is
+// returned if the configs are equal.
+//
+// The values of redactedPaths are replaced in both configs before they are compared. Paths use the JSON field
+// names separated by dots, e.g. "storage.s3.secretKey", and replace the whole value, including nested fields.
+// A redacted value that changed is still reported as changed, without revealing the old or the new value.
+func ObservedConfigDiff(oldConfig, newConfig runtime.RawExtension, redactedPaths ...string) (string, error) {
+	oldObj, err := observedConfigToMap(oldConfig)
+	if err != nil {
+		return "", fmt.Errorf("unable to deserialize old observed config: %w", err)
+	}
+	newObj, err := observedConfigToMap(newConfig)
+	if err != nil {
+		return "", fmt.Errorf("unable to deserialize new observed config: %w", err)
+	}
+	for _, path := range redactedPaths {
+		redactPath(oldObj, newObj, strings.Split(path, "."))
+	}
+
+	oldLines, err := prettyPrintLines(oldObj)
+	if err != nil {
+		return "", err
+	}
+	newLines, err := prettyPrintLines(newObj)
+	if err != nil {
+		return "", err
+	}
+	return diffLines(oldLines, newLines), nil
+}
+
+func observedConfigToMap(config runtime.RawExtension) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	if len(config.Raw) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(config.Raw, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// redactPath replaces the values of path in oldObj and newObj. The new value is marked as changed if it differs
+// from the old value.
+func redactPath(oldObj, newObj map[string]interface{}, path []string) {
+	key := path[0]
+	oldValue, oldOK := oldObj[key]
+	newValue, newOK := newObj[key]
+	if len(path) > 1 {
+		oldNested, _ := oldValue.(map[string]interface{})
+		newNested, _ := newValue.(map[string]interface{})
+		if oldNested == nil && newNested == nil {
+			return
+		}
+		// a missing parent is redacted as an empty object, so that the nested value can be compared
+		if oldNested == nil {
+			oldNested = map[string]interface{}{}
+		}
+		if newNested == nil {
+			newNested = map[string]interface{}{}
+		}
+		redactPath(oldNested, newNested, path[1:])
+		if oldOK {
+			oldObj[key] = oldNested
+		}
+		if newOK {
+			newObj[key] = newNested
+		}
+		return
+	}
+
+	if oldOK {
+		oldObj[key] = redactedValue
+	}
+	if newOK {
+		if oldOK && !reflect.DeepEqual(oldValue, newValue) {
+			newObj[key] = redactedChangedValue
+		} else {
+			newObj[key] = redactedValue
+		}
+	}
+}
+
+func prettyPrintLines(obj map[string]interface{}) ([]string, error) {
+	// maps are serialized with sorted keys
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(obj); err != nil {
+		return nil, fmt.Errorf("unable to serialize observed config: %w", err)
+	}
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"), nil
+}
+
+// diffLines returns all lines of oldLines and newLines, marking the lines that are only in one of them, using
+// the longest common subsequence of both.
+func diffLines(oldLines, newLines []string) string {
+	// lcs[i][j] is the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	changed := false
+	var result []string
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			result = append(result, "  "+oldLines[i])
+			i++
+			j++
+		case j == len(newLines) || i < len(oldLines) && lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, "- "+oldLines[i])
+			changed = true
+			i++
+		default:
+			result = append(result, "+ "+newLines[j])
+			changed = true
+			j++
+		}
+	}
+	if !changed {
+		return ""
+	}
+	return strings.Join(result, "\n")
+}
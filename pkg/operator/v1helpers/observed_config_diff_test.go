@@ -0,0 +1,110 @@
+package v1helpers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestObservedConfigDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		oldConfig     string
+		newConfig     string
+		redactedPaths []string
+		expected      string
+	}{
+		{
+			name:      "equal configs",
+			oldConfig: `{"servingInfo":{"bindAddress":"0.0.0.0:443"}}`,
+			newConfig: `{"servingInfo": {"bindAddress": "0.0.0.0:443"}}`,
+			expected:  "",
+		},
+		{
+			name:      "both empty",
+			oldConfig: ``,
+			newConfig: `{}`,
+			expected:  "",
+		},
+		{
+			name:      "changed value",
+			oldConfig: `{"servingInfo":{"bindAddress":"0.0.0.0:443","minTLSVersion":"VersionTLS12"}}`,
+			newConfig: `{"servingInfo":{"minTLSVersion":"VersionTLS13","bindAddress":"0.0.0.0:443"}}`,
+			expected: `  {
+    "servingInfo": {
+      "bindAddress": "0.0.0.0:443",
+-     "minTLSVersion": "VersionTLS12"
++     "minTLSVersion": "VersionTLS13"
+    }
+  }`,
+		},
+		{
+			name:      "added config",
+			oldConfig: ``,
+			newConfig: `{"logLevel":"Debug"}`,
+			expected: `- {}
++ {
++   "logLevel": "Debug"
++ }`,
+		},
+		{
+			name:          "redacted value changed",
+			oldConfig:     `{"storage":{"s3":{"bucket":"images","secretKey":"old-secret"}}}`,
+			newConfig:     `{"storage":{"s3":{"bucket":"images","secretKey":"new-secret"}}}`,
+			redactedPaths: []string{"storage.s3.secretKey"},
+			expected: `  {
+    "storage": {
+      "s3": {
+        "bucket": "images",
+-       "secretKey": "<redacted>"
++       "secretKey": "<redacted, changed>"
+      }
+    }
+  }`,
+		},
+		{
+			name:          "redacted value unchanged",
+			oldConfig:     `{"storage":{"s3":{"bucket":"images","secretKey":"secret"}}}`,
+			newConfig:     `{"storage":{"s3":{"bucket":"registry","secretKey":"secret"}}}`,
+			redactedPaths: []string{"storage.s3.secretKey"},
+			expected: `  {
+    "storage": {
+      "s3": {
+-       "bucket": "images",
++       "bucket": "registry",
+        "secretKey": "<redacted>"
+      }
+    }
+  }`,
+		},
+		{
+			name:          "redacted object added",
+			oldConfig:     `{"logLevel":"Normal"}`,
+			newConfig:     `{"logLevel":"Normal","credentials":{"user":"admin","password":"secret"}}`,
+			redactedPaths: []string{"credentials", "missing.path"},
+			expected: `  {
++   "credentials": "<redacted>",
+    "logLevel": "Normal"
+  }`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ObservedConfigDiff(runtime.RawExtension{Raw: []byte(tc.oldConfig)}, runtime.RawExtension{Raw: []byte(tc.newConfig)}, tc.redactedPaths...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tc.expected {
+				t.Errorf("unexpected diff:\n%s", cmp.Diff(tc.expected, actual))
+			}
+		})
+	}
+}
+
+func TestObservedConfigDiffInvalid(t *testing.T) {
+	if _, err := ObservedConfigDiff(runtime.RawExtension{Raw: []byte(`{`)}, runtime.RawExtension{}); err == nil {
+		t.Error("expected an error for an invalid observed config")
+	}
+}
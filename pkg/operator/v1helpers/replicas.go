@@ -0,0 +1,51 @@
+package v1helpers
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ReadyReplicasPolicy defines how MergeReadyReplicas combines the ready replicas of several workloads.
+type ReadyReplicasPolicy string
+
+const (
+	// ReadyReplicasMin reports the ready replicas of the least ready workload, the operator is as ready
+	// as its least ready operand.
+	ReadyReplicasMin ReadyReplicasPolicy = "Min"
+	// ReadyReplicasSum reports the total number of ready replicas of all workloads.
+	ReadyReplicasSum ReadyReplicasPolicy = "Sum"
+)
+
+// MergeReadyReplicas computes a single ReadyReplicas value for the operator status from the status of the given
+// deployments, statefulsets and daemonsets, using the ready pods of a daemonset as its ready replicas. It returns
+// zero if there are no workloads.
+func MergeReadyReplicas(policy ReadyReplicasPolicy, workloads ...runtime.Object) (int32, error) {
+	var merged int32
+	for i, workload := range workloads {
+		var ready int32
+		switch w := workload.(type) {
+		case *appsv1.Deployment:
+			ready = w.Status.ReadyReplicas
+		case *appsv1.StatefulSet:
+			ready = w.Status.ReadyReplicas
+		case *appsv1.DaemonSet:
+			ready = w.Status.NumberReady
+		default:
+			return 0, fmt.Errorf("unsupported workload type %T", workload)
+		}
+
+		switch policy {
+		case ReadyReplicasMin:
+			if i == 0 || ready < merged {
+				merged = ready
+			}
+		case ReadyReplicasSum:
+			merged += ready
+		default:
+			return 0, fmt.Errorf("unsupported ready replicas policy %q", policy)
+		}
+	}
+	return merged, nil
+}
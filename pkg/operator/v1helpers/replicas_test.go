@@ -0,0 +1,68 @@
+package v1helpers
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestMergeReadyReplicas(t *testing.T) {
+	deployment := &appsv1.Deployment{Status: appsv1.DeploymentStatus{Replicas: 3, ReadyReplicas: 3}}
+	daemonSet := &appsv1.DaemonSet{Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 5, NumberReady: 2}}
+
+	tests := []struct {
+		name      string
+		policy    ReadyReplicasPolicy
+		workloads []runtime.Object
+		expected  int32
+		expectErr bool
+	}{
+		{
+			name:      "min of a deployment and a daemonset",
+			policy:    ReadyReplicasMin,
+			workloads: []runtime.Object{deployment, daemonSet},
+			expected:  2,
+		},
+		{
+			name:      "sum of a deployment and a daemonset",
+			policy:    ReadyReplicasSum,
+			workloads: []runtime.Object{deployment, daemonSet},
+			expected:  5,
+		},
+		{
+			name:      "min with an unready statefulset",
+			policy:    ReadyReplicasMin,
+			workloads: []runtime.Object{deployment, daemonSet, &appsv1.StatefulSet{}},
+			expected:  0,
+		},
+		{
+			name:   "no workloads",
+			policy: ReadyReplicasMin,
+		},
+		{
+			name:      "unsupported workload",
+			policy:    ReadyReplicasSum,
+			workloads: []runtime.Object{deployment, &corev1.Pod{}},
+			expectErr: true,
+		},
+		{
+			name:      "unsupported policy",
+			policy:    "Max",
+			workloads: []runtime.Object{deployment},
+			expectErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := MergeReadyReplicas(tc.policy, tc.workloads...)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %d ready replicas, got %d", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package v1helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+// SpecChangeKind describes how a change of the operator spec has to be rolled out to the operand.
+type SpecChangeKind string
+
+const (
+	// SpecChangeNone means the change does not affect the operand.
+	SpecChangeNone SpecChangeKind = ""
+	// SpecChangeRoll means the operand can be updated with a rolling update.
+	SpecChangeRoll SpecChangeKind = "Roll"
+	// SpecChangeRecreate means all operand instances have to be stopped before the new ones are started.
+	SpecChangeRecreate SpecChangeKind = "Recreate"
+)
+
+var specChangeKindOrder = map[SpecChangeKind]int{
+	SpecChangeNone:     0,
+	SpecChangeRoll:     1,
+	SpecChangeRecreate: 2,
+}
+
+// SpecChangeClassifier classifies the delta between two operator specs using a configurable mapping of
+// field paths to change kinds. Paths use the JSON field names separated by dots and may point into the
+// observed config, e.g. "logLevel" or "observedConfig.volumeMounts". A changed field is classified by the
+// longest path that is equal to or a parent of it. Changed fields not covered by any path are classified
+// as Default.
+type SpecChangeClassifier struct {
+	Paths   map[string]SpecChangeKind
+	Default SpecChangeKind
+}
+
+// Classify returns the most disruptive kind of all changes between oldSpec and newSpec, together with the
+// sorted paths of the changed fields.
+func (c SpecChangeClassifier) Classify(oldSpec, newSpec *operatorv1.OperatorSpec) (SpecChangeKind, []string, error) {
+	oldObj, err := specToMap(oldSpec)
+	if err != nil {
+		return SpecChangeNone, nil, err
+	}
+	newObj, err := specToMap(newSpec)
+	if err != nil {
+		return SpecChangeNone, nil, err
+	}
+
+	changed := changedPaths("", oldObj, newObj)
+	sort.Strings(changed)
+
+	result := SpecChangeNone
+	for _, path := range changed {
+		if kind := c.classifyPath(path); specChangeKindOrder[kind] > specChangeKindOrder[result] {
+			result = kind
+		}
+	}
+	return result, changed, nil
+}
+
+// classifyPath returns the kind configured for the longest path covering the changed field. Paths below the
+// changed field are covered by the change too, e.g. when the whole observed config was added.
+func (c SpecChangeClassifier) classifyPath(changed string) SpecChangeKind {
+	result := c.Default
+	longest := -1
+	var nested []SpecChangeKind
+	for path, kind := range c.Paths {
+		switch {
+		case path == changed || strings.HasPrefix(changed, path+"."):
+			if len(path) > longest {
+				longest = len(path)
+				result = kind
+			}
+		case strings.HasPrefix(path, changed+"."):
+			nested = append(nested, kind)
+		}
+	}
+	for _, kind := range nested {
+		if specChangeKindOrder[kind] > specChangeKindOrder[result] {
+			result = kind
+		}
+	}
+	return result
+}
+
+func specToMap(spec *operatorv1.OperatorSpec) (map[string]interface{}, error) {
+	if spec == nil {
+		return nil, nil
+	}
+	specBytes, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize operator spec: %w", err)
+	}
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(specBytes, &result); err != nil {
+		return nil, fmt.Errorf("unable to deserialize operator spec: %w", err)
+	}
+	return result, nil
+}
+
+// changedPaths returns the paths of the leaf fields that differ between oldValue and newValue. Lists are
+// compared as a whole.
+func changedPaths(prefix string, oldValue, newValue interface{}) []string {
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if !oldIsMap || !newIsMap {
+		if reflect.DeepEqual(oldValue, newValue) {
+			return nil
+		}
+		return []string{prefix}
+	}
+
+	var result []string
+	for key, value := range oldMap {
+		result = append(result, changedPaths(joinSpecPath(prefix, key), value, newMap[key])...)
+	}
+	for key, value := range newMap {
+		if _, ok := oldMap[key]; !ok {
+			result = append(result, changedPaths(joinSpecPath(prefix, key), nil, value)...)
+		}
+	}
+	return result
+}
+
+func joinSpecPath(prefix, key string) string {
+	if len(prefix) == 0 {
+		return key
+	}
+	return prefix + "." + key
+}
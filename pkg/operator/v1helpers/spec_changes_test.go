@@ -0,0 +1,100 @@
+package v1helpers
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+func TestSpecChangeClassifier(t *testing.T) {
+	classifier := SpecChangeClassifier{
+		Paths: map[string]SpecChangeKind{
+			"logLevel":                    SpecChangeRoll,
+			"observedConfig.volumeMounts": SpecChangeRecreate,
+			"managementState":             SpecChangeNone,
+		},
+		Default: SpecChangeRoll,
+	}
+	spec := func(logLevel operatorv1.LogLevel, observedConfig string) *operatorv1.OperatorSpec {
+		return &operatorv1.OperatorSpec{
+			ManagementState: operatorv1.Managed,
+			LogLevel:        logLevel,
+			ObservedConfig:  runtime.RawExtension{Raw: []byte(observedConfig)},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		oldSpec         *operatorv1.OperatorSpec
+		newSpec         *operatorv1.OperatorSpec
+		expectedKind    SpecChangeKind
+		expectedChanged []string
+	}{
+		{
+			name:         "no change",
+			oldSpec:      spec(operatorv1.Normal, `{"volumeMounts":["/etc/a"]}`),
+			newSpec:      spec(operatorv1.Normal, `{"volumeMounts":["/etc/a"]}`),
+			expectedKind: SpecChangeNone,
+		},
+		{
+			name:            "log level change rolls",
+			oldSpec:         spec(operatorv1.Normal, `{"volumeMounts":["/etc/a"]}`),
+			newSpec:         spec(operatorv1.Debug, `{"volumeMounts":["/etc/a"]}`),
+			expectedKind:    SpecChangeRoll,
+			expectedChanged: []string{"logLevel"},
+		},
+		{
+			name:            "mount change recreates",
+			oldSpec:         spec(operatorv1.Normal, `{"volumeMounts":["/etc/a"]}`),
+			newSpec:         spec(operatorv1.Normal, `{"volumeMounts":["/etc/b"]}`),
+			expectedKind:    SpecChangeRecreate,
+			expectedChanged: []string{"observedConfig.volumeMounts"},
+		},
+		{
+			name:            "most disruptive change wins",
+			oldSpec:         spec(operatorv1.Normal, `{"volumeMounts":["/etc/a"]}`),
+			newSpec:         spec(operatorv1.Debug, `{"volumeMounts":["/etc/b"]}`),
+			expectedKind:    SpecChangeRecreate,
+			expectedChanged: []string{"logLevel", "observedConfig.volumeMounts"},
+		},
+		{
+			name:            "unmapped change uses default",
+			oldSpec:         spec(operatorv1.Normal, `{"servingInfo":{"bindAddress":"0.0.0.0:8443"}}`),
+			newSpec:         spec(operatorv1.Normal, `{"servingInfo":{"bindAddress":"0.0.0.0:9443"}}`),
+			expectedKind:    SpecChangeRoll,
+			expectedChanged: []string{"observedConfig.servingInfo.bindAddress"},
+		},
+		{
+			name:            "adding the observed config covers nested paths",
+			oldSpec:         spec(operatorv1.Normal, `null`),
+			newSpec:         spec(operatorv1.Normal, `{"volumeMounts":["/etc/a"]}`),
+			expectedKind:    SpecChangeRecreate,
+			expectedChanged: []string{"observedConfig"},
+		},
+		{
+			name:            "change mapped to none",
+			oldSpec:         &operatorv1.OperatorSpec{ManagementState: operatorv1.Managed},
+			newSpec:         &operatorv1.OperatorSpec{ManagementState: operatorv1.Unmanaged},
+			expectedKind:    SpecChangeNone,
+			expectedChanged: []string{"managementState"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kind, changed, err := classifier.Classify(tc.oldSpec, tc.newSpec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kind != tc.expectedKind {
+				t.Errorf("expected %q, got %q", tc.expectedKind, kind)
+			}
+			if !reflect.DeepEqual(changed, tc.expectedChanged) {
+				t.Errorf("expected changed paths %v, got %v", tc.expectedChanged, changed)
+			}
+		})
+	}
+}
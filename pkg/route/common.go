@@ -2,6 +2,7 @@ package route
 
 import (
 	"context"
+	"fmt"
 
 	authorizationv1 "k8s.io/api/authorization/v1"
 
@@ -20,4 +21,60 @@ type RouteValidationOptions struct {
 	// AllowExternalCertificates option is set when the RouteExternalCertificate
 	// feature gate is enabled.
 	AllowExternalCertificates bool
+
+	// MaxRequestHeaderList and MaxResponseHeaderList are the maximum allowed
+	// numbers of HTTP request and response header actions. Zero means the
+	// default limit of 20 header actions each.
+	MaxRequestHeaderList  int
+	MaxResponseHeaderList int
+
+	// MaxCombinedHeaderList is the maximum allowed number of HTTP request
+	// and response header actions taken together. Zero means the combined
+	// number of header actions is not bounded.
+	MaxCombinedHeaderList int
+
+	// ForbiddenHeaderNames lists additional HTTP headers that may not be
+	// modified using spec.httpHeaders.actions, compared case-insensitively.
+	// They extend strict-transport-security, proxy, cookie and set-cookie,
+	// which are always forbidden.
+	ForbiddenHeaderNames []string
+
+	// MaxTotalBackendWeight is the maximum allowed sum of the weights of
+	// spec.to and spec.alternateBackends, unset weights counting as the
+	// default weight of 100. Zero means the sum is not bounded.
+	MaxTotalBackendWeight int32
+
+	// ForbiddenHostSuffixes lists domain suffixes that spec.host and
+	// spec.subdomain must not end in, such as cluster-internal domains
+	// that never resolve outside of the cluster. Matching is
+	// case-insensitive and ignores a trailing dot.
+	ForbiddenHostSuffixes []string
+
+	// AllowedDomainSuffixes lists the domain suffixes spec.host must end in,
+	// such as the domains a cluster policy admits routes for. An empty list
+	// does not restrict the host. Matching is case-insensitive and ignores a
+	// trailing dot.
+	AllowedDomainSuffixes []string
+
+	// WarnOnCertificateHostMismatch adds a warning when the inline
+	// certificate of a route does not cover spec.host, neither by its DNS
+	// subject alternative names nor, in their absence, by its common name.
+	WarnOnCertificateHostMismatch bool
+}
+
+// Validate returns an error if the options are not usable for route validation.
+func (o RouteValidationOptions) Validate() error {
+	if o.MaxRequestHeaderList < 0 {
+		return fmt.Errorf("MaxRequestHeaderList must not be negative, got %d", o.MaxRequestHeaderList)
+	}
+	if o.MaxResponseHeaderList < 0 {
+		return fmt.Errorf("MaxResponseHeaderList must not be negative, got %d", o.MaxResponseHeaderList)
+	}
+	if o.MaxCombinedHeaderList < 0 {
+		return fmt.Errorf("MaxCombinedHeaderList must not be negative, got %d", o.MaxCombinedHeaderList)
+	}
+	if o.MaxTotalBackendWeight < 0 {
+		return fmt.Errorf("MaxTotalBackendWeight must not be negative, got %d", o.MaxTotalBackendWeight)
+	}
+	return nil
 }
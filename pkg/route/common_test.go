@@ -0,0 +1,47 @@
+package route
+
+import "testing"
+
+func TestRouteValidationOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        RouteValidationOptions
+		expectError bool
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "positive limits",
+			opts: RouteValidationOptions{MaxRequestHeaderList: 30, MaxResponseHeaderList: 10, MaxCombinedHeaderList: 35},
+		},
+		{
+			name:        "negative request limit",
+			opts:        RouteValidationOptions{MaxRequestHeaderList: -1},
+			expectError: true,
+		},
+		{
+			name:        "negative response limit",
+			opts:        RouteValidationOptions{MaxResponseHeaderList: -1},
+			expectError: true,
+		},
+		{
+			name:        "negative combined limit",
+			opts:        RouteValidationOptions{MaxCombinedHeaderList: -1},
+			expectError: true,
+		},
+		{
+			name:        "negative total backend weight",
+			opts:        RouteValidationOptions{MaxTotalBackendWeight: -1},
+			expectError: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.opts.Validate()
+			if tc.expectError != (err != nil) {
+				t.Errorf("expected error %v, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// timeoutAnnotation and timeoutTunnelAnnotation are the route annotations
+	// configuring the server and tunnel timeouts of the route.
+	timeoutAnnotation       = "haproxy.router.openshift.io/timeout"
+	timeoutTunnelAnnotation = "haproxy.router.openshift.io/timeout-tunnel"
+	// balanceAnnotation is the route annotation configuring the load
+	// balancing algorithm of the route.
+	balanceAnnotation = "haproxy.router.openshift.io/balance"
+)
+
+var (
+	// haproxyTimeoutRE matches the HAProxy time format, a number with an
+	// optional unit.
+	haproxyTimeoutRE = regexp.MustCompile(`^[0-9]+(us|ms|s|m|h|d)?$`)
+	// supportedBalanceValues are the load balancing algorithms the router
+	// accepts for the balance annotation.
+	supportedBalanceValues = sets.New("roundrobin", "leastconn", "source", "random")
+)
+
+// AnnotationValidatorFunc returns warnings for the value of a route annotation.
+// The warnings are returned by Warnings for every route carrying the annotation.
+type AnnotationValidatorFunc func(annotation, value string) []string
+
+var (
+	annotationValidatorsLock sync.RWMutex
+	annotationValidators     = map[string]AnnotationValidatorFunc{
+		setForwardedHeadersAnnotation: setForwardedHeadersWarnings,
+		ipWhitelistAnnotation:         ipAllowlistWarnings,
+		ipAllowlistAnnotation:         ipAllowlistWarnings,
+		rewriteTargetAnnotation:       rewriteTargetWarnings,
+		timeoutAnnotation:             timeoutWarnings,
+		timeoutTunnelAnnotation:       timeoutWarnings,
+		balanceAnnotation:             balanceWarnings,
+	}
+)
+
+// RegisterAnnotationValidator registers the validator consulted by Warnings for
+// the given route annotation, replacing any validator registered before,
+// including the built-in ones.
+func RegisterAnnotationValidator(annotation string, validator AnnotationValidatorFunc) {
+	annotationValidatorsLock.Lock()
+	defer annotationValidatorsLock.Unlock()
+	annotationValidators[annotation] = validator
+}
+
+// annotationWarnings runs the registered validators for the given annotations,
+// in the order of the annotation keys.
+func annotationWarnings(annotations map[string]string) []string {
+	annotationValidatorsLock.RLock()
+	defer annotationValidatorsLock.RUnlock()
+
+	var warnings []string
+	for _, annotation := range sets.List(sets.KeySet(annotations)) {
+		if validator, ok := annotationValidators[annotation]; ok {
+			warnings = append(warnings, validator(annotation, annotations[annotation])...)
+		}
+	}
+	return warnings
+}
+
+// timeoutWarnings returns a warning if the value of a timeout annotation is
+// not in the HAProxy time format. The router ignores such timeouts.
+func timeoutWarnings(annotation, value string) []string {
+	if haproxyTimeoutRE.MatchString(strings.TrimSpace(value)) {
+		return nil
+	}
+	return []string{fmt.Sprintf("metadata.annotations[%s]: %q is not a valid timeout and is ignored; the value must be a number with an optional unit of us, ms, s, m, h or d", annotation, value)}
+}
+
+// balanceWarnings returns a warning if the balance annotation has a value the
+// router does not support.
+func balanceWarnings(annotation, value string) []string {
+	if supportedBalanceValues.Has(strings.TrimSpace(value)) {
+		return nil
+	}
+	return []string{fmt.Sprintf("metadata.annotations[%s]: unsupported value %q; supported values: %s", annotation, value, strings.Join(sets.List(supportedBalanceValues), ", "))}
+}
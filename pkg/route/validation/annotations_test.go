@@ -0,0 +1,55 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRegisterAnnotationValidator(t *testing.T) {
+	const customAnnotation = "example.com/custom"
+	defer func() {
+		annotationValidatorsLock.Lock()
+		defer annotationValidatorsLock.Unlock()
+		delete(annotationValidators, customAnnotation)
+	}()
+
+	var called []string
+	RegisterAnnotationValidator(customAnnotation, func(annotation, value string) []string {
+		called = append(called, value)
+		if value != "ok" {
+			return []string{annotation + " is not ok"}
+		}
+		return nil
+	})
+
+	warnings := Warnings(&routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				customAnnotation:                      "not ok",
+				"haproxy.router.openshift.io/balance": "round-robin",
+			},
+		},
+	})
+	expected := []string{
+		"example.com/custom is not ok",
+		`metadata.annotations[haproxy.router.openshift.io/balance]: unsupported value "round-robin"; supported values: leastconn, random, roundrobin, source`,
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("expected %#v, got %#v", expected, warnings)
+	}
+	if !reflect.DeepEqual(called, []string{"not ok"}) {
+		t.Errorf("expected the validator to be called once with the annotation value, got %#v", called)
+	}
+
+	// routes without the annotation do not run the validator
+	called = nil
+	if warnings := Warnings(&routev1.Route{}); len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %#v", warnings)
+	}
+	if len(called) != 0 {
+		t.Errorf("expected the validator not to be called, got %#v", called)
+	}
+}
@@ -2,10 +2,14 @@ package validation
 
 import (
 	"context"
+	cryptotls "crypto/tls"
 	"fmt"
+	"net"
+	"path"
 	"regexp"
 	"slices"
 	"strings"
+	"unicode"
 
 	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -18,6 +22,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apiserver/pkg/authentication/user"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
 
 	routev1 "github.com/openshift/api/route/v1"
 	"github.com/openshift/library-go/pkg/authorization/authorizationutil"
@@ -31,12 +37,15 @@ const (
 	// maxHeaderValueSize is the maximum allowed length of an HTTP header
 	// value.
 	maxHeaderValueSize = 16384
-	// maxResponseHeaderList is the maximum allowed number of HTTP response
-	// header actions.
+	// maxResponseHeaderList is the default maximum allowed number of HTTP
+	// response header actions.
 	maxResponseHeaderList = 20
-	// maxRequestHeaderList is the maximum allowed number of HTTP request
-	// header actions.
+	// maxRequestHeaderList is the default maximum allowed number of HTTP
+	// request header actions.
 	maxRequestHeaderList = 20
+	// defaultBackendWeight is the weight the router uses for backends of a
+	// route without a weight.
+	defaultBackendWeight = 100
 	// permittedHeaderNameErrorMessage is the API validation message for an
 	// invalid HTTP header name.
 	permittedHeaderNameErrorMessage = "name must be a valid HTTP header name as defined in RFC 2616 section 4.2"
@@ -60,6 +69,17 @@ const (
 	permittedResponseHeaderValueErrorMessage = "Either header value provided is not in correct format or the converter specified is not allowed. The dynamic header value  may use HAProxy's %[] syntax and otherwise must be a valid HTTP header value as defined in https://datatracker.ietf.org/doc/html/rfc7230#section-3.2 Sample fetchers allowed are res.hdr, ssl_c_der. Converters allowed are lower, base64."
 	// routerServiceAccount is used to validate RBAC permissions for externalCertificate
 	routerServiceAccount = "system:serviceaccount:openshift-ingress:router"
+	// setForwardedHeadersAnnotation is the route annotation configuring how
+	// the router handles the Forwarded and X-Forwarded-* request headers.
+	setForwardedHeadersAnnotation = "haproxy.router.openshift.io/set-forwarded-headers"
+	// ipWhitelistAnnotation and ipAllowlistAnnotation are the route
+	// annotations restricting the source addresses allowed to access the
+	// route to a space separated list of IP addresses and CIDRs.
+	ipWhitelistAnnotation = "haproxy.router.openshift.io/ip_whitelist"
+	ipAllowlistAnnotation = "haproxy.router.openshift.io/ip_allowlist"
+	// rewriteTargetAnnotation is the route annotation replacing spec.path
+	// in the request path forwarded to the backend.
+	rewriteTargetAnnotation = "haproxy.router.openshift.io/rewrite-target"
 )
 
 var (
@@ -74,12 +94,34 @@ var (
 	// permittedResponseHeaderValueRE is a compiled regexp for validating an
 	// HTTP response header value.
 	permittedResponseHeaderValueRE = regexp.MustCompile(strings.Replace(permittedHeaderValueTemplate, "XYZ", "res", 1))
+	// supportedSetForwardedHeadersValues are the values the router accepts
+	// for the set-forwarded-headers annotation.
+	supportedSetForwardedHeadersValues = sets.New("append", "replace", "never", "if-none")
 )
 
 func ValidateRoute(ctx context.Context, route *routev1.Route, sarCreator routecommon.SubjectAccessReviewCreator, secretsGetter corev1client.SecretsGetter, opts routecommon.RouteValidationOptions) field.ErrorList {
 	return validateRoute(ctx, route, true, sarCreator, secretsGetter, opts)
 }
 
+// ValidateRouteComplete normalizes a copy of route and returns the validation
+// errors and warnings of the normalized route, as returned by ValidateRoute and
+// WarningsWithOptions, together with the normalized route. The host and subdomain are
+// lowercased, since hostnames are case-insensitive. The input route is not
+// modified.
+func ValidateRouteComplete(ctx context.Context, route *routev1.Route, sarCreator routecommon.SubjectAccessReviewCreator, secretsGetter corev1client.SecretsGetter, opts routecommon.RouteValidationOptions) (field.ErrorList, []string, *routev1.Route) {
+	normalized := normalizeRoute(route)
+	return ValidateRoute(ctx, normalized, sarCreator, secretsGetter, opts), WarningsWithOptions(normalized, opts), normalized
+}
+
+// normalizeRoute returns a copy of route with the host and subdomain
+// lowercased.
+func normalizeRoute(route *routev1.Route) *routev1.Route {
+	normalized := route.DeepCopy()
+	normalized.Spec.Host = strings.ToLower(normalized.Spec.Host)
+	normalized.Spec.Subdomain = strings.ToLower(normalized.Spec.Subdomain)
+	return normalized
+}
+
 // validLabels - used in the ValidateRouteUpdate function to check if "older" routes conform to DNS1123Labels or not
 func validLabels(host string) bool {
 	if len(host) == 0 {
@@ -100,8 +142,56 @@ func checkLabelSegments(host string) bool {
 	return true
 }
 
+// matchingHostSuffix returns the first of suffixes that host ends in. Host and
+// suffixes are compared case-insensitively and without a trailing dot, and a
+// suffix only matches on a label boundary.
+func matchingHostSuffix(host string, suffixes []string) (string, bool) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, suffix := range suffixes {
+		normalized := strings.ToLower(strings.Trim(suffix, "."))
+		if len(normalized) == 0 {
+			continue
+		}
+		if host == normalized || strings.HasSuffix(host, "."+normalized) {
+			return suffix, true
+		}
+	}
+	return "", false
+}
+
+// totalBackendWeight returns the sum of the weights of the backends of the
+// route, unset weights counting as defaultBackendWeight like in the router.
+func totalBackendWeight(route *routev1.Route) int32 {
+	weight := func(w *int32) int32 {
+		if w == nil {
+			return defaultBackendWeight
+		}
+		return *w
+	}
+	total := weight(route.Spec.To.Weight)
+	for _, svc := range route.Spec.AlternateBackends {
+		total += weight(svc.Weight)
+	}
+	return total
+}
+
+// headerListLimit returns the configured maximum number of header actions,
+// or defaultLimit if none is configured. Negative limits are rejected by
+// RouteValidationOptions.Validate before.
+func headerListLimit(configured, defaultLimit int) int {
+	if configured == 0 {
+		return defaultLimit
+	}
+	return configured
+}
+
 // validateRoute - private function to validate route
 func validateRoute(ctx context.Context, route *routev1.Route, checkHostname bool, sarc routecommon.SubjectAccessReviewCreator, secrets corev1client.SecretsGetter, opts routecommon.RouteValidationOptions) field.ErrorList {
+	// invalid options are a misconfiguration of the caller, the route cannot be validated with them
+	if err := opts.Validate(); err != nil {
+		return field.ErrorList{field.InternalError(nil, fmt.Errorf("invalid route validation options: %w", err))}
+	}
+
 	//ensure meta is set properly
 	result := validateObjectMeta(&route.ObjectMeta, true, validateRouteName, field.NewPath("metadata"))
 
@@ -124,6 +214,16 @@ func validateRoute(ctx context.Context, route *routev1.Route, checkHostname bool
 				}
 			}
 		}
+
+		// Forbidden suffixes are checked regardless of DNS compliance.
+		if suffix, ok := matchingHostSuffix(route.Spec.Host, opts.ForbiddenHostSuffixes); ok {
+			result = append(result, field.Invalid(specPath.Child("host"), route.Spec.Host, fmt.Sprintf("host must not end in %q", suffix)))
+		}
+		if len(opts.AllowedDomainSuffixes) > 0 {
+			if _, ok := matchingHostSuffix(route.Spec.Host, opts.AllowedDomainSuffixes); !ok {
+				result = append(result, field.Invalid(specPath.Child("host"), route.Spec.Host, fmt.Sprintf("host must end in one of the allowed domain suffixes: %s", strings.Join(opts.AllowedDomainSuffixes, ", "))))
+			}
+		}
 	}
 
 	if len(route.Spec.Subdomain) > 0 {
@@ -139,6 +239,9 @@ func validateRoute(ctx context.Context, route *routev1.Route, checkHostname bool
 				result = append(result, field.Invalid(field.NewPath("spec.subdomain"), label, strings.Join(errs, ", ")))
 			}
 		}
+		if suffix, ok := matchingHostSuffix(route.Spec.Subdomain, opts.ForbiddenHostSuffixes); ok {
+			result = append(result, field.Invalid(field.NewPath("spec.subdomain"), route.Spec.Subdomain, fmt.Sprintf("subdomain must not end in %q", suffix)))
+		}
 	}
 
 	if err := validateWildcardPolicy(route.Spec.Host, route.Spec.WildcardPolicy, specPath.Child("wildcardPolicy")); err != nil {
@@ -152,16 +255,23 @@ func validateRoute(ctx context.Context, route *routev1.Route, checkHostname bool
 			}
 		}
 		actionsPath := field.NewPath("spec", "httpHeaders", "actions")
-		if len(route.Spec.HTTPHeaders.Actions.Response) > maxResponseHeaderList {
-			result = append(result, field.Invalid(actionsPath.Child("response"), route.Spec.HTTPHeaders.Actions.Response, fmt.Sprintf("response headers list can't exceed %d items", maxResponseHeaderList)))
+		forbiddenHeaders := forbiddenHeaderNames(opts.ForbiddenHeaderNames)
+		maxResponses := headerListLimit(opts.MaxResponseHeaderList, maxResponseHeaderList)
+		if len(route.Spec.HTTPHeaders.Actions.Response) > maxResponses {
+			result = append(result, field.Invalid(actionsPath.Child("response"), route.Spec.HTTPHeaders.Actions.Response, fmt.Sprintf("response headers list can't exceed %d items", maxResponses)))
 		} else {
-			result = append(result, validateHeaders(actionsPath.Child("response"), route.Spec.HTTPHeaders.Actions.Response, permittedResponseHeaderValueRE, permittedResponseHeaderValueErrorMessage)...)
+			result = append(result, validateHeaders(actionsPath.Child("response"), route.Spec.HTTPHeaders.Actions.Response, HeaderDirectionResponse, forbiddenHeaders)...)
 		}
 
-		if len(route.Spec.HTTPHeaders.Actions.Request) > maxRequestHeaderList {
-			result = append(result, field.Invalid(actionsPath.Child("request"), route.Spec.HTTPHeaders.Actions.Request, fmt.Sprintf("request headers list can't exceed %d items", maxRequestHeaderList)))
+		maxRequests := headerListLimit(opts.MaxRequestHeaderList, maxRequestHeaderList)
+		if len(route.Spec.HTTPHeaders.Actions.Request) > maxRequests {
+			result = append(result, field.Invalid(actionsPath.Child("request"), route.Spec.HTTPHeaders.Actions.Request, fmt.Sprintf("request headers list can't exceed %d items", maxRequests)))
 		} else {
-			result = append(result, validateHeaders(actionsPath.Child("request"), route.Spec.HTTPHeaders.Actions.Request, permittedRequestHeaderValueRE, permittedRequestHeaderValueErrorMessage)...)
+			result = append(result, validateHeaders(actionsPath.Child("request"), route.Spec.HTTPHeaders.Actions.Request, HeaderDirectionRequest, forbiddenHeaders)...)
+		}
+
+		if total := len(route.Spec.HTTPHeaders.Actions.Request) + len(route.Spec.HTTPHeaders.Actions.Response); opts.MaxCombinedHeaderList > 0 && total > opts.MaxCombinedHeaderList {
+			result = append(result, field.Invalid(actionsPath, total, fmt.Sprintf("request and response headers lists combined can't exceed %d items", opts.MaxCombinedHeaderList)))
 		}
 	}
 
@@ -199,6 +309,11 @@ func validateRoute(ctx context.Context, route *routev1.Route, checkHostname bool
 			result = append(result, field.Invalid(backendPath.Index(i).Child("weight"), svc.Weight, "weight must be an integer between 0 and 256"))
 		}
 	}
+	if opts.MaxTotalBackendWeight > 0 {
+		if total := totalBackendWeight(route); total > opts.MaxTotalBackendWeight {
+			result = append(result, field.Invalid(backendPath, total, fmt.Sprintf("the sum of the weights of spec.to and spec.alternateBackends must not exceed %d", opts.MaxTotalBackendWeight)))
+		}
+	}
 
 	if route.Spec.Port != nil {
 		switch target := route.Spec.Port.TargetPort; {
@@ -315,6 +430,14 @@ func validateTLSExternalCertificate(ctx context.Context, route *routev1.Route, f
 	tls := route.Spec.TLS
 	var errs field.ErrorList
 
+	// The name must be a valid secret name, otherwise the lookups below fail with a confusing not found.
+	if msgs := kvalidation.IsDNS1123Subdomain(tls.ExternalCertificate.Name); len(msgs) > 0 {
+		for _, msg := range msgs {
+			errs = append(errs, field.Invalid(fldPath.Child("name"), tls.ExternalCertificate.Name, msg))
+		}
+		return errs
+	}
+
 	// The router serviceaccount must have permission to get/list/watch the referenced secret.
 	// The role and rolebinding to provide this access must be provided by the user.
 	if err := authorizationutil.Authorize(sarc, &user.DefaultInfo{Name: routerServiceAccount},
@@ -358,12 +481,43 @@ func validateTLSExternalCertificate(ctx context.Context, route *routev1.Route, f
 
 	// The secret should be of type kubernetes.io/tls
 	if secret.Type != corev1.SecretTypeTLS {
-		errs = append(errs, field.Invalid(fldPath, tls.ExternalCertificate.Name, fmt.Sprintf("secret of type %q required", corev1.SecretTypeTLS)))
+		return append(errs, field.Invalid(fldPath, tls.ExternalCertificate.Name, fmt.Sprintf("secret of type %q required", corev1.SecretTypeTLS)))
+	}
+
+	// The secret should contain a certificate and a private key that belong together.
+	// This is only checked once the router is allowed to read the secret.
+	if len(errs) == 0 {
+		if err := validateTLSKeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]); err != nil {
+			errs = append(errs, field.Invalid(fldPath, tls.ExternalCertificate.Name, err.Error()))
+		}
 	}
 
 	return errs
 }
 
+// validateTLSKeyPair checks that certPEM and keyPEM parse and that the private key
+// corresponds to the certificate. The error indicates which of them is invalid.
+func validateTLSKeyPair(certPEM, keyPEM []byte) error {
+	if _, err := certutil.ParseCertsPEM(certPEM); err != nil {
+		return fmt.Errorf("secret %s does not contain a valid certificate: %v", corev1.TLSCertKey, err)
+	}
+	if _, err := keyutil.ParsePrivateKeyPEM(keyPEM); err != nil {
+		return fmt.Errorf("secret %s does not contain a valid private key: %v", corev1.TLSPrivateKeyKey, err)
+	}
+	if _, err := cryptotls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return fmt.Errorf("secret %s does not match the certificate in %s: %v", corev1.TLSPrivateKeyKey, corev1.TLSCertKey, err)
+	}
+	return nil
+}
+
+// allowedInsecureEdgeTerminationPolicies are the insecure edge termination
+// policies accepted for each TLS termination type.
+var allowedInsecureEdgeTerminationPolicies = map[routev1.TLSTerminationType][]routev1.InsecureEdgeTerminationPolicyType{
+	routev1.TLSTerminationEdge:        {routev1.InsecureEdgeTerminationPolicyNone, routev1.InsecureEdgeTerminationPolicyAllow, routev1.InsecureEdgeTerminationPolicyRedirect},
+	routev1.TLSTerminationReencrypt:   {routev1.InsecureEdgeTerminationPolicyNone, routev1.InsecureEdgeTerminationPolicyAllow, routev1.InsecureEdgeTerminationPolicyRedirect},
+	routev1.TLSTerminationPassthrough: {routev1.InsecureEdgeTerminationPolicyNone, routev1.InsecureEdgeTerminationPolicyRedirect},
+}
+
 // validateInsecureEdgeTerminationPolicy tests fields for different types of
 // insecure options. Called by validateTLS.
 func validateInsecureEdgeTerminationPolicy(tls *routev1.TLSConfig, fldPath *field.Path) *field.Error {
@@ -372,30 +526,22 @@ func validateInsecureEdgeTerminationPolicy(tls *routev1.TLSConfig, fldPath *fiel
 		return nil
 	}
 
-	// It is an edge-terminated or reencrypt route, check insecure option value is
-	// one of None(for disable), Allow or Redirect.
-	allowedValues := map[routev1.InsecureEdgeTerminationPolicyType]struct{}{
-		routev1.InsecureEdgeTerminationPolicyNone:     {},
-		routev1.InsecureEdgeTerminationPolicyAllow:    {},
-		routev1.InsecureEdgeTerminationPolicyRedirect: {},
+	allowedValues, ok := allowedInsecureEdgeTerminationPolicies[tls.Termination]
+	if !ok {
+		return nil
 	}
-
-	switch tls.Termination {
-	case routev1.TLSTerminationReencrypt:
-		fallthrough
-	case routev1.TLSTerminationEdge:
-		if _, ok := allowedValues[tls.InsecureEdgeTerminationPolicy]; !ok {
-			msg := fmt.Sprintf("invalid value for InsecureEdgeTerminationPolicy option, acceptable values are %s, %s, %s, or empty", routev1.InsecureEdgeTerminationPolicyNone, routev1.InsecureEdgeTerminationPolicyAllow, routev1.InsecureEdgeTerminationPolicyRedirect)
-			return field.Invalid(fldPath, tls.InsecureEdgeTerminationPolicy, msg)
-		}
-	case routev1.TLSTerminationPassthrough:
-		if routev1.InsecureEdgeTerminationPolicyNone != tls.InsecureEdgeTerminationPolicy && routev1.InsecureEdgeTerminationPolicyRedirect != tls.InsecureEdgeTerminationPolicy {
-			msg := fmt.Sprintf("invalid value for InsecureEdgeTerminationPolicy option, acceptable values are %s, %s, or empty", routev1.InsecureEdgeTerminationPolicyNone, routev1.InsecureEdgeTerminationPolicyRedirect)
-			return field.Invalid(fldPath, tls.InsecureEdgeTerminationPolicy, msg)
+	for _, allowed := range allowedValues {
+		if tls.InsecureEdgeTerminationPolicy == allowed {
+			return nil
 		}
 	}
 
-	return nil
+	acceptable := make([]string, 0, len(allowedValues))
+	for _, allowed := range allowedValues {
+		acceptable = append(acceptable, string(allowed))
+	}
+	msg := fmt.Sprintf("invalid value %q for InsecureEdgeTerminationPolicy option with %s termination, acceptable values are %s, or empty", tls.InsecureEdgeTerminationPolicy, tls.Termination, strings.Join(acceptable, ", "))
+	return field.Invalid(fldPath, tls.InsecureEdgeTerminationPolicy, msg)
 }
 
 var (
@@ -420,14 +566,68 @@ func validateWildcardPolicy(host string, policy routev1.WildcardPolicyType, fldP
 	return nil
 }
 
-var (
-	notAllowedHTTPHeaders        = []string{"strict-transport-security", "proxy", "cookie", "set-cookie"}
-	notAllowedHTTPHeadersMessage = fmt.Sprintf("the following headers may not be modified using this API: %v", strings.Join(notAllowedHTTPHeaders, ", "))
+// notAllowedHTTPHeaders are the headers that may never be modified using the
+// route API.
+var notAllowedHTTPHeaders = []string{"strict-transport-security", "proxy", "cookie", "set-cookie"}
+
+// forbiddenHeaderNames returns the default forbidden header names extended
+// with the configured ones, lower-cased and without duplicates.
+func forbiddenHeaderNames(configured []string) []string {
+	if len(configured) == 0 {
+		return notAllowedHTTPHeaders
+	}
+	result := append([]string{}, notAllowedHTTPHeaders...)
+	for _, name := range configured {
+		name = strings.ToLower(name)
+		if !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// HeaderDirection identifies whether HTTP header actions apply to requests or
+// to responses, which determines the sample fetchers allowed in dynamic values.
+type HeaderDirection string
+
+const (
+	// HeaderDirectionRequest is used for spec.httpHeaders.actions.request.
+	HeaderDirectionRequest HeaderDirection = "Request"
+	// HeaderDirectionResponse is used for spec.httpHeaders.actions.response.
+	HeaderDirectionResponse HeaderDirection = "Response"
 )
 
+// ValidateHTTPHeaderValue verifies that value is a valid value for a header
+// action of the given direction. The value may use HAProxy's dynamic %[]
+// syntax with the allowed sample fetchers and converters, and is validated
+// exactly the way ValidateRoute validates spec.httpHeaders.actions[*].action.set.value.
+func ValidateHTTPHeaderValue(fldPath *field.Path, value string, direction HeaderDirection) field.ErrorList {
+	var valueRE *regexp.Regexp
+	var valueErrorMessage string
+	switch direction {
+	case HeaderDirectionRequest:
+		valueRE, valueErrorMessage = permittedRequestHeaderValueRE, permittedRequestHeaderValueErrorMessage
+	case HeaderDirectionResponse:
+		valueRE, valueErrorMessage = permittedResponseHeaderValueRE, permittedResponseHeaderValueErrorMessage
+	default:
+		return field.ErrorList{field.InternalError(fldPath, fmt.Errorf("unknown header direction %q", direction))}
+	}
+
+	switch valueLength := len(value); {
+	case valueLength == 0:
+		return field.ErrorList{field.Required(fldPath, "")}
+	case valueLength > maxHeaderValueSize:
+		return field.ErrorList{field.Invalid(fldPath, value, fmt.Sprintf("value exceeds the maximum length, which is %d", maxHeaderValueSize))}
+	case !valueRE.MatchString(value):
+		return field.ErrorList{field.Invalid(fldPath, value, valueErrorMessage)}
+	}
+	return nil
+}
+
 // validateHeaders verifies that the given slice of request or response headers
-// is valid using the given regexp.
-func validateHeaders(fldPath *field.Path, headers []routev1.RouteHTTPHeader, valueRegexpForHeaderValue *regexp.Regexp, valueErrorMessage string) field.ErrorList {
+// is valid. The names of the headers must not match any of forbiddenHeaders,
+// ignoring case.
+func validateHeaders(fldPath *field.Path, headers []routev1.RouteHTTPHeader, direction HeaderDirection, forbiddenHeaders []string) field.ErrorList {
 	allErrs := field.ErrorList{}
 	headersMap := map[string]struct{}{}
 	for i, header := range headers {
@@ -448,8 +648,8 @@ func validateHeaders(fldPath *field.Path, headers []routev1.RouteHTTPHeader, val
 		case nameLength > maxHeaderNameSize:
 			err := field.Invalid(idxPath.Child("name"), header.Name, fmt.Sprintf("name exceeds the maximum length, which is %d", maxHeaderNameSize))
 			allErrs = append(allErrs, err)
-		case slices.Contains(notAllowedHTTPHeaders, strings.ToLower(header.Name)):
-			err := field.Forbidden(idxPath.Child("name"), notAllowedHTTPHeadersMessage)
+		case slices.ContainsFunc(forbiddenHeaders, func(forbidden string) bool { return strings.EqualFold(forbidden, header.Name) }):
+			err := field.Forbidden(idxPath.Child("name"), fmt.Sprintf("the following headers may not be modified using this API: %v", strings.Join(forbiddenHeaders, ", ")))
 			allErrs = append(allErrs, err)
 		case !permittedHeaderNameRE.MatchString(header.Name):
 			err := field.Invalid(idxPath.Child("name"), header.Name, permittedHeaderNameErrorMessage)
@@ -466,17 +666,7 @@ func validateHeaders(fldPath *field.Path, headers []routev1.RouteHTTPHeader, val
 			allErrs = append(allErrs, err)
 		}
 		if header.Action.Set != nil {
-			switch valueLength := len(header.Action.Set.Value); {
-			case valueLength == 0:
-				err := field.Required(idxPath.Child("action", "set", "value"), "")
-				allErrs = append(allErrs, err)
-			case valueLength > maxHeaderValueSize:
-				err := field.Invalid(idxPath.Child("action", "set", "value"), header.Action.Set.Value, fmt.Sprintf("value exceeds the maximum length, which is %d", maxHeaderValueSize))
-				allErrs = append(allErrs, err)
-			case !valueRegexpForHeaderValue.MatchString(header.Action.Set.Value):
-				err := field.Invalid(idxPath.Child("action", "set", "value"), header.Action.Set.Value, valueErrorMessage)
-				allErrs = append(allErrs, err)
-			}
+			allErrs = append(allErrs, ValidateHTTPHeaderValue(idxPath.Child("action", "set", "value"), header.Action.Set.Value, direction)...)
 		}
 	}
 	return allErrs
@@ -524,10 +714,218 @@ func validateKubeFinalizerName(stringValue string, fldPath *field.Path) field.Er
 }
 
 func Warnings(route *routev1.Route) []string {
+	var warnings []string
 	if len(route.Spec.Host) != 0 && len(route.Spec.Subdomain) != 0 {
-		var warnings []string
 		warnings = append(warnings, "spec.host is set; spec.subdomain may be ignored")
-		return warnings
 	}
-	return nil
+	if route.Spec.WildcardPolicy == routev1.WildcardPolicySubdomain {
+		// the router admits a subdomain wildcard route for every host in the
+		// domain of spec.host, including the host itself
+		if i := strings.Index(route.Spec.Host, "."); i > 0 && i < len(route.Spec.Host)-1 {
+			warnings = append(warnings, fmt.Sprintf("spec.wildcardPolicy is %s; the route matches any host in *%s, not only %s", routev1.WildcardPolicySubdomain, route.Spec.Host[i:], route.Spec.Host))
+		}
+	}
+	if tls := route.Spec.TLS; tls != nil && tls.Termination == routev1.TLSTerminationReencrypt {
+		// the external certificate only replaces the serving certificate presented to clients,
+		// the connection to the backend is still verified with the destination CA
+		if tls.ExternalCertificate != nil && len(tls.ExternalCertificate.Name) > 0 && len(tls.DestinationCACertificate) == 0 {
+			warnings = append(warnings, "spec.tls.externalCertificate is set but spec.tls.destinationCACertificate is not; the backend certificate will be verified with the default service CA")
+		}
+	}
+	if tls := route.Spec.TLS; tls != nil && tls.Termination == routev1.TLSTerminationPassthrough && route.Spec.Port != nil {
+		// the router forwards the TLS connection as is, so the backend must
+		// terminate TLS itself on the target port
+		if port := route.Spec.Port.TargetPort; port.Type == intstr.String && isHTTPPortName(port.StrVal) {
+			warnings = append(warnings, fmt.Sprintf("spec.port.targetPort %q looks like a plain HTTP port; passthrough routes forward TLS connections to the backend, which must serve TLS on this port", port.StrVal))
+		}
+	}
+	if tls := route.Spec.TLS; tls != nil && tls.InsecureEdgeTerminationPolicy == routev1.InsecureEdgeTerminationPolicyRedirect && len(route.Annotations[rewriteTargetAnnotation]) > 0 {
+		// the redirect keeps the original path, while the backend sees the
+		// rewritten one and may redirect back to it
+		warnings = append(warnings, fmt.Sprintf("spec.tls.insecureEdgeTerminationPolicy is %s and metadata.annotations[%s] is set; redirects from the backend to the rewritten path may cause a redirect loop", routev1.InsecureEdgeTerminationPolicyRedirect, rewriteTargetAnnotation))
+	}
+	if tls := route.Spec.TLS; tls != nil && (tls.Termination == routev1.TLSTerminationEdge || tls.Termination == routev1.TLSTerminationReencrypt) &&
+		tls.InsecureEdgeTerminationPolicy == routev1.InsecureEdgeTerminationPolicyAllow {
+		// the router serves the route over plain HTTP next to TLS, which is
+		// rarely intended for a route that terminates TLS
+		warnings = append(warnings, fmt.Sprintf("spec.tls.insecureEdgeTerminationPolicy is %s; the route is also served over plain HTTP, consider %s to send insecure requests to HTTPS", routev1.InsecureEdgeTerminationPolicyAllow, routev1.InsecureEdgeTerminationPolicyRedirect))
+	}
+	warnings = append(warnings, pathWarnings(route.Spec.Path)...)
+	warnings = append(warnings, httpHeaderWarnings(route.Spec.HTTPHeaders)...)
+	warnings = append(warnings, annotationWarnings(route.Annotations)...)
+	return warnings
+}
+
+// pathWarnings returns a warning if the path contains redundant segments,
+// like "//", "/./" or "/../". The router matches the path verbatim, so
+// requests for the normalized path do not match it. The path is not
+// normalized, the warning only suggests the normalized form, which keeps a
+// trailing slash. Paths not starting with / are reported by ValidateRoute.
+func pathWarnings(routePath string) []string {
+	if !strings.HasPrefix(routePath, "/") {
+		return nil
+	}
+	segments := strings.Split(routePath[1:], "/")
+	redundant := false
+	for i, segment := range segments {
+		// an empty last segment is a trailing slash
+		if segment == "." || segment == ".." || len(segment) == 0 && i < len(segments)-1 {
+			redundant = true
+			break
+		}
+	}
+	if !redundant {
+		return nil
+	}
+	normalized := path.Clean(routePath)
+	if strings.HasSuffix(routePath, "/") && normalized != "/" {
+		normalized += "/"
+	}
+	return []string{fmt.Sprintf("spec.path %q contains redundant segments; the router matches the path as is, consider %q", routePath, normalized)}
+}
+
+// httpHeaderWarnings returns a warning for every header that is set both in
+// the request and in the response actions. This is legal, the request header
+// is sent to the backend and the response header to the client, but setting
+// the same header in both directions is often a copy and paste mistake.
+// Header names are compared case-insensitively.
+func httpHeaderWarnings(headers *routev1.RouteHTTPHeaders) []string {
+	if headers == nil {
+		return nil
+	}
+	requestSets := map[string]int{}
+	for i, header := range headers.Actions.Request {
+		name := strings.ToLower(header.Name)
+		if _, ok := requestSets[name]; ok || header.Action.Type != routev1.Set {
+			continue
+		}
+		requestSets[name] = i
+	}
+	var warnings []string
+	warned := sets.New[string]()
+	for i, header := range headers.Actions.Response {
+		name := strings.ToLower(header.Name)
+		j, ok := requestSets[name]
+		if !ok || header.Action.Type != routev1.Set || warned.Has(name) {
+			continue
+		}
+		warned.Insert(name)
+		warnings = append(warnings, fmt.Sprintf("spec.httpHeaders.actions.request[%d] and spec.httpHeaders.actions.response[%d] both set header %q; the request header is sent to the backend and the response header to the client, make sure both are intended", j, i, header.Name))
+	}
+	return warnings
+}
+
+// WarningsWithOptions returns the warnings of Warnings, and the warnings of the
+// optional checks enabled by opts.
+func WarningsWithOptions(route *routev1.Route, opts routecommon.RouteValidationOptions) []string {
+	warnings := Warnings(route)
+	if opts.WarnOnCertificateHostMismatch {
+		warnings = append(warnings, certificateHostWarnings(route)...)
+	}
+	return warnings
+}
+
+// certificateHostWarnings returns a warning if the inline certificate of the
+// route does not cover spec.host. Passthrough routes are skipped, the backend
+// serves its own certificate. Certificates that cannot be parsed are reported
+// by ValidateRoute.
+func certificateHostWarnings(route *routev1.Route) []string {
+	tls := route.Spec.TLS
+	if tls == nil || tls.Termination == routev1.TLSTerminationPassthrough || len(tls.Certificate) == 0 || len(route.Spec.Host) == 0 {
+		return nil
+	}
+	certs, err := certutil.ParseCertsPEM([]byte(tls.Certificate))
+	if err != nil {
+		return nil
+	}
+	names := certs[0].DNSNames
+	if len(names) == 0 && len(certs[0].Subject.CommonName) > 0 {
+		names = []string{certs[0].Subject.CommonName}
+	}
+	for _, name := range names {
+		if certificateNameMatchesHost(name, route.Spec.Host) {
+			return nil
+		}
+	}
+	if len(names) == 0 {
+		return []string{fmt.Sprintf("spec.tls.certificate has no DNS names and does not match spec.host %q", route.Spec.Host)}
+	}
+	return []string{fmt.Sprintf("spec.tls.certificate does not match spec.host %q; the certificate is valid for %s", route.Spec.Host, strings.Join(names, ", "))}
+}
+
+// certificateNameMatchesHost returns true if the DNS name of a certificate
+// covers host. A wildcard name like *.example.com covers a single label, so it
+// matches foo.example.com but neither example.com nor bar.foo.example.com.
+func certificateNameMatchesHost(name, host string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if name == host {
+		return true
+	}
+	if !strings.HasPrefix(name, "*.") {
+		return false
+	}
+	i := strings.Index(host, ".")
+	return i > 0 && host[i:] == name[1:]
+}
+
+// isHTTPPortName returns true if the port name indicates plain HTTP, like
+// "http", "http-metrics" or "web-http".
+func isHTTPPortName(name string) bool {
+	name = strings.ToLower(name)
+	return name == "http" || strings.HasPrefix(name, "http-") || strings.HasSuffix(name, "-http")
+}
+
+// setForwardedHeadersWarnings returns a warning if the set-forwarded-headers
+// annotation has a value the router does not support.
+func setForwardedHeadersWarnings(annotation, value string) []string {
+	if supportedSetForwardedHeadersValues.Has(value) {
+		return nil
+	}
+	return []string{fmt.Sprintf("metadata.annotations[%s]: unsupported value %q; supported values: %s", annotation, value, strings.Join(sets.List(supportedSetForwardedHeadersValues), ", "))}
+}
+
+// rewriteTargetWarnings returns warnings for a rewrite target the router
+// cannot render into its configuration: control characters end the
+// configuration line and an unterminated %[ sample expression is rejected
+// by HAProxy, which prevents the router from reloading.
+func rewriteTargetWarnings(annotation, value string) []string {
+	var warnings []string
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			warnings = append(warnings, fmt.Sprintf("metadata.annotations[%s]: %q must not contain control characters", annotation, value))
+			break
+		}
+	}
+	depth := 0
+	for i := 0; i < len(value); i++ {
+		switch {
+		case strings.HasPrefix(value[i:], "%["):
+			depth++
+			i++
+		case value[i] == ']' && depth > 0:
+			depth--
+		}
+	}
+	if depth > 0 {
+		warnings = append(warnings, fmt.Sprintf("metadata.annotations[%s]: %q has an unterminated %%[ expression", annotation, value))
+	}
+	return warnings
+}
+
+// ipAllowlistWarnings returns a warning for every entry of the allowlist annotation
+// that is neither an IP address nor a CIDR. The router drops these entries, which
+// can result in allowing more source addresses than intended.
+func ipAllowlistWarnings(annotation, value string) []string {
+	var warnings []string
+	for _, entry := range strings.Fields(value) {
+		if net.ParseIP(entry) != nil {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(entry); err == nil {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("metadata.annotations[%s]: %q is not a valid IP address or CIDR and is ignored", annotation, entry))
+	}
+	return warnings
 }
@@ -3,6 +3,7 @@ package validation
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"regexp"
 	"strings"
 	"testing"
@@ -17,6 +18,8 @@ import (
 	testclient "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/utils/ptr"
 
 	routev1 "github.com/openshift/api/route/v1"
 	routecommon "github.com/openshift/library-go/pkg/route"
@@ -1017,6 +1020,400 @@ func TestValidateRoute(t *testing.T) {
 	}
 }
 
+// TestValidateCombinedHeaderList verifies that the optional combined limit on
+// request and response header actions is enforced.
+func TestValidateCombinedHeaderList(t *testing.T) {
+	headers := func(prefix string, n int) []routev1.RouteHTTPHeader {
+		var result []routev1.RouteHTTPHeader
+		for i := 0; i < n; i++ {
+			result = append(result, routev1.RouteHTTPHeader{
+				Name: fmt.Sprintf("%s-%d", prefix, i),
+				Action: routev1.RouteHTTPHeaderActionUnion{
+					Type: routev1.Delete,
+				},
+			})
+		}
+		return result
+	}
+	tests := []struct {
+		name           string
+		requests       int
+		responses      int
+		maxCombined    int
+		expectedErrors int
+	}{
+		{
+			name:           "no combined limit",
+			requests:       maxRequestHeaderList,
+			responses:      maxResponseHeaderList,
+			expectedErrors: 0,
+		},
+		{
+			name:           "at the combined limit",
+			requests:       10,
+			responses:      15,
+			maxCombined:    25,
+			expectedErrors: 0,
+		},
+		{
+			name:           "above the combined limit",
+			requests:       10,
+			responses:      16,
+			maxCombined:    25,
+			expectedErrors: 1,
+		},
+		{
+			name:           "above the combined limit with only requests",
+			requests:       6,
+			maxCombined:    5,
+			expectedErrors: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "headers",
+					Namespace: "foo",
+				},
+				Spec: routev1.RouteSpec{
+					Host: "www.example.com",
+					To:   createRouteSpecTo("serviceName", "Service"),
+					HTTPHeaders: &routev1.RouteHTTPHeaders{
+						Actions: routev1.RouteHTTPHeaderActions{
+							Request:  headers("X-Request", tc.requests),
+							Response: headers("X-Response", tc.responses),
+						},
+					},
+				},
+			}
+			errs := ValidateRoute(context.Background(), route, &testSARCreator{allow: false}, &testSecretGetter{}, routecommon.RouteValidationOptions{MaxCombinedHeaderList: tc.maxCombined})
+			if len(errs) != tc.expectedErrors {
+				t.Fatalf("expected %d error(s), got %d. %v", tc.expectedErrors, len(errs), errs)
+			}
+			if tc.expectedErrors > 0 && errs[0].Field != "spec.httpHeaders.actions" {
+				t.Errorf("unexpected error field: %v", errs[0])
+			}
+		})
+	}
+}
+
+// TestValidateHeaderListLimits verifies that the configurable limits on request
+// and response header actions are enforced and reported in the error.
+func TestValidateHeaderListLimits(t *testing.T) {
+	headers := func(prefix string, n int) []routev1.RouteHTTPHeader {
+		var result []routev1.RouteHTTPHeader
+		for i := 0; i < n; i++ {
+			result = append(result, routev1.RouteHTTPHeader{
+				Name: fmt.Sprintf("%s-%d", prefix, i),
+				Action: routev1.RouteHTTPHeaderActionUnion{
+					Type: routev1.Delete,
+				},
+			})
+		}
+		return result
+	}
+	tests := []struct {
+		name          string
+		requests      int
+		responses     int
+		opts          routecommon.RouteValidationOptions
+		expectedError string
+	}{
+		{
+			name:      "default limits",
+			requests:  maxRequestHeaderList,
+			responses: maxResponseHeaderList,
+		},
+		{
+			name:          "above the default request limit",
+			requests:      maxRequestHeaderList + 1,
+			expectedError: "request headers list can't exceed 20 items",
+		},
+		{
+			name:      "raised limits",
+			requests:  30,
+			responses: 40,
+			opts:      routecommon.RouteValidationOptions{MaxRequestHeaderList: 30, MaxResponseHeaderList: 40},
+		},
+		{
+			name:          "above a raised response limit",
+			responses:     41,
+			opts:          routecommon.RouteValidationOptions{MaxResponseHeaderList: 40},
+			expectedError: "response headers list can't exceed 40 items",
+		},
+		{
+			name:          "above a lowered request limit",
+			requests:      6,
+			opts:          routecommon.RouteValidationOptions{MaxRequestHeaderList: 5},
+			expectedError: "request headers list can't exceed 5 items",
+		},
+		{
+			name:          "negative request limit",
+			requests:      1,
+			opts:          routecommon.RouteValidationOptions{MaxRequestHeaderList: -1},
+			expectedError: "invalid route validation options: MaxRequestHeaderList must not be negative, got -1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "headers",
+					Namespace: "foo",
+				},
+				Spec: routev1.RouteSpec{
+					Host: "www.example.com",
+					To:   createRouteSpecTo("serviceName", "Service"),
+					HTTPHeaders: &routev1.RouteHTTPHeaders{
+						Actions: routev1.RouteHTTPHeaderActions{
+							Request:  headers("X-Request", tc.requests),
+							Response: headers("X-Response", tc.responses),
+						},
+					},
+				},
+			}
+			errs := ValidateRoute(context.Background(), route, &testSARCreator{allow: false}, &testSecretGetter{}, tc.opts)
+			if len(tc.expectedError) == 0 {
+				if len(errs) != 0 {
+					t.Fatalf("expected no errors, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got %d. %v", len(errs), errs)
+			}
+			if errs[0].Detail != tc.expectedError {
+				t.Errorf("expected error %q, got %q", tc.expectedError, errs[0].Detail)
+			}
+		})
+	}
+}
+
+// TestValidateForbiddenHostSuffixes verifies that hosts and subdomains ending in
+// a forbidden suffix are rejected, even when DNS label checks are skipped.
+func TestValidateForbiddenHostSuffixes(t *testing.T) {
+	suffixes := []string{"svc.cluster.local", ".apps-crc.testing."}
+	tests := []struct {
+		name          string
+		host          string
+		subdomain     string
+		annotations   map[string]string
+		expectedField string
+	}{
+		{
+			name: "allowed host",
+			host: "www.example.com",
+		},
+		{
+			name:          "forbidden host",
+			host:          "foo.bar.svc.cluster.local",
+			expectedField: "spec.host",
+		},
+		{
+			name:          "forbidden host equal to the suffix",
+			host:          "svc.cluster.local",
+			expectedField: "spec.host",
+		},
+		{
+			name:          "forbidden host with different case",
+			host:          "Foo.SVC.Cluster.Local",
+			expectedField: "spec.host",
+		},
+		{
+			name:          "forbidden host with trailing dot",
+			host:          "foo.apps-crc.testing.",
+			expectedField: "spec.host",
+		},
+		{
+			name: "suffix only matches on a label boundary",
+			host: "foosvc.cluster.local",
+		},
+		{
+			name:          "forbidden host with non DNS compliant host annotation",
+			host:          "x" + strings.Repeat("a", 63) + ".svc.cluster.local",
+			annotations:   map[string]string{routev1.AllowNonDNSCompliantHostAnnotation: "true"},
+			expectedField: "spec.host",
+		},
+		{
+			name:      "allowed subdomain",
+			subdomain: "foo",
+		},
+		{
+			name:          "forbidden subdomain",
+			subdomain:     "foo.svc.cluster.local",
+			expectedField: "spec.subdomain",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "name",
+					Namespace:   "foo",
+					Annotations: tc.annotations,
+				},
+				Spec: routev1.RouteSpec{
+					Host:      tc.host,
+					Subdomain: tc.subdomain,
+					To:        createRouteSpecTo("serviceName", "Service"),
+				},
+			}
+			errs := ValidateRoute(context.Background(), route, &testSARCreator{allow: false}, &testSecretGetter{}, routecommon.RouteValidationOptions{ForbiddenHostSuffixes: suffixes})
+			// other checks may reject the host as well, only look at the suffix errors
+			var suffixErrs field.ErrorList
+			for _, err := range errs {
+				if strings.Contains(err.Detail, "must not end in") {
+					suffixErrs = append(suffixErrs, err)
+				}
+			}
+			if len(tc.expectedField) == 0 {
+				if len(suffixErrs) != 0 {
+					t.Fatalf("expected no suffix errors, got %v", suffixErrs)
+				}
+				return
+			}
+			if len(suffixErrs) != 1 {
+				t.Fatalf("expected 1 suffix error, got %d. %v", len(suffixErrs), errs)
+			}
+			if suffixErrs[0].Field != tc.expectedField {
+				t.Errorf("unexpected error field: %v", suffixErrs[0])
+			}
+		})
+	}
+}
+
+// TestValidateAllowedDomainSuffixes verifies that hosts not ending in one of the
+// allowed domain suffixes are rejected.
+func TestValidateAllowedDomainSuffixes(t *testing.T) {
+	tests := []struct {
+		name        string
+		suffixes    []string
+		host        string
+		expectedErr bool
+	}{
+		{
+			name:     "allowed suffix",
+			suffixes: []string{"apps.example.com", "example.org"},
+			host:     "www.apps.example.com",
+		},
+		{
+			name:     "allowed suffix with different case and trailing dot",
+			suffixes: []string{"Example.ORG."},
+			host:     "www.example.org",
+		},
+		{
+			name:        "disallowed suffix",
+			suffixes:    []string{"apps.example.com", "example.org"},
+			host:        "www.example.net",
+			expectedErr: true,
+		},
+		{
+			name:        "suffix only matches on a label boundary",
+			suffixes:    []string{"example.org"},
+			host:        "www.badexample.org",
+			expectedErr: true,
+		},
+		{
+			name: "no restriction without allowed suffixes",
+			host: "www.example.net",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "foo"},
+				Spec: routev1.RouteSpec{
+					Host: tc.host,
+					To:   createRouteSpecTo("serviceName", "Service"),
+				},
+			}
+			errs := ValidateRoute(context.Background(), route, &testSARCreator{allow: false}, &testSecretGetter{}, routecommon.RouteValidationOptions{AllowedDomainSuffixes: tc.suffixes})
+			if tc.expectedErr {
+				if len(errs) != 1 || errs[0].Field != "spec.host" || !strings.Contains(errs[0].Detail, "allowed domain suffixes") {
+					t.Fatalf("expected a single spec.host error, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 0 {
+				t.Fatalf("expected no errors, got %v", errs)
+			}
+		})
+	}
+}
+
+// TestValidateMaxTotalBackendWeight verifies that the sum of the weights of the
+// backends of a route is bounded by the configured maximum.
+func TestValidateMaxTotalBackendWeight(t *testing.T) {
+	tests := []struct {
+		name        string
+		max         int32
+		weight      *int32
+		alternates  []*int32
+		expectedErr bool
+	}{
+		{
+			name:       "just under the cap",
+			max:        500,
+			weight:     ptr.To[int32](256),
+			alternates: []*int32{ptr.To[int32](200), ptr.To[int32](43)},
+		},
+		{
+			name:       "equal to the cap",
+			max:        500,
+			weight:     ptr.To[int32](256),
+			alternates: []*int32{ptr.To[int32](200), ptr.To[int32](44)},
+		},
+		{
+			name:        "just over the cap",
+			max:         500,
+			weight:      ptr.To[int32](256),
+			alternates:  []*int32{ptr.To[int32](200), ptr.To[int32](45)},
+			expectedErr: true,
+		},
+		{
+			name:        "unset weights count as the default weight",
+			max:         250,
+			alternates:  []*int32{nil, ptr.To[int32](51)},
+			expectedErr: true,
+		},
+		{
+			name:       "not bounded without a cap",
+			weight:     ptr.To[int32](256),
+			alternates: []*int32{ptr.To[int32](256), ptr.To[int32](256), ptr.To[int32](256)},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "foo"},
+				Spec: routev1.RouteSpec{
+					Host: "www.example.com",
+					To:   routev1.RouteTargetReference{Name: "serviceName", Kind: "Service", Weight: tc.weight},
+				},
+			}
+			for i, weight := range tc.alternates {
+				route.Spec.AlternateBackends = append(route.Spec.AlternateBackends, routev1.RouteTargetReference{Name: fmt.Sprintf("alternate-%d", i), Kind: "Service", Weight: weight})
+			}
+			errs := ValidateRoute(context.Background(), route, &testSARCreator{allow: false}, &testSecretGetter{}, routecommon.RouteValidationOptions{MaxTotalBackendWeight: tc.max})
+			if tc.expectedErr {
+				if len(errs) != 1 || errs[0].Field != "spec.alternateBackends" {
+					t.Fatalf("expected a single spec.alternateBackends error, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 0 {
+				t.Fatalf("expected no errors, got %v", errs)
+			}
+		})
+	}
+}
+
 // TestValidateHeaders verifies that validateHeaders correctly validates
 // response and request header actions in the route spec and returns the
 // appropriate error messages.
@@ -1028,6 +1425,7 @@ func TestValidateHeaders(t *testing.T) {
 	tests := []struct {
 		name                 string
 		route                *routev1.Route
+		forbiddenHeaders     []string
 		expectedErrorMessage string
 	}{
 		{
@@ -1812,12 +2210,96 @@ func TestValidateHeaders(t *testing.T) {
 			},
 			expectedErrorMessage: `spec.httpHeaders.actions.request[0].action.type: Invalid value: "Replace": type must be "Set" or "Delete"`,
 		},
+		{
+			name: "should give an error on attempt to set a header of an extended forbidden set",
+			route: &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "extended-forbidden-headers",
+					Namespace: "foo",
+				},
+				Spec: routev1.RouteSpec{
+					Host: "subdomain.example.test",
+					To:   createRouteSpecTo("serviceName", "Service"),
+					HTTPHeaders: &routev1.RouteHTTPHeaders{
+						Actions: routev1.RouteHTTPHeaderActions{
+							Request: []routev1.RouteHTTPHeader{
+								{
+									Name: "Authorization",
+									Action: routev1.RouteHTTPHeaderActionUnion{
+										Type: routev1.Set,
+										Set:  &routev1.RouteSetHTTPHeader{Value: "Bearer token"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			forbiddenHeaders:     []string{"host", "authorization"},
+			expectedErrorMessage: "spec.httpHeaders.actions.request[0].name: Forbidden: the following headers may not be modified using this API: strict-transport-security, proxy, cookie, set-cookie, host, authorization",
+		},
+		{
+			name: "should compare an extended forbidden set case-insensitively",
+			route: &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "extended-forbidden-headers",
+					Namespace: "foo",
+				},
+				Spec: routev1.RouteSpec{
+					Host: "subdomain.example.test",
+					To:   createRouteSpecTo("serviceName", "Service"),
+					HTTPHeaders: &routev1.RouteHTTPHeaders{
+						Actions: routev1.RouteHTTPHeaderActions{
+							Response: []routev1.RouteHTTPHeader{
+								{
+									Name: "host",
+									Action: routev1.RouteHTTPHeaderActionUnion{
+										Type: routev1.Delete,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			forbiddenHeaders:     []string{"Host", "proxy"},
+			expectedErrorMessage: "spec.httpHeaders.actions.response[0].name: Forbidden: the following headers may not be modified using this API: strict-transport-security, proxy, cookie, set-cookie, host",
+		},
+		{
+			name: "should still reject a default forbidden header with a configured set",
+			route: &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "configured-forbidden-headers",
+					Namespace: "foo",
+				},
+				Spec: routev1.RouteSpec{
+					Host: "subdomain.example.test",
+					To:   createRouteSpecTo("serviceName", "Service"),
+					HTTPHeaders: &routev1.RouteHTTPHeaders{
+						Actions: routev1.RouteHTTPHeaderActions{
+							Response: []routev1.RouteHTTPHeader{
+								{
+									Name: "Set-Cookie",
+									Action: routev1.RouteHTTPHeaderActionUnion{
+										Type: routev1.Set,
+										Set:  &routev1.RouteSetHTTPHeader{Value: "session=abc"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			forbiddenHeaders:     []string{"host"},
+			expectedErrorMessage: "spec.httpHeaders.actions.response[0].name: Forbidden: the following headers may not be modified using this API: strict-transport-security, proxy, cookie, set-cookie, host",
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			forbiddenHeaders := forbiddenHeaderNames(tc.forbiddenHeaders)
 			var allErrs field.ErrorList
-			allErrs = append(allErrs, validateHeaders(field.NewPath("spec", "httpHeaders", "actions", "response"), tc.route.Spec.HTTPHeaders.Actions.Response, permittedResponseHeaderValueRE, permittedResponseHeaderValueErrorMessage)...)
-			allErrs = append(allErrs, validateHeaders(field.NewPath("spec", "httpHeaders", "actions", "request"), tc.route.Spec.HTTPHeaders.Actions.Request, permittedRequestHeaderValueRE, permittedRequestHeaderValueErrorMessage)...)
+			allErrs = append(allErrs, validateHeaders(field.NewPath("spec", "httpHeaders", "actions", "response"), tc.route.Spec.HTTPHeaders.Actions.Response, HeaderDirectionResponse, forbiddenHeaders)...)
+			allErrs = append(allErrs, validateHeaders(field.NewPath("spec", "httpHeaders", "actions", "request"), tc.route.Spec.HTTPHeaders.Actions.Request, HeaderDirectionRequest, forbiddenHeaders)...)
 			var actualErrorMessage string
 			if err := allErrs.ToAggregate(); err != nil {
 				actualErrorMessage = err.Error()
@@ -1835,10 +2317,21 @@ func TestValidateHeaders(t *testing.T) {
 }
 
 func TestValidateTLS(t *testing.T) {
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey("www.example.com", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKeyPEM, err := certutil.GenerateSelfSignedCertKey("www.example.org", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	tests := []struct {
 		name           string
 		route          *routev1.Route
 		expectedErrors int
+		// expectedErrorField is the field of the first error, if set
+		expectedErrorField string
 
 		// fields for externalCertificate
 		allow  bool
@@ -2224,6 +2717,10 @@ func TestValidateTLS(t *testing.T) {
 					Namespace: "sandbox",
 				},
 				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{
+					corev1.TLSCertKey:       certPEM,
+					corev1.TLSPrivateKeyKey: keyPEM,
+				},
 			},
 			allow:          true,
 			opts:           routecommon.RouteValidationOptions{AllowExternalCertificates: true},
@@ -2251,19 +2748,193 @@ func TestValidateTLS(t *testing.T) {
 					Namespace: "sandbox",
 				},
 				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{
+					corev1.TLSCertKey:       certPEM,
+					corev1.TLSPrivateKeyKey: keyPEM,
+				},
 			},
 			allow:          true,
 			opts:           routecommon.RouteValidationOptions{AllowExternalCertificates: true},
 			expectedErrors: 0,
 		},
-	}
-
-	ctx := request.WithUser(context.Background(), &user.DefaultInfo{})
+		{
+			name: "Valid Reencrypt route with externalCertificate and destinationCACertificate",
+			route: &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "route-test",
+					Namespace: "sandbox",
+				},
+				Spec: routev1.RouteSpec{
+					TLS: &routev1.TLSConfig{
+						Termination: routev1.TLSTerminationReencrypt,
+						ExternalCertificate: &routev1.LocalObjectReference{
+							Name: "tls-secret",
+						},
+						DestinationCACertificate: "abc",
+					},
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tls-secret",
+					Namespace: "sandbox",
+				},
+				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{
+					corev1.TLSCertKey:       certPEM,
+					corev1.TLSPrivateKeyKey: keyPEM,
+				},
+			},
+			allow:          true,
+			opts:           routecommon.RouteValidationOptions{AllowExternalCertificates: true},
+			expectedErrors: 0,
+		},
+		{
+			name: "Invalid Edge route with externalCertificate containing a malformed certificate",
+			route: &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "route-test",
+					Namespace: "sandbox",
+				},
+				Spec: routev1.RouteSpec{
+					TLS: &routev1.TLSConfig{
+						Termination: routev1.TLSTerminationEdge,
+						ExternalCertificate: &routev1.LocalObjectReference{
+							Name: "tls-secret",
+						},
+					},
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tls-secret",
+					Namespace: "sandbox",
+				},
+				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{
+					corev1.TLSCertKey:       []byte("not a certificate"),
+					corev1.TLSPrivateKeyKey: keyPEM,
+				},
+			},
+			allow:          true,
+			opts:           routecommon.RouteValidationOptions{AllowExternalCertificates: true},
+			expectedErrors: 1,
+		},
+		{
+			name: "Invalid Edge route with externalCertificate containing a malformed private key",
+			route: &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "route-test",
+					Namespace: "sandbox",
+				},
+				Spec: routev1.RouteSpec{
+					TLS: &routev1.TLSConfig{
+						Termination: routev1.TLSTerminationEdge,
+						ExternalCertificate: &routev1.LocalObjectReference{
+							Name: "tls-secret",
+						},
+					},
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tls-secret",
+					Namespace: "sandbox",
+				},
+				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{
+					corev1.TLSCertKey:       certPEM,
+					corev1.TLSPrivateKeyKey: []byte("not a key"),
+				},
+			},
+			allow:          true,
+			opts:           routecommon.RouteValidationOptions{AllowExternalCertificates: true},
+			expectedErrors: 1,
+		},
+		{
+			name: "Invalid Edge route with externalCertificate containing a mismatched private key",
+			route: &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "route-test",
+					Namespace: "sandbox",
+				},
+				Spec: routev1.RouteSpec{
+					TLS: &routev1.TLSConfig{
+						Termination: routev1.TLSTerminationEdge,
+						ExternalCertificate: &routev1.LocalObjectReference{
+							Name: "tls-secret",
+						},
+					},
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tls-secret",
+					Namespace: "sandbox",
+				},
+				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{
+					corev1.TLSCertKey:       certPEM,
+					corev1.TLSPrivateKeyKey: otherKeyPEM,
+				},
+			},
+			allow:          true,
+			opts:           routecommon.RouteValidationOptions{AllowExternalCertificates: true},
+			expectedErrors: 1,
+		},
+		{
+			name: "Invalid Edge route with externalCertificate name that is not a valid secret name",
+			route: &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "route-test",
+					Namespace: "sandbox",
+				},
+				Spec: routev1.RouteSpec{
+					TLS: &routev1.TLSConfig{
+						Termination: routev1.TLSTerminationEdge,
+						ExternalCertificate: &routev1.LocalObjectReference{
+							Name: "TLS_Secret",
+						},
+					},
+				},
+			},
+			allow:              true,
+			opts:               routecommon.RouteValidationOptions{AllowExternalCertificates: true},
+			expectedErrors:     1,
+			expectedErrorField: "externalCertificate.name",
+		},
+		{
+			name: "Invalid Reencrypt route with externalCertificate name that is not a valid secret name",
+			route: &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "route-test",
+					Namespace: "sandbox",
+				},
+				Spec: routev1.RouteSpec{
+					TLS: &routev1.TLSConfig{
+						Termination: routev1.TLSTerminationReencrypt,
+						ExternalCertificate: &routev1.LocalObjectReference{
+							Name: "tls/secret",
+						},
+					},
+				},
+			},
+			allow:              true,
+			opts:               routecommon.RouteValidationOptions{AllowExternalCertificates: true},
+			expectedErrors:     1,
+			expectedErrorField: "externalCertificate.name",
+		},
+	}
+
+	ctx := request.WithUser(context.Background(), &user.DefaultInfo{})
 	for _, tc := range tests {
 		errs := validateTLS(ctx, tc.route, nil, &testSARCreator{allow: tc.allow}, &testSecretGetter{namespace: tc.route.Namespace, secret: tc.secret}, tc.opts)
 		if len(errs) != tc.expectedErrors {
 			t.Errorf("Test case %s expected %d error(s), got %d. %v", tc.name, tc.expectedErrors, len(errs), errs)
 		}
+		if len(tc.expectedErrorField) > 0 && len(errs) > 0 && (errs[0].Field != tc.expectedErrorField || errs[0].Type != field.ErrorTypeInvalid) {
+			t.Errorf("Test case %s expected an invalid value error for %s, got %v", tc.name, tc.expectedErrorField, errs[0])
+		}
 	}
 }
 
@@ -2557,14 +3228,17 @@ func TestPermittedHeaderRegexp(t *testing.T) {
 	var requestTypes = []struct {
 		description          string
 		regexp               *regexp.Regexp
+		direction            HeaderDirection
 		testInputSubstituter func(s string) string
 	}{{
 		description:          "request",
 		regexp:               permittedRequestHeaderValueRE,
+		direction:            HeaderDirectionRequest,
 		testInputSubstituter: func(s string) string { return strings.ReplaceAll(s, "XYZ", "req") },
 	}, {
 		description:          "response",
 		regexp:               permittedResponseHeaderValueRE,
+		direction:            HeaderDirectionResponse,
 		testInputSubstituter: func(s string) string { return strings.ReplaceAll(s, "XYZ", "res") },
 	}}
 
@@ -2576,6 +3250,10 @@ func TestPermittedHeaderRegexp(t *testing.T) {
 					if got := rt.regexp.MatchString(input); got != tc.validInput {
 						t.Errorf("%q: expected %v, got %t", input, tc.validInput, got)
 					}
+					// ValidateHTTPHeaderValue must agree with the regexp
+					if errs := ValidateHTTPHeaderValue(field.NewPath("value"), input, rt.direction); (len(errs) == 0) != tc.validInput {
+						t.Errorf("%q: expected %v, got %v", input, tc.validInput, errs)
+					}
 				})
 			}
 		})
@@ -2637,6 +3315,50 @@ func TestValidateInsecureEdgeTerminationPolicy(t *testing.T) {
 	}
 }
 
+func TestValidateInsecureEdgeTerminationPolicyErrorDetail(t *testing.T) {
+	tests := []struct {
+		name           string
+		termination    routev1.TLSTerminationType
+		insecure       routev1.InsecureEdgeTerminationPolicyType
+		expectedDetail string
+	}{
+		{
+			name:           "edge",
+			termination:    routev1.TLSTerminationEdge,
+			insecure:       "foobar",
+			expectedDetail: `invalid value "foobar" for InsecureEdgeTerminationPolicy option with edge termination, acceptable values are None, Allow, Redirect, or empty`,
+		},
+		{
+			name:           "reencrypt",
+			termination:    routev1.TLSTerminationReencrypt,
+			insecure:       "something else",
+			expectedDetail: `invalid value "something else" for InsecureEdgeTerminationPolicy option with reencrypt termination, acceptable values are None, Allow, Redirect, or empty`,
+		},
+		{
+			name:           "passthrough",
+			termination:    routev1.TLSTerminationPassthrough,
+			insecure:       routev1.InsecureEdgeTerminationPolicyAllow,
+			expectedDetail: `invalid value "Allow" for InsecureEdgeTerminationPolicy option with passthrough termination, acceptable values are None, Redirect, or empty`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tls := &routev1.TLSConfig{Termination: tc.termination, InsecureEdgeTerminationPolicy: tc.insecure}
+			err := validateInsecureEdgeTerminationPolicy(tls, field.NewPath("spec", "tls", "insecureEdgeTerminationPolicy"))
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if err.Detail != tc.expectedDetail {
+				t.Errorf("expected detail %q, got %q", tc.expectedDetail, err.Detail)
+			}
+			if err.BadValue != tc.insecure {
+				t.Errorf("expected bad value %q, got %v", tc.insecure, err.BadValue)
+			}
+		})
+	}
+}
+
 func TestValidateEdgeReencryptInsecureEdgeTerminationPolicy(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -2701,10 +3423,16 @@ func TestValidateEdgeReencryptInsecureEdgeTerminationPolicy(t *testing.T) {
 
 func TestWarnings(t *testing.T) {
 	for _, tc := range []struct {
-		name      string
-		host      string
-		subdomain string
-		expected  []string
+		name           string
+		host           string
+		subdomain      string
+		wildcardPolicy routev1.WildcardPolicyType
+		tls            *routev1.TLSConfig
+		port           *routev1.RoutePort
+		path           string
+		httpHeaders    *routev1.RouteHTTPHeaders
+		annotations    map[string]string
+		expected       []string
 	}{
 		{
 			name:      "both host and subdomain set",
@@ -2716,6 +3444,17 @@ func TestWarnings(t *testing.T) {
 			name: "only host set",
 			host: "foo",
 		},
+		{
+			name:           "wildcard subdomain host",
+			host:           "specific.apps.example.com",
+			wildcardPolicy: routev1.WildcardPolicySubdomain,
+			expected:       []string{"spec.wildcardPolicy is Subdomain; the route matches any host in *.apps.example.com, not only specific.apps.example.com"},
+		},
+		{
+			name:           "host without wildcard policy",
+			host:           "specific.apps.example.com",
+			wildcardPolicy: routev1.WildcardPolicyNone,
+		},
 		{
 			name:      "only subdomain set",
 			subdomain: "bar",
@@ -2723,12 +3462,284 @@ func TestWarnings(t *testing.T) {
 		{
 			name: "both host and subdomain unset",
 		},
+		{
+			name: "reencrypt with externalCertificate and destinationCACertificate",
+			tls: &routev1.TLSConfig{
+				Termination:              routev1.TLSTerminationReencrypt,
+				ExternalCertificate:      &routev1.LocalObjectReference{Name: "tls-secret"},
+				DestinationCACertificate: "abc",
+			},
+		},
+		{
+			name: "reencrypt with externalCertificate without destinationCACertificate",
+			tls: &routev1.TLSConfig{
+				Termination:         routev1.TLSTerminationReencrypt,
+				ExternalCertificate: &routev1.LocalObjectReference{Name: "tls-secret"},
+			},
+			expected: []string{"spec.tls.externalCertificate is set but spec.tls.destinationCACertificate is not; the backend certificate will be verified with the default service CA"},
+		},
+		{
+			name: "reencrypt without externalCertificate or destinationCACertificate",
+			tls: &routev1.TLSConfig{
+				Termination: routev1.TLSTerminationReencrypt,
+			},
+		},
+		{
+			name: "edge with externalCertificate",
+			tls: &routev1.TLSConfig{
+				Termination:         routev1.TLSTerminationEdge,
+				ExternalCertificate: &routev1.LocalObjectReference{Name: "tls-secret"},
+			},
+		},
+		{
+			name:      "host and subdomain set on reencrypt with externalCertificate without destinationCACertificate",
+			host:      "foo",
+			subdomain: "bar",
+			tls: &routev1.TLSConfig{
+				Termination:         routev1.TLSTerminationReencrypt,
+				ExternalCertificate: &routev1.LocalObjectReference{Name: "tls-secret"},
+			},
+			expected: []string{
+				"spec.host is set; spec.subdomain may be ignored",
+				"spec.tls.externalCertificate is set but spec.tls.destinationCACertificate is not; the backend certificate will be verified with the default service CA",
+			},
+		},
+		{
+			name:     "passthrough with http-named port",
+			tls:      &routev1.TLSConfig{Termination: routev1.TLSTerminationPassthrough},
+			port:     &routev1.RoutePort{TargetPort: intstr.FromString("http")},
+			expected: []string{`spec.port.targetPort "http" looks like a plain HTTP port; passthrough routes forward TLS connections to the backend, which must serve TLS on this port`},
+		},
+		{
+			name:     "passthrough with http-prefixed port",
+			tls:      &routev1.TLSConfig{Termination: routev1.TLSTerminationPassthrough},
+			port:     &routev1.RoutePort{TargetPort: intstr.FromString("http-alt")},
+			expected: []string{`spec.port.targetPort "http-alt" looks like a plain HTTP port; passthrough routes forward TLS connections to the backend, which must serve TLS on this port`},
+		},
+		{
+			name: "passthrough with https port",
+			tls:  &routev1.TLSConfig{Termination: routev1.TLSTerminationPassthrough},
+			port: &routev1.RoutePort{TargetPort: intstr.FromString("https")},
+		},
+		{
+			name: "passthrough with numeric port",
+			tls:  &routev1.TLSConfig{Termination: routev1.TLSTerminationPassthrough},
+			port: &routev1.RoutePort{TargetPort: intstr.FromInt32(8080)},
+		},
+		{
+			name: "passthrough without port",
+			tls:  &routev1.TLSConfig{Termination: routev1.TLSTerminationPassthrough},
+		},
+		{
+			name: "edge with http-named port",
+			tls:  &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge},
+			port: &routev1.RoutePort{TargetPort: intstr.FromString("http")},
+		},
+		{
+			name:        "set-forwarded-headers append",
+			annotations: map[string]string{"haproxy.router.openshift.io/set-forwarded-headers": "append"},
+		},
+		{
+			name:        "set-forwarded-headers replace",
+			annotations: map[string]string{"haproxy.router.openshift.io/set-forwarded-headers": "replace"},
+		},
+		{
+			name:        "set-forwarded-headers never",
+			annotations: map[string]string{"haproxy.router.openshift.io/set-forwarded-headers": "never"},
+		},
+		{
+			name:        "set-forwarded-headers if-none",
+			annotations: map[string]string{"haproxy.router.openshift.io/set-forwarded-headers": "if-none"},
+		},
+		{
+			name:        "set-forwarded-headers invalid",
+			annotations: map[string]string{"haproxy.router.openshift.io/set-forwarded-headers": "Append"},
+			expected:    []string{`metadata.annotations[haproxy.router.openshift.io/set-forwarded-headers]: unsupported value "Append"; supported values: append, if-none, never, replace`},
+		},
+		{
+			name:        "set-forwarded-headers empty",
+			annotations: map[string]string{"haproxy.router.openshift.io/set-forwarded-headers": ""},
+			expected:    []string{`metadata.annotations[haproxy.router.openshift.io/set-forwarded-headers]: unsupported value ""; supported values: append, if-none, never, replace`},
+		},
+		{
+			name:        "valid ip_whitelist",
+			annotations: map[string]string{"haproxy.router.openshift.io/ip_whitelist": "192.168.1.10 10.0.0.0/8  2001:db8::/32 ::1"},
+		},
+		{
+			name:        "valid ip_allowlist",
+			annotations: map[string]string{"haproxy.router.openshift.io/ip_allowlist": "192.168.1.10 10.0.0.0/8"},
+		},
+		{
+			name:        "malformed CIDR in ip_whitelist",
+			annotations: map[string]string{"haproxy.router.openshift.io/ip_whitelist": "192.168.1.10 10.0.0.0/33 10.1.0.0/16"},
+			expected:    []string{`metadata.annotations[haproxy.router.openshift.io/ip_whitelist]: "10.0.0.0/33" is not a valid IP address or CIDR and is ignored`},
+		},
+		{
+			name:        "malformed entries in ip_allowlist",
+			annotations: map[string]string{"haproxy.router.openshift.io/ip_allowlist": "192.168.1.300,10.0.0.1 example.com"},
+			expected: []string{
+				`metadata.annotations[haproxy.router.openshift.io/ip_allowlist]: "192.168.1.300,10.0.0.1" is not a valid IP address or CIDR and is ignored`,
+				`metadata.annotations[haproxy.router.openshift.io/ip_allowlist]: "example.com" is not a valid IP address or CIDR and is ignored`,
+			},
+		},
+		{
+			name:        "valid timeout",
+			annotations: map[string]string{"haproxy.router.openshift.io/timeout": "5m"},
+		},
+		{
+			name:        "invalid timeout-tunnel",
+			annotations: map[string]string{"haproxy.router.openshift.io/timeout-tunnel": "5 minutes"},
+			expected:    []string{`metadata.annotations[haproxy.router.openshift.io/timeout-tunnel]: "5 minutes" is not a valid timeout and is ignored; the value must be a number with an optional unit of us, ms, s, m, h or d`},
+		},
+		{
+			name:        "valid balance",
+			annotations: map[string]string{"haproxy.router.openshift.io/balance": "leastconn"},
+		},
+		{
+			name:        "invalid balance",
+			annotations: map[string]string{"haproxy.router.openshift.io/balance": "round-robin"},
+			expected:    []string{`metadata.annotations[haproxy.router.openshift.io/balance]: unsupported value "round-robin"; supported values: leastconn, random, roundrobin, source`},
+		},
+		{
+			name:        "valid rewrite-target",
+			annotations: map[string]string{"haproxy.router.openshift.io/rewrite-target": "/api/%[req.hdr(X-Version)]/"},
+		},
+		{
+			name:        "rewrite-target with control characters",
+			annotations: map[string]string{"haproxy.router.openshift.io/rewrite-target": "/api\n/v1"},
+			expected:    []string{`metadata.annotations[haproxy.router.openshift.io/rewrite-target]: "/api\n/v1" must not contain control characters`},
+		},
+		{
+			name:        "rewrite-target with unterminated expression",
+			annotations: map[string]string{"haproxy.router.openshift.io/rewrite-target": "/api/%[req.hdr(X-Version)/"},
+			expected:    []string{`metadata.annotations[haproxy.router.openshift.io/rewrite-target]: "/api/%[req.hdr(X-Version)/" has an unterminated %[ expression`},
+		},
+		{
+			name:        "redirect insecure policy with rewrite-target",
+			tls:         &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect},
+			annotations: map[string]string{"haproxy.router.openshift.io/rewrite-target": "/"},
+			expected:    []string{"spec.tls.insecureEdgeTerminationPolicy is Redirect and metadata.annotations[haproxy.router.openshift.io/rewrite-target] is set; redirects from the backend to the rewritten path may cause a redirect loop"},
+		},
+		{
+			name: "redirect insecure policy without rewrite-target",
+			tls:  &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect},
+		},
+		{
+			name:        "allow insecure policy with rewrite-target",
+			tls:         &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyAllow},
+			annotations: map[string]string{"haproxy.router.openshift.io/rewrite-target": "/"},
+			expected:    []string{"spec.tls.insecureEdgeTerminationPolicy is Allow; the route is also served over plain HTTP, consider Redirect to send insecure requests to HTTPS"},
+		},
+		{
+			name:     "edge with allow insecure policy",
+			tls:      &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyAllow},
+			expected: []string{"spec.tls.insecureEdgeTerminationPolicy is Allow; the route is also served over plain HTTP, consider Redirect to send insecure requests to HTTPS"},
+		},
+		{
+			name:     "reencrypt with allow insecure policy",
+			tls:      &routev1.TLSConfig{Termination: routev1.TLSTerminationReencrypt, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyAllow},
+			expected: []string{"spec.tls.insecureEdgeTerminationPolicy is Allow; the route is also served over plain HTTP, consider Redirect to send insecure requests to HTTPS"},
+		},
+		{
+			name: "edge with redirect insecure policy",
+			tls:  &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect},
+		},
+		{
+			name: "edge with none insecure policy",
+			tls:  &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyNone},
+		},
+		{
+			name: "reencrypt with none insecure policy",
+			tls:  &routev1.TLSConfig{Termination: routev1.TLSTerminationReencrypt, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyNone},
+		},
+		{
+			name: "normalized path",
+			path: "/a/b/",
+		},
+		{
+			name: "root path",
+			path: "/",
+		},
+		{
+			name:     "path with double slash",
+			path:     "/a//b",
+			expected: []string{`spec.path "/a//b" contains redundant segments; the router matches the path as is, consider "/a/b"`},
+		},
+		{
+			name:     "path with dot segment",
+			path:     "/a/./b/",
+			expected: []string{`spec.path "/a/./b/" contains redundant segments; the router matches the path as is, consider "/a/b/"`},
+		},
+		{
+			name:     "path with dot dot segment",
+			path:     "/a/../b",
+			expected: []string{`spec.path "/a/../b" contains redundant segments; the router matches the path as is, consider "/b"`},
+		},
+		{
+			name:     "path with trailing dot segment",
+			path:     "/a/.",
+			expected: []string{`spec.path "/a/." contains redundant segments; the router matches the path as is, consider "/a"`},
+		},
+		{
+			name:     "path with leading double slash",
+			path:     "//",
+			expected: []string{`spec.path "//" contains redundant segments; the router matches the path as is, consider "/"`},
+		},
+		{
+			name: "same header set in request and response",
+			httpHeaders: &routev1.RouteHTTPHeaders{
+				Actions: routev1.RouteHTTPHeaderActions{
+					Request: []routev1.RouteHTTPHeader{
+						{Name: "X-Frame-Options", Action: routev1.RouteHTTPHeaderActionUnion{Type: routev1.Set, Set: &routev1.RouteSetHTTPHeader{Value: "DENY"}}},
+					},
+					Response: []routev1.RouteHTTPHeader{
+						{Name: "Cache-Control", Action: routev1.RouteHTTPHeaderActionUnion{Type: routev1.Set, Set: &routev1.RouteSetHTTPHeader{Value: "no-cache"}}},
+						{Name: "x-frame-options", Action: routev1.RouteHTTPHeaderActionUnion{Type: routev1.Set, Set: &routev1.RouteSetHTTPHeader{Value: "DENY"}}},
+					},
+				},
+			},
+			expected: []string{`spec.httpHeaders.actions.request[0] and spec.httpHeaders.actions.response[1] both set header "x-frame-options"; the request header is sent to the backend and the response header to the client, make sure both are intended`},
+		},
+		{
+			name: "different headers set in request and response",
+			httpHeaders: &routev1.RouteHTTPHeaders{
+				Actions: routev1.RouteHTTPHeaderActions{
+					Request: []routev1.RouteHTTPHeader{
+						{Name: "X-Forwarded-Client-Cert", Action: routev1.RouteHTTPHeaderActionUnion{Type: routev1.Set, Set: &routev1.RouteSetHTTPHeader{Value: "%{+Q}[ssl_c_der,base64]"}}},
+					},
+					Response: []routev1.RouteHTTPHeader{
+						{Name: "X-Frame-Options", Action: routev1.RouteHTTPHeaderActionUnion{Type: routev1.Set, Set: &routev1.RouteSetHTTPHeader{Value: "DENY"}}},
+					},
+				},
+			},
+		},
+		{
+			name: "same header set in request and deleted in response",
+			httpHeaders: &routev1.RouteHTTPHeaders{
+				Actions: routev1.RouteHTTPHeaderActions{
+					Request: []routev1.RouteHTTPHeader{
+						{Name: "X-Debug", Action: routev1.RouteHTTPHeaderActionUnion{Type: routev1.Set, Set: &routev1.RouteSetHTTPHeader{Value: "1"}}},
+					},
+					Response: []routev1.RouteHTTPHeader{
+						{Name: "X-Debug", Action: routev1.RouteHTTPHeaderActionUnion{Type: routev1.Delete}},
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			actual := Warnings(&routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: tc.annotations,
+				},
 				Spec: routev1.RouteSpec{
-					Host:      tc.host,
-					Subdomain: tc.subdomain,
+					Host:           tc.host,
+					Subdomain:      tc.subdomain,
+					WildcardPolicy: tc.wildcardPolicy,
+					TLS:            tc.tls,
+					Port:           tc.port,
+					Path:           tc.path,
+					HTTPHeaders:    tc.httpHeaders,
 				},
 			})
 			if len(actual) != len(tc.expected) {
@@ -2742,3 +3753,176 @@ func TestWarnings(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRouteComplete(t *testing.T) {
+	tests := []struct {
+		name               string
+		route              *routev1.Route
+		expectedHost       string
+		expectedSubdomain  string
+		expectedErrors     int
+		expectedWarnings   int
+		expectedInputError bool
+	}{
+		{
+			name: "uppercase host is lowercased",
+			route: &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "foo"},
+				Spec: routev1.RouteSpec{
+					Host: "WWW.Example.COM",
+					To:   createRouteSpecTo("serviceName", "Service"),
+				},
+			},
+			expectedHost:       "www.example.com",
+			expectedInputError: true,
+		},
+		{
+			name: "errors and warnings of the normalized route",
+			route: &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "foo", Annotations: map[string]string{"haproxy.router.openshift.io/balance": "fastest"}},
+				Spec: routev1.RouteSpec{
+					Host:      "Host.Example.com",
+					Subdomain: "Sub",
+					To:        createRouteSpecTo("serviceName", "Service"),
+					TLS:       &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: "foobar"},
+				},
+			},
+			expectedHost:       "host.example.com",
+			expectedSubdomain:  "sub",
+			expectedErrors:     1,
+			expectedWarnings:   2,
+			expectedInputError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			input := tc.route.DeepCopy()
+			opts := routecommon.RouteValidationOptions{}
+			errs, warnings, normalized := ValidateRouteComplete(context.Background(), tc.route, &testSARCreator{allow: false}, &testSecretGetter{}, opts)
+
+			if !reflect.DeepEqual(tc.route, input) {
+				t.Errorf("expected the input route not to be modified")
+			}
+			if normalized.Spec.Host != tc.expectedHost || normalized.Spec.Subdomain != tc.expectedSubdomain {
+				t.Errorf("expected host %q and subdomain %q, got %q and %q", tc.expectedHost, tc.expectedSubdomain, normalized.Spec.Host, normalized.Spec.Subdomain)
+			}
+			if len(errs) != tc.expectedErrors {
+				t.Errorf("expected %d errors, got %v", tc.expectedErrors, errs)
+			}
+			if len(warnings) != tc.expectedWarnings {
+				t.Errorf("expected %d warnings, got %v", tc.expectedWarnings, warnings)
+			}
+
+			// the results match the individual functions on the normalized route
+			if expected := ValidateRoute(context.Background(), normalized, &testSARCreator{allow: false}, &testSecretGetter{}, opts); !reflect.DeepEqual(errs, expected) {
+				t.Errorf("expected errors %v, got %v", expected, errs)
+			}
+			if expected := Warnings(normalized); !reflect.DeepEqual(warnings, expected) {
+				t.Errorf("expected warnings %v, got %v", expected, warnings)
+			}
+			if inputErrs := ValidateRoute(context.Background(), tc.route, &testSARCreator{allow: false}, &testSecretGetter{}, opts); (len(inputErrs) > len(errs)) != tc.expectedInputError {
+				t.Errorf("expected the unnormalized route to have more errors: %v", inputErrs)
+			}
+		})
+	}
+}
+
+func TestWarningsCertificateHostMismatch(t *testing.T) {
+	newCertificate := func(host string, alternateDNS ...string) string {
+		certPEM, _, err := certutil.GenerateSelfSignedCertKey(host, nil, alternateDNS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(certPEM)
+	}
+	exampleCert := newCertificate("www.example.com", "api.example.com")
+	wildcardCert := newCertificate("*.example.com")
+
+	tests := []struct {
+		name             string
+		host             string
+		tls              *routev1.TLSConfig
+		disabled         bool
+		expectedWarnings int
+	}{
+		{
+			name: "exact match",
+			host: "www.example.com",
+			tls:  &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, Certificate: exampleCert},
+		},
+		{
+			name: "exact match of an alternate name, case-insensitive",
+			host: "API.example.com",
+			tls:  &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, Certificate: exampleCert},
+		},
+		{
+			name: "wildcard match",
+			host: "foo.example.com",
+			tls:  &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, Certificate: wildcardCert},
+		},
+		{
+			name:             "wildcard does not match the domain itself",
+			host:             "example.com",
+			tls:              &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, Certificate: wildcardCert},
+			expectedWarnings: 1,
+		},
+		{
+			name:             "wildcard does not match nested subdomains",
+			host:             "bar.foo.example.com",
+			tls:              &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, Certificate: wildcardCert},
+			expectedWarnings: 1,
+		},
+		{
+			name:             "mismatch",
+			host:             "www.example.org",
+			tls:              &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, Certificate: exampleCert},
+			expectedWarnings: 1,
+		},
+		{
+			name:             "mismatch with reencrypt termination",
+			host:             "www.example.org",
+			tls:              &routev1.TLSConfig{Termination: routev1.TLSTerminationReencrypt, Certificate: exampleCert, DestinationCACertificate: testDestinationCACertificate},
+			expectedWarnings: 1,
+		},
+		{
+			name:     "mismatch is not reported when the check is disabled",
+			host:     "www.example.org",
+			tls:      &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, Certificate: exampleCert},
+			disabled: true,
+		},
+		{
+			name: "passthrough is skipped",
+			host: "www.example.org",
+			tls:  &routev1.TLSConfig{Termination: routev1.TLSTerminationPassthrough, Certificate: exampleCert},
+		},
+		{
+			name: "invalid certificate is skipped",
+			host: "www.example.org",
+			tls:  &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, Certificate: "dummy"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "foo"},
+				Spec: routev1.RouteSpec{
+					Host: tc.host,
+					To:   createRouteSpecTo("serviceName", "Service"),
+					TLS:  tc.tls,
+				},
+			}
+			opts := routecommon.RouteValidationOptions{WarnOnCertificateHostMismatch: !tc.disabled}
+			warnings := WarningsWithOptions(route, opts)
+			if len(warnings) != tc.expectedWarnings {
+				t.Errorf("expected %d warnings, got %v", tc.expectedWarnings, warnings)
+			}
+			for _, warning := range warnings {
+				if !strings.Contains(warning, "spec.tls.certificate") {
+					t.Errorf("unexpected warning: %s", warning)
+				}
+			}
+		})
+	}
+}